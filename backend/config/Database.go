@@ -17,13 +17,14 @@ func DB() *gorm.DB {
 
 func ConnectionDB() {
 
-	database, err := gorm.Open(sqlite.Open("testDB.db"), &gorm.Config{
+	database, err := gorm.Open(sqlite.Open("testDB.db?_busy_timeout=5000"), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
 	})
 	if err != nil {
 		panic("failed to connect")
 	}
 	fmt.Println("Connect to database successfully")
+	registerPIICallbacks(database)
 	db = database
 }
 
@@ -52,6 +53,36 @@ func SetupDatabase() {
 		&entity.Payment{},
 		&entity.Bill{},
 		&entity.BillStatus{},
+		&entity.BillItem{},
+		&entity.Installment{},
+		&entity.ReceiptSubmission{},
+		&entity.ReceiptSequence{},
+		&entity.OfficialReceipt{},
+		&entity.Hold{},
+		&entity.RefundPolicy{},
+		&entity.RefundRequest{},
+		&entity.ProfileChangeRequest{},
+		&entity.ProfileChangeAttachment{},
+		&entity.ProgramTransfer{},
+		&entity.TransferCreditMapping{},
+		&entity.StudentStatusLog{},
+		&entity.StatusRequest{},
+		&entity.StatusRequestDocument{},
+		&entity.StudentIDSequence{},
+		&entity.AuditEvent{},
+		&entity.DataSubjectRequest{},
+		&entity.StudentDocument{},
+		&entity.ReportWorkflowStep{},
+		&entity.ReportStatusLog{},
+		&entity.ReportFieldValue{},
+		&entity.ReportActionLog{},
+		&entity.BillWaiver{},
+		&entity.BankStatementImport{},
+		&entity.BankTransaction{},
+		&entity.Scholarship{},
+		&entity.StudentScholarship{},
+		&entity.FeeSchedule{},
+		&entity.LabFee{},
 
 		&entity.Grades{},
 		&entity.Scores{},
@@ -62,4 +93,6 @@ func SetupDatabase() {
 		&entity.Reviewer{},
 		&entity.ReviewerComment{},
 	)	
+	encryptLegacyCitizenIDs(db)
+	rehashCitizenIDs(db)
 }
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"os"
+	"strconv"
+)
+
+// ค่าตั้งต้นของการคิดค่าปรับชำระล่าช้า (แก้ได้ผ่าน environment variable)
+const (
+	defaultLateFeeGraceDays = 7
+	defaultLateFeeAmount    = 500
+)
+
+func envInt(key string, def int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil && v >= 0 {
+		return v
+	}
+	return def
+}
+
+// จำนวนวันผ่อนผันหลังวันครบกำหนดก่อนคิดค่าปรับ (LATE_FEE_GRACE_DAYS)
+func LateFeeGraceDays() int {
+	return envInt("LATE_FEE_GRACE_DAYS", defaultLateFeeGraceDays)
+}
+
+// ค่าปรับชำระล่าช้าต่องวด (LATE_FEE_AMOUNT) 0 = ไม่คิดค่าปรับ
+func LateFeeAmount() int {
+	return envInt("LATE_FEE_AMOUNT", defaultLateFeeAmount)
+}
+
+// กำหนดชำระของงวดเพิ่มที่สร้างเมื่อยอดบิลเพิ่มหลังชำระงวดสุดท้ายแล้ว นับจากวันนี้หรือวันครบกำหนดงวดสุดท้าย (INSTALLMENT_TOPUP_DUE_DAYS)
+func InstallmentTopUpDueDays() int {
+	return envInt("INSTALLMENT_TOPUP_DUE_DAYS", 30)
+}
+
+// รอบการคิดค่าปรับอัตโนมัติ หน่วยชั่วโมง (LATE_FEE_SWEEP_HOURS) 0 = ไม่รันอัตโนมัติ ใช้ POST /bills/admin/late-fees แทน
+func LateFeeSweepHours() int {
+	return envInt("LATE_FEE_SWEEP_HOURS", 24)
+}
+
+// หมายเลขพร้อมเพย์ของมหาวิทยาลัยสำหรับรับโอน (PROMPTPAY_ID)
+// เบอร์โทรศัพท์ 10 หลัก, เลขประจำตัวผู้เสียภาษี 13 หลัก หรือ e-Wallet 15 หลัก
+func PromptPayID() string {
+	return os.Getenv("PROMPTPAY_ID")
+}
+
+// Biller ID สำหรับ QR ชำระบิล (PROMPTPAY_BILLER_ID) ถ้ากำหนดจะใช้ Tag 30 แทนการโอนเงินแบบ Tag 29
+func PromptPayBillerID() string {
+	return os.Getenv("PROMPTPAY_BILLER_ID")
+}
+
+// ช่วงวันที่ (±วัน) ที่ใช้จับคู่รายการเดินบัญชีกับหลักฐานที่อัปโหลด (RECONCILE_WINDOW_DAYS)
+func ReconcileWindowDays() int {
+	return envInt("RECONCILE_WINDOW_DAYS", 3)
+}
+
+// ไฟล์ฟอนต์ TrueType ที่รองรับภาษาไทยสำหรับใบเสร็จ PDF (RECEIPT_FONT_PATH)
+// ถ้าไม่กำหนดจะพิมพ์ใบเสร็จด้วยฟอนต์มาตรฐานเป็นภาษาอังกฤษ
+func ReceiptFontPath() string {
+	return os.Getenv("RECEIPT_FONT_PATH")
+}
+
+// ชื่อหน่วยงานผู้ออกใบเสร็จ (RECEIPT_ISSUER_NAME) และเลขประจำตัวผู้เสียภาษี (RECEIPT_TAX_ID)
+func ReceiptIssuerName() string {
+	if v := os.Getenv("RECEIPT_ISSUER_NAME"); v != "" {
+		return v
+	}
+	return "Registration Office"
+}
+
+func ReceiptTaxID() string {
+	return os.Getenv("RECEIPT_TAX_ID")
+}
+
+// จำนวนวันครบกำหนดชำระของบิลนับจากวันที่ออกบิล (BILL_DUE_DAYS)
+func BillDueDays() int {
+	return envInt("BILL_DUE_DAYS", 30)
+}
+
+// จำนวนวันหลังครบกำหนดชำระที่จะระงับสิทธิ์นักศึกษาอัตโนมัติ (HOLD_OVERDUE_DAYS)
+// บิลที่ไม่ได้ผ่อนชำระนับจากวันครบกำหนดของบิล
+func HoldOverdueDays() int {
+	return envInt("HOLD_OVERDUE_DAYS", 30)
+}
+
+// ค่ารักษาสภาพนักศึกษาต่อภาคการศึกษาที่ลาพัก (LEAVE_MAINTENANCE_FEE)
+func LeaveMaintenanceFee() int {
+	return envInt("LEAVE_MAINTENANCE_FEE", 1000)
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"os"
+	"sync"
+)
+
+var ErrStudentCardKey = errors.New("STUDENT_CARD_SIGNING_KEY is not configured")
+
+var (
+	cardKeyOnce sync.Once
+	cardKey     ed25519.PrivateKey
+	cardKeyErr  error
+)
+
+// กุญแจ Ed25519 สำหรับลงนาม QR บนบัตรนักศึกษา (STUDENT_CARD_SIGNING_KEY เป็น seed 32 ไบต์แบบ base64)
+// ไม่มีกุญแจสำรอง ถ้าไม่ได้ตั้งค่าระบบจะไม่ออกบัตรและไม่เผยแพร่กุญแจสาธารณะ
+func StudentCardKey() (ed25519.PrivateKey, error) {
+	cardKeyOnce.Do(func() {
+		v := os.Getenv("STUDENT_CARD_SIGNING_KEY")
+		if v == "" {
+			cardKeyErr = ErrStudentCardKey
+			return
+		}
+		seed, err := base64.StdEncoding.DecodeString(v)
+		if err != nil || len(seed) != ed25519.SeedSize {
+			log.Println("STUDENT_CARD_SIGNING_KEY must be a 32-byte seed encoded in base64")
+			cardKeyErr = ErrStudentCardKey
+			return
+		}
+		cardKey = ed25519.NewKeyFromSeed(seed)
+	})
+	return cardKey, cardKeyErr
+}
+
+// กุญแจสาธารณะที่เผยแพร่ให้เครื่องตรวจบัตรใช้ตรวจลายเซ็นแบบออฟไลน์
+func StudentCardPublicKey() (ed25519.PublicKey, error) {
+	key, err := StudentCardKey()
+	if err != nil {
+		return nil, err
+	}
+	return key.Public().(ed25519.PublicKey), nil
+}
+
+// รหัสกุญแจ (8 ไบต์แรกของ SHA-256 ของกุญแจสาธารณะ) ใช้บอกว่าบัตรลงนามด้วยกุญแจใด
+func StudentCardKeyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:8])
+}
+
+// อายุบัตร/QR นับจากวันที่ออกบัตร หน่วยวัน (STUDENT_CARD_VALID_DAYS)
+func StudentCardValidDays() int {
+	return envInt("STUDENT_CARD_VALID_DAYS", 365)
+}
+
+// URL หน้าตรวจบัตร (STUDENT_CARD_VERIFY_URL) ถ้ากำหนด QR จะเป็น URL นี้ต่อด้วย token
+// เพื่อให้สแกนด้วยโทรศัพท์แล้วเปิดหน้าตรวจได้ทันที ถ้าไม่กำหนด QR จะมีเฉพาะ token
+func StudentCardVerifyURL() string {
+	return os.Getenv("STUDENT_CARD_VERIFY_URL")
+}
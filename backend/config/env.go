@@ -0,0 +1,8 @@
+package config
+
+import "os"
+
+// โหมดพัฒนา (APP_ENV=development) อนุญาตให้ใช้กุญแจสำหรับพัฒนาแทนกุญแจจริงที่ต้องตั้งค่าใน production
+func DevMode() bool {
+	return os.Getenv("APP_ENV") == "development"
+}
@@ -0,0 +1,320 @@
+package config
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+
+	"reg_system/entity"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// ค่าที่เข้ารหัสแล้วขึ้นต้นด้วย prefix นี้ ค่าที่ไม่มี prefix ถือเป็นข้อมูลเดิมที่ยังไม่เข้ารหัส
+const piiPrefix = "enc:v1:"
+
+var (
+	piiKeyOnce sync.Once
+	piiKey     []byte
+	piiHashKey []byte
+	piiKeyErr  error
+)
+
+// info ของ HKDF สำหรับกุญแจ HMAC ที่ใช้ทำ hash (แยกจากกุญแจเข้ารหัส AES-GCM)
+const piiHashKeyInfo = "reg_system pii hash key v1"
+
+// กุญแจเข้ารหัสข้อมูลส่วนบุคคล AES-256 (PII_ENCRYPTION_KEY เป็น base64 ขนาด 32 ไบต์)
+// ต้องตั้งค่าเสมอ ยกเว้นโหมดพัฒนา (APP_ENV=development) ที่ใช้กุญแจสำหรับพัฒนาได้
+func loadPIIKey() ([]byte, error) {
+	piiKeyOnce.Do(func() {
+		defer func() {
+			if piiKeyErr == nil {
+				piiHashKey, piiKeyErr = hkdf.Key(sha256.New, piiKey, nil, piiHashKeyInfo, 32)
+			}
+		}()
+		if v := os.Getenv("PII_ENCRYPTION_KEY"); v != "" {
+			key, err := base64.StdEncoding.DecodeString(v)
+			if err != nil || len(key) != 32 {
+				piiKeyErr = errors.New("PII_ENCRYPTION_KEY must be 32 bytes encoded in base64")
+				return
+			}
+			piiKey = key
+			return
+		}
+		if !DevMode() {
+			piiKeyErr = errors.New("PII_ENCRYPTION_KEY is not set (set APP_ENV=development to use the development key)")
+			return
+		}
+		log.Println("WARNING: PII_ENCRYPTION_KEY is not set, using the development key")
+		sum := sha256.Sum256([]byte("reg_system development pii key"))
+		piiKey = sum[:]
+	})
+	return piiKey, piiKeyErr
+}
+
+// ตรวจกุญแจตอนเริ่มเซิร์ฟเวอร์ ถ้าไม่มีกุญแจจะไม่เริ่มทำงาน
+func RequirePIIKey() error {
+	_, err := loadPIIKey()
+	return err
+}
+
+func piiKeyBytes() []byte {
+	key, err := loadPIIKey()
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+func piiHashKeyBytes() []byte {
+	piiKeyBytes()
+	return piiHashKey
+}
+
+// ผู้ดูแลที่ดูเลขประจำตัวประชาชนเต็มได้ (PII_REVEAL_ADMINS คั่นด้วย ,) ไม่ได้ตั้งค่า = ไม่มีใครดูได้
+func CanRevealPII(username string) bool {
+	v := os.Getenv("PII_REVEAL_ADMINS")
+	if v == "" {
+		return false
+	}
+	for _, u := range strings.Split(v, ",") {
+		if strings.TrimSpace(u) == username {
+			return true
+		}
+	}
+	return false
+}
+
+func EncryptPII(plain string) (string, error) {
+	if plain == "" {
+		return "", nil
+	}
+	block, err := aes.NewCipher(piiKeyBytes())
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plain), nil)
+	return piiPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func DecryptPII(value string) (string, error) {
+	if !strings.HasPrefix(value, piiPrefix) {
+		return value, nil
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, piiPrefix))
+	if err != nil {
+		return "", err
+	}
+	block, err := aes.NewCipher(piiKeyBytes())
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("encrypted value is too short")
+	}
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+// HMAC ของข้อมูลส่วนบุคคล ใช้ค้นหา/ตรวจซ้ำโดยไม่ต้องถอดรหัส
+// กุญแจ HMAC สร้างจากกุญแจหลักด้วย HKDF จึงไม่ใช้กุญแจเดียวกับการเข้ารหัส
+func HashPII(plain string) string {
+	if plain == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, piiHashKeyBytes())
+	mac.Write([]byte("citizen-id:" + plain))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// serializer:pii เข้ารหัสฟิลด์ชนิด string ตอนบันทึกและถอดรหัสตอนอ่าน
+type PIISerializer struct{}
+
+func (PIISerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var stored string
+	switch v := dbValue.(type) {
+	case nil:
+	case string:
+		stored = v
+	case []byte:
+		stored = string(v)
+	default:
+		return fmt.Errorf("unsupported pii value %T", dbValue)
+	}
+	plain, err := DecryptPII(stored)
+	if err != nil {
+		return err
+	}
+	value := reflect.New(field.FieldType).Elem()
+	value.SetString(plain)
+	field.ReflectValueOf(ctx, dst).Set(value)
+	return nil
+}
+
+func (PIISerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	return EncryptPII(reflect.ValueOf(fieldValue).String())
+}
+
+func init() {
+	schema.RegisterSerializer("pii", PIISerializer{})
+}
+
+// เติม CitizenIDHash ให้ตรงกับ CitizenID ทุกครั้งที่บันทึก (struct, slice หรือ map ของ Updates)
+func citizenIDHashCallback(db *gorm.DB) {
+	stmt := db.Statement
+	if stmt.Schema == nil || db.Error != nil {
+		return
+	}
+	cidField := stmt.Schema.LookUpField("CitizenID")
+	hashField := stmt.Schema.LookUpField("CitizenIDHash")
+	if cidField == nil || hashField == nil {
+		return
+	}
+
+	// Updates ด้วย map ไม่ผ่าน serializer จึงเข้ารหัสค่าที่นี่
+	if m, ok := stmt.Dest.(map[string]interface{}); ok {
+		for _, key := range []string{cidField.DBName, cidField.Name} {
+			v, ok := m[key]
+			if !ok {
+				continue
+			}
+			plain, err := DecryptPII(fmt.Sprint(v))
+			if err != nil {
+				db.AddError(err)
+				return
+			}
+			encrypted, err := EncryptPII(plain)
+			if err != nil {
+				db.AddError(err)
+				return
+			}
+			m[key] = encrypted
+			m[hashField.DBName] = HashPII(plain)
+		}
+		return
+	}
+
+	setHash := func(v reflect.Value) {
+		v = reflect.Indirect(v)
+		if v.Kind() != reflect.Struct || v.Type() != stmt.Schema.ModelType {
+			return
+		}
+		if cid := cidField.ReflectValueOf(stmt.Context, v).String(); cid != "" {
+			db.AddError(hashField.Set(stmt.Context, v, HashPII(cid)))
+		}
+	}
+	rv := reflect.Indirect(reflect.ValueOf(stmt.Dest))
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			setHash(rv.Index(i))
+		}
+	case reflect.Struct:
+		setHash(rv)
+	}
+}
+
+func registerPIICallbacks(db *gorm.DB) {
+	db.Callback().Create().Before("gorm:create").Register("pii:citizen_id_hash", citizenIDHashCallback)
+	db.Callback().Update().Before("gorm:update").Register("pii:citizen_id_hash", citizenIDHashCallback)
+}
+
+// คำนวณ CitizenIDHash ใหม่ให้ตรงกับกุญแจ hash ปัจจุบัน (เช่น ข้อมูลที่ hash ด้วยกุญแจเข้ารหัสก่อนแยกกุญแจ)
+func rehashCitizenIDs(db *gorm.DB) {
+	for _, t := range []struct {
+		table string
+		pk    string
+	}{
+		{"students", "student_id"},
+		{"teachers", "teacher_id"},
+		{"admins", "admin_id"},
+	} {
+		var rows []struct {
+			ID            string
+			CitizenID     string
+			CitizenIDHash string
+		}
+		if err := db.Table(t.table).Select(t.pk + " AS id, citizen_id, citizen_id_hash").
+			Where("citizen_id <> ''").Scan(&rows).Error; err != nil {
+			log.Println("rehash citizen IDs:", err)
+			continue
+		}
+		for _, r := range rows {
+			plain, err := DecryptPII(r.CitizenID)
+			if err != nil {
+				log.Println("rehash citizen IDs:", err)
+				continue
+			}
+			if hash := HashPII(plain); hash != r.CitizenIDHash {
+				if err := db.Table(t.table).Where(t.pk+" = ?", r.ID).Update("citizen_id_hash", hash).Error; err != nil {
+					log.Println("rehash citizen IDs:", err)
+				}
+			}
+		}
+	}
+}
+
+// เข้ารหัสเลขประจำตัวประชาชนที่ยังเป็นข้อความธรรมดา (ข้อมูลก่อนเปิดใช้การเข้ารหัส) และเติม hash
+func encryptLegacyCitizenIDs(db *gorm.DB) {
+	for _, t := range []struct {
+		model interface{}
+		table string
+		pk    string
+	}{
+		{&entity.Students{}, "students", "student_id"},
+		{&entity.Teachers{}, "teachers", "teacher_id"},
+		{&entity.Admins{}, "admins", "admin_id"},
+	} {
+		var rows []struct {
+			ID        string
+			CitizenID string
+		}
+		if err := db.Table(t.table).Select(t.pk+" AS id, citizen_id").
+			Where("citizen_id <> '' AND (citizen_id NOT LIKE ? OR citizen_id_hash IS NULL OR citizen_id_hash = '')", piiPrefix+"%").
+			Scan(&rows).Error; err != nil {
+			log.Println("encrypt legacy citizen IDs:", err)
+			continue
+		}
+		for _, r := range rows {
+			plain, err := DecryptPII(r.CitizenID)
+			if err != nil {
+				log.Println("encrypt legacy citizen IDs:", err)
+				continue
+			}
+			if err := db.Model(t.model).Unscoped().Where(t.pk+" = ?", r.ID).
+				Updates(map[string]interface{}{"citizen_id": entity.CitizenID(plain)}).Error; err != nil {
+				log.Println("encrypt legacy citizen IDs:", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package config
+
+import "os"
+
+// จำนวนภาคการศึกษาที่ลาพักได้รวมตลอดหลักสูตร (MAX_LEAVE_TERMS)
+func MaxLeaveTerms() int {
+	return envInt("MAX_LEAVE_TERMS", 4)
+}
+
+// รูปแบบรหัสนักศึกษาที่ระบบออกให้ (STUDENT_ID_PATTERN)
+// {DEGREE} ตัวอักษรนำหน้าของระดับการศึกษา, {YY} ปีที่เข้าศึกษา (พ.ศ.) 2 หลัก,
+// {FACULTY} รหัสคณะ 2 ตัว, {SEQ:n} เลขรัน n หลัก ส่วนอื่นเป็นตัวอักษรตามที่เขียน
+func StudentIDPattern() string {
+	if v := os.Getenv("STUDENT_ID_PATTERN"); v != "" {
+		return v
+	}
+	return "{DEGREE}{YY}{SEQ:5}"
+}
+
+// ขนาดไฟล์เอกสารนักศึกษาสูงสุด หน่วยกิโลไบต์ (DOCUMENT_MAX_SIZE_KB)
+func DocumentMaxSize() int64 {
+	return int64(envInt("DOCUMENT_MAX_SIZE_KB", 5120)) * 1024
+}
+
+// ขนาดรูปถ่ายนักศึกษาสูงสุด หน่วยกิโลไบต์ (PHOTO_MAX_SIZE_KB)
+func PhotoMaxSize() int64 {
+	return int64(envInt("PHOTO_MAX_SIZE_KB", 2048)) * 1024
+}
+
+// ความกว้าง/สูงสูงสุดของรูปถ่าย หน่วยพิกเซล (PHOTO_MAX_DIMENSION)
+// ตรวจจากหัวไฟล์ก่อนถอดรหัสรูป ไฟล์เล็กที่ประกาศขนาดรูปใหญ่มากจะไม่ถูกโหลดเข้าหน่วยความจำ
+func PhotoMaxDimension() int {
+	return envInt("PHOTO_MAX_DIMENSION", 6000)
+}
+
+// รอบการตรวจการย้ายสาขาและคำร้องเปลี่ยนสถานภาพที่ถึงภาคการศึกษาที่มีผล หน่วยชั่วโมง (EFFECTIVE_CHANGE_SWEEP_HOURS) 0 = ไม่รันอัตโนมัติ
+func EffectiveChangeSweepHours() int {
+	return envInt("EFFECTIVE_CHANGE_SWEEP_HOURS", 1)
+}
+
+// ชื่อผู้ใช้ของเจ้าหน้าที่ทะเบียนที่พิจารณาคำร้องขั้น registrar (REPORT_REGISTRAR_USERNAME)
+func ReportRegistrarUsername() string {
+	if v := os.Getenv("REPORT_REGISTRAR_USERNAME"); v != "" {
+		return v
+	}
+	return "admin"
+}
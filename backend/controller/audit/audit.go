@@ -0,0 +1,101 @@
+package audit
+
+import (
+	"errors"
+	"net/http"
+
+	"reg_system/config"
+	"reg_system/entity"
+	"reg_system/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ส่งเลขประจำตัวประชาชนเต็มของบุคคล (subject เช่น students/B6616052) และบันทึก AuditEvent
+// ?reason= เหตุผลที่ขอดู (บันทึกไว้ในประวัติ)
+func revealCitizenID(c *gin.Context, subject string, load func(db *gorm.DB) (entity.CitizenID, error)) {
+	claims := services.CurrentClaims(c)
+	if !config.CanRevealPII(claims.Username) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not authorized to reveal personal data"})
+		return
+	}
+
+	db := config.DB()
+	var cid entity.CitizenID
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if cid, err = load(tx); err != nil {
+			return err
+		}
+		return services.RecordAudit(tx, &entity.AuditEvent{
+			Actor:   claims.Username,
+			Role:    claims.Role,
+			Action:  entity.AuditPIIReveal,
+			Subject: subject,
+			Field:   "CitizenID",
+			Reason:  c.Query("reason"),
+			IP:      c.ClientIP(),
+		})
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "record not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"subject": subject, "CitizenID": string(cid)})
+}
+
+// GET /students/:id/citizen-id - ดูเลขประจำตัวประชาชนเต็มของนักศึกษา
+func RevealStudentCitizenID(c *gin.Context) {
+	id := c.Param("id")
+	revealCitizenID(c, "students/"+id, func(db *gorm.DB) (entity.CitizenID, error) {
+		var s entity.Students
+		err := db.Select("student_id", "citizen_id").First(&s, "student_id = ?", id).Error
+		return s.CitizenID, err
+	})
+}
+
+// GET /teachers/:id/citizen-id - ดูเลขประจำตัวประชาชนเต็มของอาจารย์
+func RevealTeacherCitizenID(c *gin.Context) {
+	id := c.Param("id")
+	revealCitizenID(c, "teachers/"+id, func(db *gorm.DB) (entity.CitizenID, error) {
+		var t entity.Teachers
+		err := db.Select("teacher_id", "citizen_id").First(&t, "teacher_id = ?", id).Error
+		return t.CitizenID, err
+	})
+}
+
+// GET /admin/:id/citizen-id - ดูเลขประจำตัวประชาชนเต็มของผู้ดูแล
+func RevealAdminCitizenID(c *gin.Context) {
+	id := c.Param("id")
+	revealCitizenID(c, "admins/"+id, func(db *gorm.DB) (entity.CitizenID, error) {
+		var a entity.Admins
+		err := db.Select("admin_id", "citizen_id").First(&a, "admin_id = ?", id).Error
+		return a.CitizenID, err
+	})
+}
+
+// GET /audit-events/?actor=&action=&subject= - ประวัติการเข้าถึงข้อมูลส่วนบุคคล
+func GetAuditEvents(c *gin.Context) {
+	q := config.DB().Model(&entity.AuditEvent{})
+	if v := c.Query("actor"); v != "" {
+		q = q.Where("actor = ?", v)
+	}
+	if v := c.Query("action"); v != "" {
+		q = q.Where("action = ?", v)
+	}
+	if v := c.Query("subject"); v != "" {
+		q = q.Where("subject = ?", v)
+	}
+
+	var events []entity.AuditEvent
+	if err := q.Order("id DESC").Limit(500).Find(&events).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, events)
+}
@@ -1,6 +1,7 @@
 package bill
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -13,6 +14,7 @@ import (
 	"reg_system/services"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // ======================================================
@@ -20,14 +22,54 @@ import (
 // ======================================================
 
 type BillResponse struct {
-	ID         int               `json:"id"`
-	TotalPrice int               `json:"total_price"`
-	Subjects   []SubjectResponse `json:"subjects"`
-	Status     string            `json:"status"`
-	FilePath   string            `json:"file_path,omitempty"`
-	Year       int               `json:"year,omitempty"`
-	Term       int               `json:"term,omitempty"`
-	StatusMap  map[string]string `json:"status_map,omitempty"` // เพิ่ม map สถานะ
+	ID            int               `json:"id"`
+	TotalPrice    int               `json:"total_price"`
+	Subjects      []SubjectResponse `json:"subjects"`
+	Status        string            `json:"status"`
+	FilePath      string            `json:"file_path,omitempty"`
+	Year          int               `json:"year,omitempty"`
+	Term          int               `json:"term,omitempty"`
+	StatusMap     map[string]string `json:"status_map,omitempty"` // เพิ่ม map สถานะ
+	FilePathMap   map[string]string `json:"file_path_map,omitempty"`
+	TotalPriceMap map[string]int    `json:"total_price_map,omitempty"`
+	PriceErrorMap map[string]string `json:"price_error_map,omitempty"` // เทอมที่คำนวณราคาไม่ได้ (ไม่มีตารางค่าธรรมเนียม)
+	Bills         []TermBill        `json:"bills"`                     // บิลรายเทอมพร้อมรายการ
+}
+
+// บิล 1 เทอม พร้อมรายการค่าใช้จ่าย
+type TermBill struct {
+	ID           int                   `json:"id"`
+	Year         int                   `json:"year"`
+	Term         int                   `json:"term"`
+	TotalPrice   int                   `json:"total_price"`
+	Status       string                `json:"status"`
+	Items        []entity.BillItem     `json:"items"`
+	Installments []entity.Installment  `json:"installments"`
+	Balance      *services.BillBalance `json:"balance,omitempty"`
+}
+
+func toTermBill(bill entity.Bill) TermBill {
+	status := "-"
+	if bill.Status != nil {
+		status = bill.Status.Status
+	}
+	items := bill.Items
+	if items == nil {
+		items = []entity.BillItem{}
+	}
+	installments := bill.Installments
+	if installments == nil {
+		installments = []entity.Installment{}
+	}
+	return TermBill{
+		ID:           bill.ID,
+		Year:         bill.AcademicYear,
+		Term:         bill.Term,
+		TotalPrice:   bill.TotalPrice,
+		Status:       status,
+		Items:        items,
+		Installments: installments,
+	}
 }
 
 type SubjectResponse struct {
@@ -49,7 +91,8 @@ func GetBillByStudentID(c *gin.Context) {
 
 	// 1️⃣ ดึง Registration ของ student พร้อม Subject + Semester
 	var registrations []entity.Registration
-	if err := db.Preload("Subject.Semester").
+	if err := db.Scopes(services.ApprovedRegistrations).
+		Preload("Subject.Semester").
 		Where("student_id = ?", studentID).
 		Find(&registrations).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch registrations"})
@@ -64,18 +107,28 @@ func GetBillByStudentID(c *gin.Context) {
 	// 2️⃣ ดึง Bill ของ student
 	var bills []entity.Bill
 	if err := db.Preload("Status").
+		Preload("Items").
+		Preload("Installments", func(db *gorm.DB) *gorm.DB { return db.Order("seq ASC") }).
 		Where("student_id = ?", studentID).
+		Order("id DESC").
 		Find(&bills).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch bills"})
 		return
 	}
 
+	var student entity.Students
+	if err := db.First(&student, "student_id = ?", studentID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "student not found"})
+		return
+	}
+
 	// 3️⃣ สร้าง map
 	type SubjectKey string
 	subjectMap := map[SubjectKey]SubjectResponse{}
 	statusMap := map[string]string{}
 	filePathMap := map[string]string{}
 	totalPriceMap := map[string]int{}
+	unbilled := map[string][]services.Subject{}
 
 	for _, reg := range registrations {
 		if reg.Subject == nil || reg.Subject.Semester == nil {
@@ -97,7 +150,7 @@ func GetBillByStudentID(c *gin.Context) {
 			}
 		}
 
-		// หา bill ของเทอมนี้
+		// หา bill ของเทอมนี้ (ยอดเงินมาจากรายการในบิล จึงนับครั้งเดียวต่อเทอม)
 		found := false
 		for _, bill := range bills {
 			if bill.AcademicYear == year && bill.Term == term {
@@ -109,17 +162,43 @@ func GetBillByStudentID(c *gin.Context) {
 				if bill.FilePath != "" {
 					filePathMap[key] = bill.FilePath
 				}
-				totalPriceMap[key] += bill.TotalPrice
+				totalPriceMap[key] = bill.TotalPrice
 				found = true
 				break
 			}
 		}
 
 		if !found {
-			// กรณีไม่มี bill ให้คำนวณเอง
+			// กรณีไม่มี bill ให้คำนวณเองจากตารางค่าธรรมเนียม
 			statusMap[key] = "ค้างชำระ"
-			totalPriceMap[key] += reg.Subject.Credit * 800
+			unbilled[key] = append(unbilled[key], services.Subject{
+				SubjectID:   reg.SubjectID,
+				SubjectName: reg.Subject.SubjectName,
+				Credit:      reg.Subject.Credit,
+			})
+		}
+	}
+
+	priceErrorMap := map[string]string{}
+	for key, subjs := range unbilled {
+		var year, term int
+		fmt.Sscanf(key, "%d-%d", &year, &term)
+		pricedAt, err := services.BillPricingDate(db, year, term, nil)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to calculate fees: " + err.Error()})
+			return
+		}
+		lines, err := services.CalculateFees(db, student, subjs, pricedAt)
+		if errors.Is(err, services.ErrNoFeeSchedule) {
+			// เทอมที่ยังไม่มีตารางค่าธรรมเนียม แสดงว่าคำนวณราคาไม่ได้ แทนที่จะให้ทั้งรายการล้มเหลว
+			priceErrorMap[key] = err.Error()
+			continue
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to calculate fees: " + err.Error()})
+			return
 		}
+		totalPriceMap[key] += services.SumFees(lines)
 	}
 
 	// แปลง map เป็น slice
@@ -135,66 +214,119 @@ func GetBillByStudentID(c *gin.Context) {
 		defaultTerm = subjects[0].Term
 	}
 
+	termBills := []TermBill{}
+	for _, bill := range bills {
+		tb := toTermBill(bill)
+		balance, err := services.LedgerBalance(db, bill.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to calculate balance"})
+			return
+		}
+		tb.Balance = &balance
+		termBills = append(termBills, tb)
+	}
+
 	resp := BillResponse{
-		ID:         0,
-		TotalPrice: totalPriceMap[fmt.Sprintf("%d-%d", defaultYear, defaultTerm)],
-		Subjects:   subjects,
-		Status:     "-",
-		StatusMap:  statusMap,
-		FilePath:   filePathMap[fmt.Sprintf("%d-%d", defaultYear, defaultTerm)],
-		Year:       defaultYear,
-		Term:       defaultTerm,
+		ID:            0,
+		TotalPrice:    totalPriceMap[fmt.Sprintf("%d-%d", defaultYear, defaultTerm)],
+		Subjects:      subjects,
+		Status:        "-",
+		StatusMap:     statusMap,
+		FilePath:      filePathMap[fmt.Sprintf("%d-%d", defaultYear, defaultTerm)],
+		Year:          defaultYear,
+		Term:          defaultTerm,
+		FilePathMap:   filePathMap,
+		TotalPriceMap: totalPriceMap,
+		PriceErrorMap: priceErrorMap,
+		Bills:         termBills,
 	}
 
 	c.JSON(http.StatusOK, resp)
 }
 
-// POST /bills/:id/create - สร้างบิลใหม่จาก registration
+// POST /bills/:id/create - สร้าง/คำนวณบิลรายเทอมจาก registration
+// ระบุ ?year=&term= เพื่อสร้างเฉพาะเทอม ถ้าไม่ระบุจะสร้างทุกเทอมที่มีการลงทะเบียน
 func CreateBill(c *gin.Context) {
 	studentID := c.Param("id")
 	db := config.DB()
 
-	var regs []entity.Registration
-	if err := db.Preload("Subject").Preload("Subject.Semester").Find(&regs, "student_id = ?", studentID).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch registrations"})
-		return
+	type termKey struct{ Year, Term int }
+	terms := []termKey{}
+
+	if c.Query("year") != "" || c.Query("term") != "" {
+		year, err := strconv.Atoi(c.Query("year"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid year"})
+			return
+		}
+		term, err := strconv.Atoi(c.Query("term"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid term"})
+			return
+		}
+		terms = append(terms, termKey{year, term})
+	} else {
+		// นับเฉพาะรายวิชาที่อาจารย์ที่ปรึกษาอนุมัติแล้ว
+		var regs []entity.Registration
+		if err := db.Scopes(services.ApprovedRegistrations).
+			Preload("Subject").Preload("Subject.Semester").Find(&regs, "student_id = ?", studentID).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch registrations"})
+			return
+		}
+		seen := map[termKey]bool{}
+		for _, reg := range regs {
+			if reg.Subject == nil || reg.Subject.Semester == nil {
+				continue
+			}
+			k := termKey{reg.Subject.Semester.AcademicYear, reg.Subject.Semester.Term}
+			if !seen[k] {
+				seen[k] = true
+				terms = append(terms, k)
+			}
+		}
 	}
 
-	if len(regs) == 0 {
+	if len(terms) == 0 {
 		c.JSON(http.StatusNotFound, gin.H{"error": "no registration found"})
 		return
 	}
 
-	var subjects []services.Subject
-	for _, reg := range regs {
-		if reg.Subject != nil {
-			subjects = append(subjects, services.Subject{
-				SubjectID:   reg.SubjectID,
-				SubjectName: reg.Subject.SubjectName,
-				Credit:      reg.Subject.Credit,
-			})
+	var bills []entity.Bill
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, t := range terms {
+			bill, err := services.BuildBill(tx, studentID, t.Year, t.Term)
+			if err != nil {
+				return err
+			}
+			bills = append(bills, *bill)
 		}
+		return nil
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "student not found"})
+		case errors.Is(err, services.ErrNoFeeSchedule):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create bill"})
+		}
+		return
 	}
 
-	ratePerCredit := 800
-	totalPrice := services.CalculateTotalPrice(subjects, ratePerCredit)
-
-	bill := entity.Bill{
-		StudentID:  studentID,
-		Date:       time.Now(),
-		TotalPrice: totalPrice,
-		StatusID:   1, // 1 = ยังไม่ชำระเงิน
-	}
-
-	if err := db.Create(&bill).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create bill"})
-		return
+	termBills := []TermBill{}
+	for _, b := range bills {
+		termBills = append(termBills, toTermBill(b))
 	}
+	last := termBills[len(termBills)-1]
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "bill created",
-		"bill_id": bill.ID,
-		"status":  "ยังไม่ชำระเงิน",
+		"message":     "bill created",
+		"bill_id":     last.ID,
+		"status":      last.Status,
+		"total_price": last.TotalPrice,
+		"items":       last.Items,
+		"bills":       termBills,
 	})
 }
 
@@ -205,8 +337,6 @@ func UploadReceipt(c *gin.Context) {
 	termStr := c.Param("term")
 	db := config.DB()
 
-	now := time.Now() //แก้ให้ส่งเวลาจริงเข้าไปในฐานข้อมูล
-
 	year, err := strconv.Atoi(yearStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid year"})
@@ -219,53 +349,61 @@ func UploadReceipt(c *gin.Context) {
 		return
 	}
 
-	// หา Registration ของ student ตามปี/เทอม
-	var registrations []entity.Registration
-	if err := db.Preload("Subject.Semester").
-		Where("student_id = ?", studentID).
-		Find(&registrations).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "registrations not found"})
+	// หา Bill ของ student ปี/เทอมนี้ ถ้าไม่มีให้สร้างใหม่ (พร้อมเชื่อม Registration และรายการในบิล)
+	regs, err := services.TermRegistrations(db, studentID, year, term)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch registrations"})
 		return
 	}
-
-	var selectedRegs []entity.Registration
-	for _, reg := range registrations {
-		if reg.Subject != nil && reg.Subject.Semester != nil &&
-			reg.Subject.Semester.AcademicYear == year &&
-			reg.Subject.Semester.Term == term {
-			selectedRegs = append(selectedRegs, reg)
-		}
+	if len(regs) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no registrations found for this year and term"})
+		return
 	}
 
-	if len(selectedRegs) == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "no registrations found for this year and term"})
+	// ตรวจสถานะก่อน BuildBill เพราะ BuildBill ลบและสร้างรายการในบิลใหม่
+	var existing entity.Bill
+	err = db.Where("student_id = ? AND academic_year = ? AND term = ?", studentID, year, term).
+		Order("id DESC").First(&existing).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch bill"})
+		return
+	}
+	if err == nil && existing.StatusID == entity.BillStatusPaid {
+		c.JSON(http.StatusConflict, gin.H{"error": "bill is already paid"})
 		return
 	}
 
-	// หา Bill ของ student ปี/เทอมนี้ ถ้าไม่มีให้สร้างใหม่
 	var bill entity.Bill
-	if err := db.Where("student_id = ? AND academic_year = ? AND term = ?", studentID, year, term).First(&bill).Error; err != nil {
-		// สร้าง bill ใหม่
-		bill = entity.Bill{
-			StudentID:    studentID,
-			AcademicYear: year,
-			Term:         term,
-			Registration: selectedRegs,
-		}
-		if err := db.Create(&bill).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create bill"})
-			return
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		b, err := services.BuildBill(tx, studentID, year, term)
+		if err != nil {
+			return err
 		}
-	} else {
-		// ถ้ามีแล้ว อัปเดต Registration (กรณีเราต้องการเชื่อม)
-		if err := db.Model(&bill).Association("Registration").Replace(selectedRegs); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update bill registrations"})
-			return
+		bill = *b
+		return nil
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create bill"})
+		return
+	}
+
+	// ถ้ามีแผนผ่อนชำระ ใช้งวดที่ระบุ (installment_id) หรืองวดแรกที่ยังไม่ชำระ
+	var installment *entity.Installment
+	if len(bill.Installments) > 0 {
+		instID := c.PostForm("installment_id")
+		for i := range bill.Installments {
+			inst := &bill.Installments[i]
+			if instID != "" && strconv.Itoa(inst.ID) != instID {
+				continue
+			}
+			if inst.StatusID == entity.BillStatusPaid {
+				continue
+			}
+			if installment == nil || inst.Seq < installment.Seq {
+				installment = inst
+			}
 		}
-		if err := db.Model(&bill).Updates(map[string]interface{}{
-			"Date": now, // ✅ เซ็ตวันอัปโหลดใหม่
-		}).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update bill date"})
+		if installment == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "no unpaid installment found"})
 			return
 		}
 	}
@@ -277,131 +415,98 @@ func UploadReceipt(c *gin.Context) {
 		return
 	}
 
-	uploadDir := "./uploads"
-	if err := os.MkdirAll(uploadDir, os.ModePerm); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "cannot create upload folder"})
-		return
-	}
-
-	timestamp := time.Now().Format("20060102150405")
-	fileName := fmt.Sprintf("%s_%s", timestamp, file.Filename)
-	filePath := filepath.Join(uploadDir, fileName)
-
-	if err := c.SaveUploadedFile(file, filePath); err != nil {
+	fileName, err := services.SaveUpload(file)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// อัปเดต bill: FilePath + StatusID = 2 (รอตรวจสอบ)
-	if err := db.Model(&bill).Updates(map[string]interface{}{
-		"FilePath": fileName,
-		"StatusID": 2,
-		"Date":     now,
-	}).Error; err != nil {
+	// เก็บหลักฐานเป็นเวอร์ชันใหม่ บิลที่ผ่อนชำระจะผูกกับงวด แล้วคำนวณสถานะบิลใหม่
+	var sub *entity.ReceiptSubmission
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		sub, err = services.SubmitReceipt(tx, bill, installment, fileName, file.Filename, currentUsername(c))
+		return err
+	}); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update bill"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	resp := gin.H{
 		"message":     "upload success",
 		"file_path":   fileName,
 		"status":      "รอตรวจสอบ",
-		"uploaded_at": now.Format("2006-01-02 15:04:05"), // ✅ ส่งเวลาให้ frontend
-	})
+		"receipt_id":  sub.ID,
+		"version":     sub.Version,
+		"uploaded_at": sub.SubmittedAt.Format("2006-01-02 15:04:05"), // ✅ ส่งเวลาให้ frontend
+	}
+	if installment != nil {
+		resp["installment_id"] = installment.ID
+		resp["seq"] = installment.Seq
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
-// GET /bills/admin/all - ดึงบิลทั้งหมด สำหรับแอดมิน
+// GET /bills/admin/all - ดึงบิลทั้งหมด สำหรับแอดมิน (1 แถวต่อบิลรายเทอม)
 func GetAllBills(c *gin.Context) {
 	db := config.DB()
 
 	var bills []entity.Bill
-	if err := db.Preload("Registration.Subject.Semester").
+	if err := db.Preload("Items").
+		Preload("Installments", func(db *gorm.DB) *gorm.DB { return db.Order("seq ASC") }).
 		Preload("Student").
 		Preload("Status").
+		Order("academic_year DESC, term DESC, id DESC").
 		Find(&bills).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch bills"})
 		return
 	}
 
 	type AdminBill struct {
-		ID         int    `json:"id"`
-		StudentID  string `json:"student_id"`
-		FullName   string `json:"full_name"`
-		TotalPrice int    `json:"total_price"`
-		Status     string `json:"status"`
-		FilePath   string `json:"file_path,omitempty"`
-		Date       string `json:"date"`
-		Year       int    `json:"year"`
-		Term       int    `json:"term"`
-	}
-
-	ratePerCredit := 800 // ✅ เรทต่อหน่วยกิต
-	resp := []AdminBill{}
-
-	// สร้าง map เก็บข้อมูลแบบ student+year+term
-	type BillKey struct {
-		StudentID string
-		Year      int
-		Term      int
+		ID           int                  `json:"id"`
+		StudentID    string               `json:"student_id"`
+		FullName     string               `json:"full_name"`
+		TotalPrice   int                  `json:"total_price"`
+		Status       string               `json:"status"`
+		FilePath     string               `json:"file_path,omitempty"`
+		Date         string               `json:"date"`
+		DueDate      string               `json:"due_date"`
+		UploadedAt   *time.Time           `json:"uploaded_at,omitempty"`
+		Year         int                  `json:"year"`
+		Term         int                  `json:"term"`
+		Items        []entity.BillItem    `json:"items"`
+		Installments []entity.Installment `json:"installments"`
 	}
-	grouped := map[BillKey][]services.Subject{}
-	statusMap := map[BillKey]string{}
-	filePathMap := map[BillKey]string{}
-	latestID := map[BillKey]int{}
-	dateMap := map[BillKey]string{}
-	nameMap := map[BillKey]string{}
 
+	resp := []AdminBill{}
 	for _, bill := range bills {
 		if bill.Student == nil {
 			continue
 		}
 
-		fullName := bill.Student.FirstName + " " + bill.Student.LastName
-
-		// ✅ ใช้ bill.Registration ไม่ใช่ Student.Registration
-		for _, reg := range bill.Registration {
-			if reg.Subject != nil && reg.Subject.Semester != nil {
-				key := BillKey{
-					StudentID: bill.StudentID,
-					Year:      reg.Subject.Semester.AcademicYear,
-					Term:      reg.Subject.Semester.Term,
-				}
-				// เก็บ subject
-				grouped[key] = append(grouped[key], services.Subject{
-					SubjectID:   reg.Subject.SubjectID,
-					SubjectName: reg.Subject.SubjectName,
-					Credit:      reg.Subject.Credit,
-				})
-
-				// เก็บค่าอื่น ๆ
-				if bill.Status != nil {
-					statusMap[key] = bill.Status.Status
-				}
-				if bill.FilePath != "" {
-					filePathMap[key] = bill.FilePath
-				}
-				if existing, ok := latestID[key]; !ok || bill.ID > existing {
-					latestID[key] = bill.ID
-					dateMap[key] = bill.Date.Format("2006-01-02")
-					nameMap[key] = fullName
-				}
-			}
+		status := ""
+		if bill.Status != nil {
+			status = bill.Status.Status
+		}
+		items := bill.Items
+		if items == nil {
+			items = []entity.BillItem{}
 		}
-	}
 
-	// ✅ แปลงเป็น response
-	for key, subjects := range grouped {
-		totalPrice := services.CalculateTotalPrice(subjects, ratePerCredit)
 		resp = append(resp, AdminBill{
-			ID:         latestID[key],
-			StudentID:  key.StudentID,
-			FullName:   nameMap[key],
-			TotalPrice: totalPrice,
-			Status:     statusMap[key],
-			FilePath:   filePathMap[key],
-			Date:       dateMap[key],
-			Year:       key.Year,
-			Term:       key.Term,
+			ID:           bill.ID,
+			StudentID:    bill.StudentID,
+			FullName:     bill.Student.FirstName + " " + bill.Student.LastName,
+			TotalPrice:   bill.TotalPrice,
+			Status:       status,
+			FilePath:     bill.FilePath,
+			Date:         bill.Date.Format("2006-01-02"),
+			DueDate:      services.BillDueDate(bill).Format("2006-01-02"),
+			UploadedAt:   bill.UploadedAt,
+			Year:         bill.AcademicYear,
+			Term:         bill.Term,
+			Items:        items,
+			Installments: bill.Installments,
 		})
 	}
 
@@ -412,7 +517,7 @@ func GetAllBills(c *gin.Context) {
 
 func ShowFile(c *gin.Context) {
 	filename := c.Param("id")
-	filePath := fmt.Sprintf("./uploads/%s", filename)
+	filePath := filepath.Join(services.UploadDir, filepath.Base(filename))
 
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "file not found"})
@@ -428,7 +533,8 @@ func UpdateBillStatus(c *gin.Context) {
 	db := config.DB()
 
 	var req struct {
-		StatusID int `json:"status_id"` // แก้เป็น int
+		StatusID int    `json:"status_id"` // แก้เป็น int
+		Reason   string `json:"reason"`    // เหตุผลกรณีไม่อนุมัติ (status_id = 1)
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -442,16 +548,61 @@ func UpdateBillStatus(c *gin.Context) {
 		return
 	}
 
-	bill.StatusID = req.StatusID // ตอนนี้ตรงชนิดแล้ว
-	if err := db.Save(&bill).Error; err != nil {
+	if err := db.First(&entity.BillStatus{}, req.StatusID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid status_id"})
+		return
+	}
+	if req.StatusID == entity.BillStatusPending {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bills become pending only when a receipt is uploaded"})
+		return
+	}
+	if req.StatusID == entity.BillStatusUnpaid && req.Reason == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrReceiptReasonMissing.Error()})
+		return
+	}
+
+	// สถานะบิลคำนวณจากสมุดบัญชีรับเงิน: อนุมัติ (3) = อนุมัติหลักฐานที่รอตรวจ และบันทึกรับชำระ
+	// ไม่อนุมัติ (1) = ปฏิเสธหลักฐานที่รอตรวจพร้อมเหตุผล
+	reviewer := currentUsername(c)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		pending, err := services.PendingReceipts(tx, bill.ID)
+		if err != nil {
+			return err
+		}
+		approve := req.StatusID == entity.BillStatusPaid
+		if len(pending) == 0 {
+			if approve {
+				// ยืนยันการรับเงินโดยไม่มีหลักฐานแนบ (เช่น ชำระที่เคาน์เตอร์)
+				return services.ApproveBillReceipt(tx, bill, reviewer)
+			}
+			return services.SyncBillStatus(tx, bill.ID)
+		}
+		for i := range pending {
+			if err := services.ReviewReceipt(tx, &pending[i], approve, req.Reason, reviewer); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, services.ErrInstallmentSettled) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update bill status"})
 		return
 	}
 
+	if err := db.First(&bill, bill.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"message": "bill status updated",
-		"bill_id": bill.ID,
-		"status":  req.StatusID,
+		"message":     "bill status updated",
+		"bill_id":     bill.ID,
+		"status":      bill.StatusID,
+		"reviewed_by": reviewer,
 	})
 }
 
@@ -0,0 +1,236 @@
+package bill
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"reg_system/config"
+	"reg_system/entity"
+	"reg_system/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type InstallmentLineReq struct {
+	Amount  int       `json:"Amount"  binding:"required"`
+	DueDate time.Time `json:"DueDate" binding:"required"`
+}
+
+// กำหนดงวดเอง (Installments) หรือให้ระบบแบ่งเท่า ๆ กัน (Count + FirstDueDate + IntervalDays)
+type InstallmentPlanReq struct {
+	Installments []InstallmentLineReq `json:"Installments"`
+	Count        int                  `json:"Count"`
+	FirstDueDate time.Time            `json:"FirstDueDate"`
+	IntervalDays int                  `json:"IntervalDays"`
+}
+
+// ดึงบิลตาม id และตรวจสิทธิ์: นักศึกษาเข้าถึงได้เฉพาะบิลของตัวเอง
+func findBillForUser(c *gin.Context, db *gorm.DB) (*entity.Bill, bool) {
+	var bill entity.Bill
+	if err := db.First(&bill, c.Param("id")).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "bill not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		}
+		return nil, false
+	}
+
+	claims := services.CurrentClaims(c)
+	if claims.Role != "admin" && claims.Username != bill.StudentID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden: not your bill"})
+		return nil, false
+	}
+	return &bill, true
+}
+
+// GET /bills/:id/installments - งวดผ่อนชำระของบิล
+// ค่าปรับงวดที่เลยกำหนดคิดโดย POST /bills/admin/late-fees หรืองานตามรอบ ไม่คิดตอนเรียกดู
+func GetInstallments(c *gin.Context) {
+	db := config.DB()
+	bill, ok := findBillForUser(c, db)
+	if !ok {
+		return
+	}
+
+	var installments []entity.Installment
+	if err := db.Preload("Status").Where("bill_id = ?", bill.ID).Order("seq ASC").Find(&installments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := db.Preload("Status").First(bill, bill.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	status := "-"
+	if bill.Status != nil {
+		status = bill.Status.Status
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"bill_id":      bill.ID,
+		"total_price":  bill.TotalPrice,
+		"status":       status,
+		"installments": installments,
+	})
+}
+
+// POST /bills/:id/installments - สร้าง/แทนที่แผนผ่อนชำระ (admin)
+func CreateInstallments(c *gin.Context) {
+	var req InstallmentPlanReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := config.DB()
+	bill, ok := findBillForUser(c, db)
+	if !ok {
+		return
+	}
+	if bill.StatusID == entity.BillStatusPaid {
+		c.JSON(http.StatusConflict, gin.H{"error": "bill is already paid"})
+		return
+	}
+
+	var lines []services.InstallmentLine
+	switch {
+	case len(req.Installments) > 0:
+		for _, l := range req.Installments {
+			lines = append(lines, services.InstallmentLine{Amount: l.Amount, DueDate: l.DueDate})
+		}
+	case req.Count > 0:
+		if req.Count > 12 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "count must not exceed 12"})
+			return
+		}
+		if req.FirstDueDate.IsZero() || (req.Count > 1 && req.IntervalDays <= 0) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "FirstDueDate and IntervalDays are required"})
+			return
+		}
+		lines = services.SplitInstallments(bill.TotalPrice, req.Count, req.FirstDueDate, req.IntervalDays)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Installments or Count is required"})
+		return
+	}
+
+	var installments []entity.Installment
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		installments, err = services.CreateInstallmentPlan(tx, *bill, lines)
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInstallmentLocked):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"bill_id": bill.ID, "installments": installments})
+}
+
+// DELETE /bills/:id/installments - ยกเลิกแผนผ่อนชำระ กลับไปชำระเต็มจำนวน
+func DeleteInstallments(c *gin.Context) {
+	db := config.DB()
+	bill, ok := findBillForUser(c, db)
+	if !ok {
+		return
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		return services.DeleteInstallmentPlan(tx, bill.ID)
+	}); err != nil {
+		if errors.Is(err, services.ErrInstallmentLocked) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Delete installment plan success"})
+}
+
+// PUT /bills/:id/installments/:iid - แอดมินตรวจหลักฐานรายงวด
+// body: { status_id: 3 (อนุมัติ) | 1 (ไม่อนุมัติ ให้ส่งใหม่), reason }
+func ReviewInstallment(c *gin.Context) {
+	var req struct {
+		StatusID int    `json:"status_id"`
+		Reason   string `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	if req.StatusID != entity.BillStatusPaid && req.StatusID != entity.BillStatusUnpaid {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status_id must be 1 or 3"})
+		return
+	}
+
+	db := config.DB()
+	var inst entity.Installment
+	if err := db.Where("id = ? AND bill_id = ?", c.Param("iid"), c.Param("id")).First(&inst).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "installment not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		}
+		return
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		// ถ้ามีหลักฐานรอตรวจของงวดนี้ ให้ผ่านขั้นตอนตรวจหลักฐาน (บันทึกผู้ตรวจ/เหตุผล)
+		var sub entity.ReceiptSubmission
+		err := tx.Where("installment_id = ? AND status = ?", inst.ID, entity.ReceiptSubmitted).
+			Order("id DESC").First(&sub).Error
+		if err == nil {
+			return services.ReviewReceipt(tx, &sub, req.StatusID == entity.BillStatusPaid, req.Reason, currentUsername(c))
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if req.StatusID == entity.BillStatusPaid {
+			return services.ApproveInstallment(tx, inst, currentUsername(c))
+		}
+		if err := tx.Model(&inst).Update("status_id", req.StatusID).Error; err != nil {
+			return err
+		}
+		return services.SyncBillStatus(tx, inst.BillID)
+	}); err != nil {
+		switch {
+		case errors.Is(err, services.ErrReceiptReasonMissing):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrInstallmentSettled):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "installment status updated",
+		"installment_id": inst.ID,
+		"status":         req.StatusID,
+	})
+}
+
+// POST /bills/admin/late-fees - คิดค่าปรับงวดที่เลยกำหนดของทุกบิล
+func ApplyLateFees(c *gin.Context) {
+	var count int
+	err := config.DB().Transaction(func(tx *gorm.DB) error {
+		var err error
+		count, err = services.ApplyLateFees(tx, "", time.Now())
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "late fees applied", "installments": count})
+}
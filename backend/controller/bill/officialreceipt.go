@@ -0,0 +1,57 @@
+package bill
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+
+	"reg_system/config"
+	"reg_system/entity"
+	"reg_system/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GET /bills/:id/official-receipts - ใบเสร็จรับเงินที่ออกแล้วของบิล
+func GetOfficialReceipts(c *gin.Context) {
+	db := config.DB()
+	bill, ok := findBillForUser(c, db)
+	if !ok {
+		return
+	}
+
+	var receipts []entity.OfficialReceipt
+	if err := db.Where("bill_id = ?", bill.ID).Order("id ASC").Find(&receipts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, receipts)
+}
+
+// GET /bills/:id/official-receipts/:rid/pdf - ดาวน์โหลดใบเสร็จเป็น PDF
+func DownloadOfficialReceipt(c *gin.Context) {
+	db := config.DB()
+	bill, ok := findBillForUser(c, db)
+	if !ok {
+		return
+	}
+
+	var receipt entity.OfficialReceipt
+	if err := db.Where("id = ? AND bill_id = ?", c.Param("rid"), bill.ID).First(&receipt).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "receipt not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		}
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := services.RenderOfficialReceipt(&buf, receipt); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate receipt: " + err.Error()})
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="`+receipt.ReceiptNo+`.pdf"`)
+	c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
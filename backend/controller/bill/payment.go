@@ -0,0 +1,133 @@
+package bill
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"reg_system/config"
+	"reg_system/entity"
+	"reg_system/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type PaymentReq struct {
+	Type          string    `json:"Type"`
+	Method        string    `json:"Method"`
+	Amount        int       `json:"Amount" binding:"required"`
+	Reference     string    `json:"Reference"`
+	Note          string    `json:"Note"`
+	PayerID       string    `json:"PayerID"`
+	PaidAt        time.Time `json:"PaidAt"`
+	InstallmentID *int      `json:"InstallmentID"`
+	TargetBillID  int       `json:"TargetBillID"` // ใช้กับ Type = credit_transfer
+}
+
+var paymentMethods = map[string]bool{
+	entity.PaymentMethodCash:      true,
+	entity.PaymentMethodTransfer:  true,
+	entity.PaymentMethodPromptPay: true,
+	entity.PaymentMethodCard:      true,
+}
+
+// ชื่อผู้ใช้ที่เข้าสู่ระบบ (ใช้บันทึกผู้ทำรายการ)
+func currentUsername(c *gin.Context) string {
+	return services.CurrentClaims(c).Username
+}
+
+// GET /bills/:id/payments - รายการรับ/คืนเงินของบิลพร้อมยอดคงเหลือ
+func GetPayments(c *gin.Context) {
+	db := config.DB()
+	bill, ok := findBillForUser(c, db)
+	if !ok {
+		return
+	}
+
+	var payments []entity.Payment
+	if err := db.Where("bill_id = ?", bill.ID).Order("paid_at ASC, id ASC").Find(&payments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	balance, err := services.LedgerBalance(db, bill.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"bill_id":  bill.ID,
+		"balance":  balance,
+		"payments": payments,
+	})
+}
+
+// POST /bills/:id/payments - แอดมินบันทึกรับชำระ / คืนเงิน / โอนเครดิตไปบิลอื่น
+func CreatePayment(c *gin.Context) {
+	var req PaymentReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Type == "" {
+		req.Type = entity.PaymentTypePayment
+	}
+
+	db := config.DB()
+	bill, ok := findBillForUser(c, db)
+	if !ok {
+		return
+	}
+
+	recordedBy := currentUsername(c)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		switch req.Type {
+		case entity.PaymentTypePayment, entity.PaymentTypeRefund:
+			if !paymentMethods[req.Method] {
+				return errors.New("method must be cash, transfer, promptpay or card")
+			}
+			payerID := req.PayerID
+			if payerID == "" {
+				payerID = bill.StudentID
+			}
+			return services.RecordPayment(tx, &entity.Payment{
+				BillID:        bill.ID,
+				InstallmentID: req.InstallmentID,
+				Type:          req.Type,
+				Method:        req.Method,
+				Amount:        req.Amount,
+				Reference:     req.Reference,
+				Note:          req.Note,
+				PayerID:       payerID,
+				RecordedBy:    recordedBy,
+				PaidAt:        req.PaidAt,
+			})
+		case entity.PaymentTypeCreditTransfer:
+			if req.TargetBillID == 0 {
+				return errors.New("TargetBillID is required for credit_transfer")
+			}
+			return services.TransferCredit(tx, bill.ID, req.TargetBillID, req.Amount, recordedBy)
+		default:
+			return errors.New("type must be payment, refund or credit_transfer")
+		}
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "bill not found"})
+		case errors.Is(err, services.ErrInsufficientCredit):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	balance, err := services.LedgerBalance(db, bill.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "payment recorded", "balance": balance})
+}
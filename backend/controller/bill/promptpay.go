@@ -0,0 +1,106 @@
+package bill
+
+import (
+	"errors"
+	"net/http"
+
+	"reg_system/config"
+	"reg_system/entity"
+	"reg_system/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skip2/go-qrcode"
+	"gorm.io/gorm"
+)
+
+type PromptPayResponse struct {
+	BillID        int    `json:"bill_id"`
+	InstallmentID int    `json:"installment_id,omitempty"`
+	Amount        int    `json:"amount"`
+	Reference     string `json:"reference"`
+	Payload       string `json:"payload"`
+}
+
+// สร้าง payload พร้อมเพย์ของบิล (ยอดค้างชำระ) หรือของงวด (?installment_id=, ยอดที่งวดนั้นยังขาด)
+// เขียน error response ให้เองและคืนค่า false ถ้าสร้างไม่ได้
+func buildPromptPay(c *gin.Context) (*PromptPayResponse, bool) {
+	if config.PromptPayID() == "" && config.PromptPayBillerID() == "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "PromptPay is not configured"})
+		return nil, false
+	}
+
+	db := config.DB()
+	bill, ok := findBillForUser(c, db)
+	if !ok {
+		return nil, false
+	}
+
+	resp := &PromptPayResponse{BillID: bill.ID}
+	if instID := c.Query("installment_id"); instID != "" {
+		var inst entity.Installment
+		if err := db.Where("id = ? AND bill_id = ?", instID, bill.ID).First(&inst).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "installment not found"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+			}
+			return nil, false
+		}
+		remaining, err := services.InstallmentRemaining(db, inst)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return nil, false
+		}
+		if inst.StatusID == entity.BillStatusPaid || remaining == 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "installment is already paid"})
+			return nil, false
+		}
+		resp.InstallmentID = inst.ID
+		resp.Amount = remaining
+		resp.Reference = services.BillReference(bill.ID, inst.Seq)
+	} else {
+		balance, err := services.LedgerBalance(db, bill.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return nil, false
+		}
+		if balance.Outstanding == 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "bill has no outstanding balance"})
+			return nil, false
+		}
+		resp.Amount = balance.Outstanding
+		resp.Reference = services.BillReference(bill.ID, 0)
+	}
+
+	payload, err := services.PromptPayPayload(config.PromptPayID(), config.PromptPayBillerID(), resp.Amount, bill.StudentID, resp.Reference)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	resp.Payload = payload
+	return resp, true
+}
+
+// GET /bills/:id/promptpay - payload QR พร้อมเพย์ (ยอดเงินและรหัสอ้างอิงบิล)
+func GetPromptPay(c *gin.Context) {
+	resp, ok := buildPromptPay(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GET /bills/:id/promptpay.png - รูป QR พร้อมเพย์
+func GetPromptPayPNG(c *gin.Context) {
+	resp, ok := buildPromptPay(c)
+	if !ok {
+		return
+	}
+	png, err := qrcode.Encode(resp.Payload, qrcode.Medium, 320)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate QR code"})
+		return
+	}
+	c.Header("X-Bill-Reference", resp.Reference)
+	c.Data(http.StatusOK, "image/png", png)
+}
@@ -0,0 +1,73 @@
+package bill
+
+import (
+	"errors"
+	"net/http"
+
+	"reg_system/config"
+	"reg_system/entity"
+	"reg_system/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GET /bills/:id/receipts - ประวัติหลักฐานการชำระทุกเวอร์ชันของบิล
+func GetReceipts(c *gin.Context) {
+	db := config.DB()
+	bill, ok := findBillForUser(c, db)
+	if !ok {
+		return
+	}
+
+	var subs []entity.ReceiptSubmission
+	if err := db.Where("bill_id = ?", bill.ID).Order("submitted_at DESC, id DESC").Find(&subs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, subs)
+}
+
+// PUT /bills/:id/receipts/:rid - แอดมินตรวจหลักฐาน
+// body: { Status: "approved" | "rejected", Reason }
+func ReviewReceipt(c *gin.Context) {
+	var req struct {
+		Status string `json:"Status" binding:"required"`
+		Reason string `json:"Reason"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Status != entity.ReceiptApproved && req.Status != entity.ReceiptRejected {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be approved or rejected"})
+		return
+	}
+
+	db := config.DB()
+	var sub entity.ReceiptSubmission
+	if err := db.Where("id = ? AND bill_id = ?", c.Param("rid"), c.Param("id")).First(&sub).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "receipt not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		}
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return services.ReviewReceipt(tx, &sub, req.Status == entity.ReceiptApproved, req.Reason, currentUsername(c))
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrReceiptReviewed), errors.Is(err, services.ErrInstallmentSettled):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrReceiptReasonMissing):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, sub)
+}
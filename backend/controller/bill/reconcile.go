@@ -0,0 +1,158 @@
+package bill
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"reg_system/config"
+	"reg_system/entity"
+	"reg_system/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// POST /bills/admin/reconcile - นำเข้า statement (CSV)
+// form-data: file, mapping (JSON ไม่บังคับ เช่น {"date":"Txn Date","amount":"Credit","date_format":"02/01/2006"})
+func ImportStatement(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file not found"})
+		return
+	}
+
+	var mapping services.StatementMapping
+	if raw := c.PostForm("mapping"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid mapping: " + err.Error()})
+			return
+		}
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cannot open file"})
+		return
+	}
+	defer f.Close()
+
+	lines, lineErrs, err := services.ParseStatement(f, mapping)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var imp *entity.BankStatementImport
+	err = config.DB().Transaction(func(tx *gorm.DB) error {
+		var err error
+		imp, err = services.ImportStatement(tx, file.Filename, currentUsername(c), lines)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "statement imported",
+		"import":  imp,
+		"errors":  lineErrs,
+	})
+}
+
+// GET /bills/admin/reconcile?status=&import_id= - คิวรายการที่ต้องตรวจ (ค่าเริ่มต้น: ambiguous + unmatched)
+func GetBankTransactions(c *gin.Context) {
+	q := config.DB().Model(&entity.BankTransaction{})
+	switch status := c.Query("status"); status {
+	case "":
+		q = q.Where("status IN ?", []string{entity.BankTxAmbiguous, entity.BankTxUnmatched})
+	case "all":
+	default:
+		q = q.Where("status = ?", status)
+	}
+	if importID := c.Query("import_id"); importID != "" {
+		q = q.Where("import_id = ?", importID)
+	}
+
+	var txs []entity.BankTransaction
+	if err := q.Order("transacted_at DESC, id DESC").Find(&txs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, txs)
+}
+
+// PUT /bills/admin/reconcile/:txid - เจ้าหน้าที่ยืนยันการจับคู่หรือละเว้นรายการ
+// body: { Action: "match" | "ignore", BillID, InstallmentID, Note }
+func ResolveBankTransaction(c *gin.Context) {
+	var req struct {
+		Action        string `json:"Action" binding:"required"`
+		BillID        int    `json:"BillID"`
+		InstallmentID *int   `json:"InstallmentID"`
+		Note          string `json:"Note"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := config.DB()
+	var btx entity.BankTransaction
+	if err := db.First(&btx, c.Param("txid")).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "transaction not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		}
+		return
+	}
+	if btx.Status == entity.BankTxMatched {
+		c.JSON(http.StatusConflict, gin.H{"error": "transaction is already matched"})
+		return
+	}
+
+	user := currentUsername(c)
+	now := time.Now()
+	err := db.Transaction(func(tx *gorm.DB) error {
+		switch req.Action {
+		case "ignore":
+			btx.Status = entity.BankTxIgnored
+			btx.MatchNote = req.Note
+		case "match":
+			var bill entity.Bill
+			if err := tx.First(&bill, req.BillID).Error; err != nil {
+				return err
+			}
+			var inst *entity.Installment
+			if req.InstallmentID != nil {
+				inst = &entity.Installment{}
+				if err := tx.Where("id = ? AND bill_id = ?", *req.InstallmentID, bill.ID).First(inst).Error; err != nil {
+					return err
+				}
+			}
+			note := "matched manually"
+			if req.Note != "" {
+				note += ": " + req.Note
+			}
+			if err := services.ApplyBankMatch(tx, &btx, bill, inst, entity.PaymentMethodTransfer, user, note); err != nil {
+				return err
+			}
+		default:
+			return errors.New("action must be match or ignore")
+		}
+		btx.ResolvedBy = user
+		btx.ResolvedAt = &now
+		return tx.Save(&btx).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "bill or installment not found"})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, btx)
+}
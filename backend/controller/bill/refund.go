@@ -0,0 +1,186 @@
+package bill
+
+import (
+	"errors"
+	"net/http"
+
+	"reg_system/config"
+	"reg_system/entity"
+	"reg_system/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GET /bills/:id/refunds - คำขอคืนเงินของบิล
+func GetRefunds(c *gin.Context) {
+	db := config.DB()
+	bill, ok := findBillForUser(c, db)
+	if !ok {
+		return
+	}
+
+	var refunds []entity.RefundRequest
+	if err := db.Preload("RefundPayment").Where("bill_id = ?", bill.ID).Order("id DESC").Find(&refunds).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, refunds)
+}
+
+// POST /bills/:id/refunds - ขอคืนเงินส่วนที่ชำระเกิน (ถอนรายวิชา/ชำระซ้ำ)
+// body: { Amount (0 = ทั้งหมด), PaymentID, Reason: "drop" | "overpayment", Note, BankAccount }
+func CreateRefund(c *gin.Context) {
+	var req struct {
+		Amount      int    `json:"Amount"`
+		PaymentID   *int   `json:"PaymentID"`
+		Reason      string `json:"Reason"`
+		Note        string `json:"Note"`
+		BankAccount string `json:"BankAccount"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Reason == "" {
+		req.Reason = entity.RefundReasonOverpayment
+	}
+	if req.Reason != entity.RefundReasonDrop && req.Reason != entity.RefundReasonOverpayment {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reason must be drop or overpayment"})
+		return
+	}
+
+	db := config.DB()
+	bill, ok := findBillForUser(c, db)
+	if !ok {
+		return
+	}
+
+	var count int64
+	if err := db.Model(&entity.RefundRequest{}).
+		Where("bill_id = ? AND status = ?", bill.ID, entity.RefundPending).
+		Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		return
+	}
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "bill already has a pending refund request"})
+		return
+	}
+
+	refund := entity.RefundRequest{
+		BillID:      bill.ID,
+		StudentID:   bill.StudentID,
+		PaymentID:   req.PaymentID,
+		Reason:      req.Reason,
+		Note:        req.Note,
+		Amount:      req.Amount,
+		BankAccount: req.BankAccount,
+		RequestedBy: currentUsername(c),
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return services.CreateRefundRequest(tx, &refund)
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrRefundAmount) || errors.Is(err, services.ErrInvalidPayment) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, refund)
+}
+
+// PUT /bills/:id/refunds/:rfid - ฝ่ายการเงินอนุมัติ/ไม่อนุมัติคำขอคืนเงิน
+// body: { Status: "approved" | "rejected", Amount, Method, Reason }
+func ReviewRefund(c *gin.Context) {
+	var req struct {
+		Status string `json:"Status" binding:"required"`
+		Amount int    `json:"Amount"`
+		Method string `json:"Method"`
+		Reason string `json:"Reason"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Status != entity.RefundApproved && req.Status != entity.RefundRejected {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be approved or rejected"})
+		return
+	}
+	if req.Status == entity.RefundRejected && req.Reason == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reason is required when rejecting a refund"})
+		return
+	}
+	if req.Method != "" && !paymentMethods[req.Method] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payment method"})
+		return
+	}
+
+	db := config.DB()
+	var refund entity.RefundRequest
+	if err := db.Where("id = ? AND bill_id = ?", c.Param("rfid"), c.Param("id")).First(&refund).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "refund request not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		}
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return services.ReviewRefund(tx, &refund, req.Status == entity.RefundApproved, req.Amount, req.Method, req.Reason, currentUsername(c))
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrRefundReviewed):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrRefundAmount):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	balance, err := services.LedgerBalance(db, refund.BillID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"refund": refund, "balance": balance})
+}
+
+// GET /bills/admin/refunds?status= - คิวคำขอคืนเงิน (ค่าเริ่มต้น: รอพิจารณา)
+func GetRefundQueue(c *gin.Context) {
+	status := c.DefaultQuery("status", entity.RefundPending)
+	q := config.DB().Model(&entity.RefundRequest{})
+	if status != "all" {
+		q = q.Where("status = ?", status)
+	}
+
+	var refunds []entity.RefundRequest
+	if err := q.Order("requested_at ASC").Find(&refunds).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, refunds)
+}
+
+// GET /students/:id/account - ยอดรวมบัญชีของนักศึกษา (ค้างชำระ/เครดิตคงเหลือ)
+func GetStudentAccount(c *gin.Context) {
+	sid := c.Param("id")
+	claims := services.CurrentClaims(c)
+	if claims.Role != "admin" && claims.Username != sid {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+
+	account, err := services.StudentAccountBalance(config.DB(), sid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, account)
+}
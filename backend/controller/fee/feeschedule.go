@@ -0,0 +1,204 @@
+package fee
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"reg_system/config"
+	"reg_system/entity"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type LabFeeReq struct {
+	SubjectID string `json:"SubjectID" binding:"required"`
+	Amount    int    `json:"Amount"    binding:"min=0"`
+}
+
+type FeeScheduleReq struct {
+	Name          string      `json:"Name"          binding:"required"`
+	DegreeID      *int        `json:"DegreeID"`
+	FacultyID     *string     `json:"FacultyID"`
+	CurriculumID  *string     `json:"CurriculumID"`
+	EntryYear     *int        `json:"EntryYear"`
+	RatePerCredit int         `json:"RatePerCredit" binding:"min=0"`
+	TermFee       int         `json:"TermFee"       binding:"min=0"`
+	EffectiveFrom time.Time   `json:"EffectiveFrom" binding:"required"`
+	EffectiveTo   *time.Time  `json:"EffectiveTo"`
+	LabFees       []LabFeeReq `json:"LabFees"`
+}
+
+// ตรวจความถูกต้องของขอบเขตและช่วงวันที่
+func validateFeeSchedule(db *gorm.DB, req FeeScheduleReq) error {
+	if req.EffectiveTo != nil && req.EffectiveTo.Before(req.EffectiveFrom) {
+		return errors.New("EffectiveTo must be after EffectiveFrom")
+	}
+	if req.DegreeID != nil {
+		if err := db.First(&entity.Degree{}, "degree_id = ?", *req.DegreeID).Error; err != nil {
+			return errors.New("invalid DegreeID")
+		}
+	}
+	if req.FacultyID != nil {
+		if err := db.First(&entity.Faculty{}, "faculty_id = ?", *req.FacultyID).Error; err != nil {
+			return errors.New("invalid FacultyID")
+		}
+	}
+	if req.CurriculumID != nil {
+		if err := db.First(&entity.Curriculum{}, "curriculum_id = ?", *req.CurriculumID).Error; err != nil {
+			return errors.New("invalid CurriculumID")
+		}
+	}
+	seen := map[string]bool{}
+	for _, lab := range req.LabFees {
+		if seen[lab.SubjectID] {
+			return errors.New("duplicate lab fee for subject " + lab.SubjectID)
+		}
+		seen[lab.SubjectID] = true
+		if err := db.First(&entity.Subject{}, "subject_id = ?", lab.SubjectID).Error; err != nil {
+			return errors.New("invalid SubjectID " + lab.SubjectID)
+		}
+	}
+	return nil
+}
+
+func toLabFees(reqs []LabFeeReq) []entity.LabFee {
+	out := make([]entity.LabFee, 0, len(reqs))
+	for _, r := range reqs {
+		out = append(out, entity.LabFee{SubjectID: r.SubjectID, Amount: r.Amount})
+	}
+	return out
+}
+
+// GET /fee-schedules
+func GetFeeScheduleAll(c *gin.Context) {
+	var schedules []entity.FeeSchedule
+	db := config.DB()
+	if err := db.Preload("LabFees").Order("effective_from DESC").Find(&schedules).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, schedules)
+}
+
+// GET /fee-schedules/:id
+func GetFeeScheduleByID(c *gin.Context) {
+	id := c.Param("id")
+	var fs entity.FeeSchedule
+	db := config.DB()
+	if err := db.Preload("LabFees.Subject").First(&fs, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "fee schedule not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, fs)
+}
+
+// POST /fee-schedules
+func CreateFeeSchedule(c *gin.Context) {
+	var req FeeScheduleReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := config.DB()
+	if err := validateFeeSchedule(db, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fs := entity.FeeSchedule{
+		Name:          req.Name,
+		DegreeID:      req.DegreeID,
+		FacultyID:     req.FacultyID,
+		CurriculumID:  req.CurriculumID,
+		EntryYear:     req.EntryYear,
+		RatePerCredit: req.RatePerCredit,
+		TermFee:       req.TermFee,
+		EffectiveFrom: req.EffectiveFrom,
+		EffectiveTo:   req.EffectiveTo,
+		LabFees:       toLabFees(req.LabFees),
+	}
+	if err := db.Create(&fs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, fs)
+}
+
+// PUT /fee-schedules/:id (แทนที่ค่าทั้งหมด รวมถึงค่าแล็บรายวิชา)
+func UpdateFeeSchedule(c *gin.Context) {
+	id := c.Param("id")
+	var req FeeScheduleReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := config.DB()
+	if err := validateFeeSchedule(db, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var fs entity.FeeSchedule
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&fs, id).Error; err != nil {
+			return err
+		}
+		fs.Name = req.Name
+		fs.DegreeID = req.DegreeID
+		fs.FacultyID = req.FacultyID
+		fs.CurriculumID = req.CurriculumID
+		fs.EntryYear = req.EntryYear
+		fs.RatePerCredit = req.RatePerCredit
+		fs.TermFee = req.TermFee
+		fs.EffectiveFrom = req.EffectiveFrom
+		fs.EffectiveTo = req.EffectiveTo
+		if err := tx.Save(&fs).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("fee_schedule_id = ?", fs.ID).Delete(&entity.LabFee{}).Error; err != nil {
+			return err
+		}
+		fs.LabFees = toLabFees(req.LabFees)
+		for i := range fs.LabFees {
+			fs.LabFees[i].FeeScheduleID = fs.ID
+		}
+		if len(fs.LabFees) > 0 {
+			return tx.Create(&fs.LabFees).Error
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "fee schedule not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, fs)
+}
+
+// DELETE /fee-schedules/:id (soft delete เพื่อให้บิลเดิมยังอ้างอิงได้)
+func DeleteFeeSchedule(c *gin.Context) {
+	id := c.Param("id")
+	db := config.DB()
+
+	result := db.Delete(&entity.FeeSchedule{}, id)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "fee schedule not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Delete fee schedule success"})
+}
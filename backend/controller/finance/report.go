@@ -0,0 +1,125 @@
+package finance
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"reg_system/config"
+	"reg_system/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+)
+
+// ส่งรายงานตาม ?format= : json (ค่าเริ่มต้น), csv หรือ xlsx
+func writeReport(c *gin.Context, name string, data interface{}, header []string, rows [][]string) {
+	switch c.DefaultQuery("format", "json") {
+	case "json":
+		c.JSON(http.StatusOK, data)
+	case "csv":
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.csv"`, name))
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Status(http.StatusOK)
+		// BOM ให้ Excel เปิดภาษาไทยได้ถูกต้อง
+		c.Writer.WriteString("\ufeff")
+		w := csv.NewWriter(c.Writer)
+		w.Write(header)
+		w.WriteAll(rows)
+	case "xlsx":
+		f := excelize.NewFile()
+		defer f.Close()
+		sheet := f.GetSheetName(0)
+		for i, row := range append([][]string{header}, rows...) {
+			values := make([]interface{}, len(row))
+			for j, v := range row {
+				// ตัวเลขเขียนเป็น number เพื่อให้รวมยอดใน Excel ได้
+				if n, err := strconv.ParseFloat(v, 64); err == nil && i > 0 {
+					values[j] = n
+				} else {
+					values[j] = v
+				}
+			}
+			cell, _ := excelize.CoordinatesToCellName(1, i+1)
+			if err := f.SetSheetRow(sheet, cell, &values); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.xlsx"`, name))
+		c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		c.Status(http.StatusOK)
+		if err := f.Write(c.Writer); err != nil {
+			c.Error(err)
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json, csv or xlsx"})
+	}
+}
+
+func itoa(n int) string { return strconv.Itoa(n) }
+
+// GET /finance/reports/revenue - ยอดเรียกเก็บเทียบยอดรับชำระ ต่อเทอม/คณะ/สาขา
+func GetRevenueReport(c *gin.Context) {
+	rows, err := services.RevenueReport(config.DB())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	table := make([][]string, 0, len(rows))
+	for _, r := range rows {
+		table = append(table, []string{
+			itoa(r.AcademicYear), itoa(r.Term), r.FacultyID, r.FacultyName, r.MajorID, r.MajorName,
+			itoa(r.Bills), itoa(r.Billed), itoa(r.Collected), itoa(r.Outstanding),
+		})
+	}
+	writeReport(c, "revenue", rows,
+		[]string{"AcademicYear", "Term", "FacultyID", "FacultyName", "MajorID", "MajorName", "Bills", "Billed", "Collected", "Outstanding"},
+		table)
+}
+
+// GET /finance/reports/aging?as_of=2006-01-02 - อายุลูกหนี้ค้างชำระ
+func GetAgingReport(c *gin.Context) {
+	asOf := time.Now()
+	if v := c.Query("as_of"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "as_of must be YYYY-MM-DD"})
+			return
+		}
+		asOf = t
+	}
+
+	rows, err := services.AgingReport(config.DB(), asOf)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	table := make([][]string, 0, len(rows))
+	for _, r := range rows {
+		table = append(table, []string{r.Bucket, itoa(r.Bills), itoa(r.Students), itoa(r.Amount)})
+	}
+	writeReport(c, "aging", rows, []string{"Bucket", "Bills", "Students", "Amount"}, table)
+}
+
+// GET /finance/reports/collection - อัตราการเก็บเงินแยกตามรุ่น
+func GetCollectionReport(c *gin.Context) {
+	rows, err := services.CollectionReport(config.DB())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	table := make([][]string, 0, len(rows))
+	for _, r := range rows {
+		table = append(table, []string{
+			itoa(r.Cohort), itoa(r.Students), itoa(r.Billed), itoa(r.Collected),
+			strconv.FormatFloat(r.Rate, 'f', 2, 64),
+		})
+	}
+	writeReport(c, "collection", rows, []string{"Cohort", "Students", "Billed", "Collected", "Rate"}, table)
+}
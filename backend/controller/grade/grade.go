@@ -82,7 +82,14 @@ func GetGradeByStudentID(c *gin.Context) {
 	// ใช้ loop ในการวนค่า array ของ grade ใน [] ออกมา
 	// append ค่าเข้าตัวเเปรที่สร้างไว้ด้วย Struct ที่สร้างขึ้นมา
 
-	// ประกาศตัวเเปรเป็น Struct ที่ส่งออกข้อมูล
+	response := toGradeResponses(grades)
+
+	c.JSON(http.StatusOK, &response)
+
+}
+
+// แปลงเกรดเป็นรูปแบบที่ frontend ใช้ (ต้อง Preload Subject และ Subject.Semester)
+func toGradeResponses(grades []entity.Grades) []GradeResponse {
 	var response []GradeResponse
 
 	for _, grade := range grades {
@@ -110,7 +117,5 @@ func GetGradeByStudentID(c *gin.Context) {
 			AcademicYear: academicYear,
 		})
 	}
-
-	c.JSON(http.StatusOK, &response)
-
+	return response
 }
@@ -0,0 +1,63 @@
+package grade
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"reg_system/config"
+	"reg_system/entity"
+	"reg_system/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GET /students/:id/transcript - ออกใบแสดงผลการเรียน (ถูกระงับได้ด้วย hold)
+func GetTranscript(c *gin.Context) {
+	sid := c.Param("id")
+	claims := services.CurrentClaims(c)
+	if claims.Role != "admin" && claims.Username != sid {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+
+	db := config.DB()
+	var student entity.Students
+	if err := db.First(&student, "student_id = ?", sid).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Student not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		}
+		return
+	}
+
+	holds, err := services.BlockingHolds(db, sid, services.HoldActionTranscript)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(holds) > 0 {
+		c.JSON(http.StatusForbidden, gin.H{"error": "transcript is blocked by active holds", "holds": holds})
+		return
+	}
+
+	var grades []entity.Grades
+	if err := db.Preload("Subject").Preload("Subject.Semester").
+		Where("student_id = ?", sid).Find(&grades).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	totalCredits, _ := services.CalculateTotalCredits(sid)
+
+	c.JSON(http.StatusOK, gin.H{
+		"StudentID":    student.StudentID,
+		"FirstName":    student.FirstName,
+		"LastName":     student.LastName,
+		"GPAX":         services.CalculateGPA(grades),
+		"TotalCredits": totalCredits,
+		"Grades":       toGradeResponses(grades),
+		"IssuedAt":     time.Now(),
+	})
+}
@@ -2,6 +2,7 @@ package graduation
 
 import (
 	//"fmt"
+	"errors"
 	"log"
 	"net/http"
 	"reg_system/config"
@@ -38,6 +39,17 @@ func CreateGraduation(c *gin.Context) {
 		return
 	}
 
+	// นักศึกษาที่ถูกระงับสิทธิ์ (เช่น ค้างชำระ) แจ้งจบไม่ได้
+	holds, err := services.BlockingHolds(db, input.StudentID, services.HoldActionGraduation)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(holds) > 0 {
+		c.JSON(http.StatusForbidden, gin.H{"error": "graduation is blocked by active holds", "holds": holds})
+		return
+	}
+
 	// 2️⃣ สร้าง Graduation object โดยใช้ CurriculumID จาก student table
 	graduation := entity.Graduation{
 		StudentID:    input.StudentID,
@@ -46,18 +58,20 @@ func CreateGraduation(c *gin.Context) {
 	}
 
 	// 3️⃣ ใช้ transaction เพื่อให้ทั้งการสร้าง Graduation และอัพเดท status นักศึกษาเป็น atomic operation
-	err := db.Transaction(func(tx *gorm.DB) error {
+	err = db.Transaction(func(tx *gorm.DB) error {
 		if err := tx.Create(&graduation).Error; err != nil {
 			return err
 		}
-		if err := tx.Model(&entity.Students{}).
-			Where("student_id = ?", input.StudentID).
-			Update("status_student_id", "20").Error; err != nil {
-			return err
-		}
-		return nil
+		return services.ChangeStudentStatus(tx, input.StudentID, entity.StatusGraduationRequested, services.StatusChange{
+			Reason:     "ยื่นคำขอแจ้งจบ",
+			ApprovedBy: input.StudentID,
+		})
 	})
 
+	if errors.Is(err, services.ErrStatusTransition) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -233,13 +247,7 @@ func UpdateGraduation(c *gin.Context) {
 	graduationID := c.Param("id")
 
 	// --- ตรวจสอบ role ของผู้ใช้ ---
-	claimsI, exists := c.Get("user")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
-		return
-	}
-
-	claims := claimsI.(*services.JwtClaim) // ใช้ struct จาก JWT
+	claims := services.CurrentClaims(c) // ใช้ struct จาก JWT
 	if claims.Role != "admin" {
 		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden: insufficient permissions"})
 		return
@@ -265,10 +273,15 @@ func UpdateGraduation(c *gin.Context) {
 			return err
 		}
 
-		// อัปเดต StatusStudent ของนักเรียน
-		if err := tx.Model(&entity.Students{}).
-			Where("student_id = ?", graduation.StudentID).
-			Update("status_student_id", input.StatusStudentID).Error; err != nil {
+		// อัปเดต StatusStudent ของนักเรียน (ตรวจเส้นทางและบันทึกประวัติ)
+		reason := "พิจารณาคำขอแจ้งจบ"
+		if input.RejectReason != nil && *input.RejectReason != "" {
+			reason = *input.RejectReason
+		}
+		if err := services.ChangeStudentStatus(tx, graduation.StudentID, input.StatusStudentID, services.StatusChange{
+			Reason:     reason,
+			ApprovedBy: claims.Username,
+		}); err != nil {
 			return err
 		}
 
@@ -281,6 +294,10 @@ func UpdateGraduation(c *gin.Context) {
 		return nil
 	})
 
+	if errors.Is(err, services.ErrStatusTransition) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -0,0 +1,160 @@
+package hold
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"reg_system/config"
+	"reg_system/entity"
+	"reg_system/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func currentUsername(c *gin.Context) string {
+	return services.CurrentClaims(c).Username
+}
+
+// GET /holds/?student_id=&active=true
+func GetHoldAll(c *gin.Context) {
+	q := config.DB().Model(&entity.Hold{})
+	if sid := c.Query("student_id"); sid != "" {
+		q = q.Where("student_id = ?", sid)
+	}
+	if c.Query("active") == "true" {
+		q = q.Where("released_at IS NULL AND (release_date IS NULL OR release_date > ?)", time.Now())
+	}
+
+	var holds []entity.Hold
+	if err := q.Order("placed_at DESC").Find(&holds).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, holds)
+}
+
+// POST /holds/ - เจ้าหน้าที่ระงับสิทธิ์นักศึกษา
+// สิทธิ์ที่ถูกระงับเป็นไปตามประเภท เว้นแต่ส่ง Blocks* มากำหนดเอง
+func CreateHold(c *gin.Context) {
+	var req struct {
+		StudentID          string     `json:"StudentID" binding:"required"`
+		Type               string     `json:"Type" binding:"required"`
+		Reason             string     `json:"Reason" binding:"required"`
+		Office             string     `json:"Office" binding:"required"`
+		ReleaseDate        *time.Time `json:"ReleaseDate"`
+		BlocksRegistration *bool      `json:"BlocksRegistration"`
+		BlocksTranscript   *bool      `json:"BlocksTranscript"`
+		BlocksGraduation   *bool      `json:"BlocksGraduation"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := config.DB()
+	var count int64
+	if err := db.Model(&entity.Students{}).Where("student_id = ?", req.StudentID).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		return
+	}
+	if count == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Student not found"})
+		return
+	}
+
+	h := entity.Hold{
+		StudentID:   req.StudentID,
+		Type:        req.Type,
+		Reason:      req.Reason,
+		Office:      req.Office,
+		ReleaseDate: req.ReleaseDate,
+		PlacedBy:    currentUsername(c),
+		PlacedAt:    time.Now(),
+	}
+	if err := services.ApplyHoldDefaults(&h); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.BlocksRegistration != nil {
+		h.BlocksRegistration = *req.BlocksRegistration
+	}
+	if req.BlocksTranscript != nil {
+		h.BlocksTranscript = *req.BlocksTranscript
+	}
+	if req.BlocksGraduation != nil {
+		h.BlocksGraduation = *req.BlocksGraduation
+	}
+
+	if err := db.Create(&h).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, h)
+}
+
+// PUT /holds/:id/release - ปลดการระงับสิทธิ์
+// body: { Note }
+func ReleaseHold(c *gin.Context) {
+	var req struct {
+		Note string `json:"Note"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := config.DB()
+	var h entity.Hold
+	if err := db.First(&h, c.Param("id")).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "hold not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		}
+		return
+	}
+	if h.ReleasedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "hold is already released"})
+		return
+	}
+
+	if err := services.ReleaseHold(db, &h, currentUsername(c), req.Note); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, h)
+}
+
+// POST /holds/overdue - ฝ่ายการเงินระงับสิทธิ์นักศึกษาที่ค้างชำระเกินกำหนด
+func PlaceOverdueHolds(c *gin.Context) {
+	var placed []entity.Hold
+	err := config.DB().Transaction(func(tx *gorm.DB) error {
+		var err error
+		placed, err = services.PlaceOverdueHolds(tx, time.Now())
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"placed": len(placed), "holds": placed})
+}
+
+// GET /students/:id/holds - hold ที่ยังมีผลของนักศึกษา
+func GetHoldsByStudentID(c *gin.Context) {
+	sid := c.Param("id")
+	claims := services.CurrentClaims(c)
+	if claims.Role != "admin" && claims.Username != sid {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+
+	holds, err := services.ActiveHolds(config.DB(), sid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, holds)
+}
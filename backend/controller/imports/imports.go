@@ -0,0 +1,73 @@
+package imports
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"reg_system/config"
+	"reg_system/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type importFunc func(tx *gorm.DB, rows [][]string, mapping services.ImportMapping, dryRun bool) (*services.ImportResult, error)
+
+// อ่านไฟล์จาก form-data แล้วนำเข้าด้วย fn
+// form-data: file (.csv/.xlsx), mapping (JSON ไม่บังคับ เช่น {"StudentID":"รหัสนักศึกษา"}), sheet, dry_run=true
+func runImport(c *gin.Context, fn importFunc) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file not found"})
+		return
+	}
+
+	mapping := services.ImportMapping{}
+	if raw := c.PostForm("mapping"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid mapping: " + err.Error()})
+			return
+		}
+	}
+	dryRun := c.PostForm("dry_run") == "true" || c.Query("dry_run") == "true"
+
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cannot open file"})
+		return
+	}
+	defer f.Close()
+
+	rows, err := services.ReadImportTable(f, file.Filename, c.PostForm("sheet"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var result *services.ImportResult
+	err = config.DB().Transaction(func(tx *gorm.DB) error {
+		var err error
+		result, err = fn(tx, rows, mapping, dryRun)
+		return err
+	})
+	if errors.Is(err, services.ErrImportFormat) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// POST /students/import - นำเข้านักศึกษาจำนวนมาก (สร้างบัญชีผู้ใช้ให้ด้วย)
+func ImportStudents(c *gin.Context) {
+	runImport(c, services.ImportStudents)
+}
+
+// POST /teachers/import - นำเข้าอาจารย์จำนวนมาก (สร้างบัญชีผู้ใช้และ Reviewer ให้ด้วย)
+func ImportTeachers(c *gin.Context) {
+	runImport(c, services.ImportTeachers)
+}
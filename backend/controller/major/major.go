@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"reg_system/config"
 	"reg_system/entity"
+	"strings"
 )
 
 func GetMajorAll(c *gin.Context) {
@@ -37,3 +38,35 @@ func CreateMajor(c *gin.Context) {
 
 	c.JSON(http.StatusOK, major)
 }
+
+// PUT /majors/:id/head - กำหนดหัวหน้าสาขาวิชา (ผู้พิจารณาคำร้องขั้น department_head)
+// body: { HeadTeacherID }
+func UpdateMajorHead(c *gin.Context) {
+	var body struct {
+		HeadTeacherID string `json:"HeadTeacherID"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	headID := strings.TrimSpace(body.HeadTeacherID)
+
+	db := config.DB()
+	var major entity.Majors
+	if err := db.First(&major, "major_id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "major not found"})
+		return
+	}
+	if headID != "" {
+		var teacher entity.Teachers
+		if err := db.Select("teacher_id").First(&teacher, "teacher_id = ?", headID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "teacher not found"})
+			return
+		}
+	}
+	if err := db.Model(&major).Update("head_teacher_id", headID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, major)
+}
@@ -0,0 +1,218 @@
+package pdpa
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"reg_system/config"
+	"reg_system/entity"
+	"reg_system/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func writeDataRequestError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "student not found"})
+	case errors.Is(err, services.ErrErasureScope):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrDataRequestOpen), errors.Is(err, services.ErrDataRequestState):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// GET /students/:id/data-export?format=json|zip - สำเนาข้อมูลส่วนบุคคลทั้งหมดของนักศึกษา
+// zip จะรวมไฟล์แนบไว้ในโฟลเดอร์ files/ ทุกครั้งที่ส่งออกจะบันทึกคำขอเข้าถึงข้อมูลและ AuditEvent
+// เลขประจำตัวประชาชนแสดงเต็มเฉพาะนักศึกษาเจ้าของข้อมูลหรือแอดมินใน PII_REVEAL_ADMINS (บันทึก AuditEvent การดูด้วย)
+func ExportStudentData(c *gin.Context) {
+	sid := c.Param("id")
+	claims := services.CurrentClaims(c)
+	if claims.Role != "admin" && claims.Username != sid {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "zip" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or zip"})
+		return
+	}
+
+	var exp *services.StudentDataExport
+	err := config.DB().Transaction(func(tx *gorm.DB) error {
+		if _, err := services.RecordDataAccess(tx, sid, claims.Username); err != nil {
+			return err
+		}
+		if err := services.RecordAudit(tx, &entity.AuditEvent{
+			Actor:   claims.Username,
+			Role:    claims.Role,
+			Action:  entity.AuditPDPAExport,
+			Subject: "students/" + sid,
+			Reason:  c.Query("reason"),
+			IP:      c.ClientIP(),
+		}); err != nil {
+			return err
+		}
+		reveal := (claims.Role == "student" && claims.Username == sid) ||
+			(claims.Role == "admin" && config.CanRevealPII(claims.Username))
+		if reveal {
+			if err := services.RecordAudit(tx, &entity.AuditEvent{
+				Actor:   claims.Username,
+				Role:    claims.Role,
+				Action:  entity.AuditPIIReveal,
+				Subject: "students/" + sid,
+				Field:   "CitizenID",
+				Reason:  c.Query("reason"),
+				IP:      c.ClientIP(),
+			}); err != nil {
+				return err
+			}
+		}
+		var err error
+		exp, err = services.BuildStudentExport(tx, sid, reveal)
+		return err
+	})
+	if err != nil {
+		writeDataRequestError(c, err)
+		return
+	}
+
+	if format == "json" {
+		c.JSON(http.StatusOK, exp)
+		return
+	}
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s_personal_data.zip", sid))
+	if err := services.WriteStudentExportZip(c.Writer, exp); err != nil {
+		c.Error(err)
+	}
+}
+
+// POST /data-requests/ - ยื่นคำขอลบข้อมูลส่วนบุคคล
+// body: { Scopes: ["contact", "sensitive", "attachments"], Reason, StudentID (เฉพาะแอดมินยื่นแทน) }
+func CreateErasureRequest(c *gin.Context) {
+	var req struct {
+		StudentID string   `json:"StudentID"`
+		Scopes    []string `json:"Scopes" binding:"required"`
+		Reason    string   `json:"Reason"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	claims := services.CurrentClaims(c)
+	if claims.Role != "admin" || req.StudentID == "" {
+		req.StudentID = claims.Username
+	}
+
+	var r *entity.DataSubjectRequest
+	err := config.DB().Transaction(func(tx *gorm.DB) error {
+		var err error
+		r, err = services.CreateErasureRequest(tx, req.StudentID, req.Scopes, req.Reason, claims.Username)
+		return err
+	})
+	if err != nil {
+		writeDataRequestError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, r)
+}
+
+// GET /data-requests/?type=&status= - คำขอของเจ้าของข้อมูลทั้งหมด
+func GetDataRequestAll(c *gin.Context) {
+	q := config.DB().Model(&entity.DataSubjectRequest{})
+	if t := c.Query("type"); t != "" {
+		q = q.Where("type = ?", t)
+	}
+	if status := c.Query("status"); status != "" {
+		q = q.Where("status = ?", status)
+	}
+
+	var requests []entity.DataSubjectRequest
+	if err := q.Order("requested_at DESC").Find(&requests).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, requests)
+}
+
+// GET /students/:id/data-requests - ประวัติคำขอเข้าถึง/ลบข้อมูลของนักศึกษา
+func GetDataRequestsByStudentID(c *gin.Context) {
+	sid := c.Param("id")
+	if claims := services.CurrentClaims(c); claims.Role != "admin" && claims.Username != sid {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+
+	var requests []entity.DataSubjectRequest
+	if err := config.DB().Where("student_id = ?", sid).Order("requested_at DESC").Find(&requests).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, requests)
+}
+
+// PUT /data-requests/:id - อนุมัติ (ลบข้อมูลทันที) หรือปฏิเสธคำขอลบข้อมูล
+// body: { Status: "approved" | "rejected", Reason }
+func ReviewErasureRequest(c *gin.Context) {
+	var req struct {
+		Status string `json:"Status" binding:"required"`
+		Reason string `json:"Reason"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	switch {
+	case req.Status != "approved" && req.Status != entity.DataRequestRejected:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be approved or rejected"})
+		return
+	case req.Status == entity.DataRequestRejected && req.Reason == "":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reason is required when rejecting a request"})
+		return
+	}
+
+	db := config.DB()
+	var r entity.DataSubjectRequest
+	if err := db.First(&r, "id = ? AND type = ?", c.Param("id"), entity.DataRequestErasure).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "erasure request not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		}
+		return
+	}
+
+	claims := services.CurrentClaims(c)
+	var files []string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if files, err = services.ReviewErasureRequest(tx, &r, req.Status == "approved", req.Reason, claims.Username); err != nil {
+			return err
+		}
+		if r.Status != entity.DataRequestCompleted {
+			return nil
+		}
+		return services.RecordAudit(tx, &entity.AuditEvent{
+			Actor:   claims.Username,
+			Role:    claims.Role,
+			Action:  entity.AuditPDPAErasure,
+			Subject: "students/" + r.StudentID,
+			Field:   fmt.Sprint(r.Scopes),
+			Reason:  r.Reason,
+			IP:      c.ClientIP(),
+		})
+	})
+	if err != nil {
+		writeDataRequestError(c, err)
+		return
+	}
+
+	// ลบไฟล์หลัง commit เพื่อไม่ให้ไฟล์หายถ้า transaction ล้มเหลว
+	services.RemoveUploads(files)
+	c.JSON(http.StatusOK, r)
+}
@@ -0,0 +1,225 @@
+package programtransfer
+
+import (
+	"errors"
+	"net/http"
+
+	"reg_system/config"
+	"reg_system/entity"
+	"reg_system/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func findTransfer(c *gin.Context, db *gorm.DB) (*entity.ProgramTransfer, bool) {
+	var t entity.ProgramTransfer
+	if err := db.First(&t, c.Param("id")).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "program transfer not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		}
+		return nil, false
+	}
+	return &t, true
+}
+
+func writeTransferError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "student, curriculum or semester not found"})
+	case errors.Is(err, services.ErrTransferState), errors.Is(err, services.ErrTransferOpen):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrTransferGPAX), errors.Is(err, services.ErrTransferSameProgram):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// POST /program-transfers/ - ยื่นคำขอย้ายสาขา/หลักสูตร
+// body: { ToCurriculumID, Reason, StudentID (เฉพาะแอดมินยื่นแทน) }
+func CreateProgramTransfer(c *gin.Context) {
+	var req struct {
+		StudentID      string `json:"StudentID"`
+		ToCurriculumID string `json:"ToCurriculumID" binding:"required"`
+		Reason         string `json:"Reason"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	claims := services.CurrentClaims(c)
+	if claims.Role != "admin" || req.StudentID == "" {
+		req.StudentID = claims.Username
+	}
+
+	var t *entity.ProgramTransfer
+	err := config.DB().Transaction(func(tx *gorm.DB) error {
+		var err error
+		t, err = services.CreateProgramTransfer(tx, req.StudentID, req.ToCurriculumID, req.Reason)
+		return err
+	})
+	if err != nil {
+		writeTransferError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, t)
+}
+
+// GET /program-transfers/?status=&faculty_id= - คำขอย้ายทั้งหมด
+// อาจารย์เห็นเฉพาะคำขอที่ย้ายเข้าคณะของตนเอง
+func GetProgramTransferAll(c *gin.Context) {
+	db := config.DB()
+	q := db.Preload("Student").Preload("ToCurriculum")
+	if status := c.Query("status"); status != "" {
+		q = q.Where("status = ?", status)
+	}
+
+	claims := services.CurrentClaims(c)
+	if claims.Role == "teacher" {
+		var teacher entity.Teachers
+		if err := db.First(&teacher, "teacher_id = ?", claims.Username).Error; err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "teacher not found"})
+			return
+		}
+		q = q.Where("to_faculty_id = ?", teacher.FacultyID)
+	} else if facultyID := c.Query("faculty_id"); facultyID != "" {
+		q = q.Where("to_faculty_id = ?", facultyID)
+	}
+
+	var transfers []entity.ProgramTransfer
+	if err := q.Order("requested_at DESC").Find(&transfers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, transfers)
+}
+
+// GET /students/:id/program-transfers - ประวัติการย้ายสาขา/หลักสูตรพร้อมการเทียบโอน
+func GetProgramTransfersByStudentID(c *gin.Context) {
+	sid := c.Param("id")
+	if claims := services.CurrentClaims(c); claims.Role != "admin" && claims.Username != sid {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+
+	var transfers []entity.ProgramTransfer
+	if err := config.DB().Preload("CreditMappings").Preload("EffectiveSemester").
+		Where("student_id = ?", sid).Order("requested_at DESC").Find(&transfers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, transfers)
+}
+
+// PUT /program-transfers/:id/faculty-review - คณะปลายทางรับ/ไม่รับนักศึกษา
+// body: { Status: "accepted" | "rejected", Comment }
+func FacultyReviewTransfer(c *gin.Context) {
+	var req struct {
+		Status  string `json:"Status" binding:"required"`
+		Comment string `json:"Comment"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Status != "accepted" && req.Status != "rejected" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be accepted or rejected"})
+		return
+	}
+
+	db := config.DB()
+	t, ok := findTransfer(c, db)
+	if !ok {
+		return
+	}
+	claims := services.CurrentClaims(c)
+	if claims.Role == "teacher" {
+		var teacher entity.Teachers
+		if err := db.First(&teacher, "teacher_id = ?", claims.Username).Error; err != nil || teacher.FacultyID != t.ToFacultyID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "only the target faculty can review this request"})
+			return
+		}
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return services.FacultyReviewTransfer(tx, t, req.Status == "accepted", req.Comment, claims.Username)
+	})
+	if err != nil {
+		writeTransferError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, t)
+}
+
+// PUT /program-transfers/:id - เจ้าหน้าที่อนุมัติการย้าย (มีผลตั้งแต่ภาคการศึกษาที่ระบุ) หรือไม่อนุมัติ
+// ถ้าภาคการศึกษายังไม่เริ่ม คำขอเป็น scheduled และย้ายสาขาเมื่อถึงวันเปิดภาค
+// body: { Status: "approved" | "rejected", EffectiveSemesterID, Reason,
+//
+//	Mappings: [{ SubjectID, TargetSubjectID, Counted }] }
+func ReviewProgramTransfer(c *gin.Context) {
+	var req struct {
+		Status              string                         `json:"Status" binding:"required"`
+		EffectiveSemesterID int                            `json:"EffectiveSemesterID"`
+		Reason              string                         `json:"Reason"`
+		Mappings            []entity.TransferCreditMapping `json:"Mappings"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	switch {
+	case req.Status != entity.ProgramTransferApproved && req.Status != entity.ProgramTransferRejected:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be approved or rejected"})
+		return
+	case req.Status == entity.ProgramTransferApproved && req.EffectiveSemesterID == 0:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "EffectiveSemesterID is required"})
+		return
+	case req.Status == entity.ProgramTransferRejected && req.Reason == "":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reason is required when rejecting a transfer"})
+		return
+	}
+
+	db := config.DB()
+	t, ok := findTransfer(c, db)
+	if !ok {
+		return
+	}
+
+	reviewer := services.CurrentClaims(c).Username
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if req.Status == entity.ProgramTransferRejected {
+			return services.RejectProgramTransfer(tx, t, req.Reason, reviewer)
+		}
+		return services.ApproveProgramTransfer(tx, t, req.EffectiveSemesterID, req.Mappings, reviewer)
+	})
+	if err != nil {
+		writeTransferError(c, err)
+		return
+	}
+
+	db.Preload("CreditMappings").First(t, t.ID)
+	c.JSON(http.StatusOK, t)
+}
+
+// GET /students/:id/degree-audit - ตรวจสอบหน่วยกิตเทียบกับหลักสูตรปัจจุบัน
+func GetDegreeAudit(c *gin.Context) {
+	sid := c.Param("id")
+	if claims := services.CurrentClaims(c); claims.Role != "admin" && claims.Username != sid {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+
+	res, err := services.DegreeAudit(config.DB(), sid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Student not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
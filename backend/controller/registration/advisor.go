@@ -0,0 +1,222 @@
+package registration
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"reg_system/config"
+	"reg_system/entity"
+	"reg_system/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// แผนการเรียนของนักศึกษา 1 เทอม (กลุ่มของ registration ในเทอมเดียวกัน)
+type StudyPlanResponse struct {
+	SemesterID    int                    `json:"SemesterID"`
+	Term          int                    `json:"Term"`
+	AcademicYear  int                    `json:"AcademicYear"`
+	TotalCredit   int                    `json:"TotalCredit"`
+	Registrations []RegistrationResponse `json:"Registrations"`
+}
+
+type AdviseeResponse struct {
+	StudentID string              `json:"StudentID"`
+	FirstName string              `json:"FirstName"`
+	LastName  string              `json:"LastName"`
+	Plans     []StudyPlanResponse `json:"Plans"`
+}
+
+// อาจารย์ดูข้อมูลได้เฉพาะของตัวเอง (admin ผ่านได้เสมอ)
+func isSelfOrAdmin(c *gin.Context, teacherID string) bool {
+	claims := services.CurrentClaims(c)
+	return claims.Role == "admin" || claims.Username == teacherID
+}
+
+// เทอมของรายการลงทะเบียน: ใช้เทอมของรายวิชาเป็นหลัก ถ้าไม่มีใช้ SemesterID ที่บันทึกไว้
+func registrationSemester(reg entity.Registration) (int, int, int) {
+	if reg.Subject != nil && reg.Subject.Semester != nil {
+		id, _ := strconv.Atoi(reg.Subject.Semester.ID)
+		return id, reg.Subject.Semester.Term, reg.Subject.Semester.AcademicYear
+	}
+	return reg.SemesterID, 0, 0
+}
+
+// GET /teachers/:id/advisees/registrations?status=pending
+// ดึงแผนการเรียนของนักศึกษาในที่ปรึกษา แยกตามนักศึกษาและเทอม
+func GetAdviseeRegistrations(c *gin.Context) {
+	tid := c.Param("id")
+	if !isSelfOrAdmin(c, tid) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden: not your advisees"})
+		return
+	}
+
+	status := c.DefaultQuery("status", entity.RegistrationPending)
+
+	var advisees []entity.Students
+	db := config.DB()
+	if err := db.Where("advisor_id = ?", tid).Find(&advisees).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := []AdviseeResponse{}
+	for _, stu := range advisees {
+		var regs []entity.Registration
+		q := db.Preload("Subject").Preload("Subject.Semester").Where("student_id = ?", stu.StudentID)
+		if status != "all" {
+			q = q.Where("status = ?", status)
+		}
+		if err := q.Find(&regs).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if len(regs) == 0 {
+			continue
+		}
+
+		plans := map[int]*StudyPlanResponse{}
+		for _, reg := range regs {
+			semID, term, year := registrationSemester(reg)
+			plan, ok := plans[semID]
+			if !ok {
+				plan = &StudyPlanResponse{SemesterID: semID, Term: term, AcademicYear: year}
+				plans[semID] = plan
+			}
+
+			subjectName := ""
+			credit := 0
+			if reg.Subject != nil {
+				subjectName = reg.Subject.SubjectName
+				credit = reg.Subject.Credit
+			}
+			plan.TotalCredit += credit
+			plan.Registrations = append(plan.Registrations, RegistrationResponse{
+				ID:             reg.ID,
+				RegistrationID: reg.RegistrationID,
+				SubjectID:      reg.SubjectID,
+				SubjectName:    subjectName,
+				Credit:         credit,
+				Status:         reg.Status,
+				AdvisorComment: reg.AdvisorComment,
+			})
+		}
+
+		out := AdviseeResponse{
+			StudentID: stu.StudentID,
+			FirstName: stu.FirstName,
+			LastName:  stu.LastName,
+		}
+		for _, p := range plans {
+			out.Plans = append(out.Plans, *p)
+		}
+		sort.Slice(out.Plans, func(i, j int) bool { return out.Plans[i].SemesterID < out.Plans[j].SemesterID })
+		response = append(response, out)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// PUT /teachers/:id/advisees/:sid/registrations
+// body: { Status: "approved" | "rejected", Comment, SemesterID?, RegistrationIDs? }
+// ถ้าไม่ระบุ RegistrationIDs จะพิจารณารายการที่รออนุมัติทั้งหมด (ของเทอมที่ระบุ ถ้ามี)
+func ReviewAdviseeRegistrations(c *gin.Context) {
+	tid := c.Param("id")
+	sid := c.Param("sid")
+	if !isSelfOrAdmin(c, tid) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden: not your advisees"})
+		return
+	}
+
+	var payload struct {
+		Status          string `json:"Status" binding:"required"`
+		Comment         string `json:"Comment"`
+		SemesterID      int    `json:"SemesterID"`
+		RegistrationIDs []int  `json:"RegistrationIDs"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if payload.Status != entity.RegistrationApproved && payload.Status != entity.RegistrationRejected {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be approved or rejected"})
+		return
+	}
+	if payload.Status == entity.RegistrationRejected && payload.Comment == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "comment is required when rejecting"})
+		return
+	}
+
+	db := config.DB()
+
+	var student entity.Students
+	if err := db.First(&student, "student_id = ?", sid).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "student not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		}
+		return
+	}
+	if student.AdvisorID != tid {
+		c.JSON(http.StatusForbidden, gin.H{"error": "student is not an advisee of this teacher"})
+		return
+	}
+
+	var regs []entity.Registration
+	q := db.Preload("Subject.Semester").Where("student_id = ? AND status = ?", sid, entity.RegistrationPending)
+	if len(payload.RegistrationIDs) > 0 {
+		q = q.Where("id IN ?", payload.RegistrationIDs)
+	}
+	if err := q.Find(&regs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ids := []int{}
+	for _, reg := range regs {
+		if semID, _, _ := registrationSemester(reg); payload.SemesterID != 0 && semID != payload.SemesterID {
+			continue
+		}
+		ids = append(ids, reg.ID)
+	}
+	if len(ids) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no pending registrations found"})
+		return
+	}
+
+	now := time.Now()
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entity.Registration{}).
+			Where("id IN ?", ids).
+			Updates(map[string]interface{}{
+				"status":          payload.Status,
+				"advisor_comment": payload.Comment,
+				"reviewed_by":     tid,
+				"reviewed_at":     now,
+			}).Error; err != nil {
+			return err
+		}
+		// รายการที่อนุมัติแล้วต้องนับในบิลของเทอมนั้น
+		for _, reg := range regs {
+			if err := services.RefreshBillForRegistration(tx, reg); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":          "registrations reviewed",
+		"status":           payload.Status,
+		"registration_ids": ids,
+	})
+}
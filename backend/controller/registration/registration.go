@@ -1,12 +1,15 @@
 package registration
 
 import (
+	"errors"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 	"net/http"
 	"reg_system/config"
 	"reg_system/entity"
+	"reg_system/services"
 )
 
 func CreateRegistration(c *gin.Context) {
@@ -18,14 +21,57 @@ func CreateRegistration(c *gin.Context) {
 	}
 
 	db := config.DB()
-	result := db.Create(&registration)
 
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+	// หลักสูตรที่ต้องให้อาจารย์ที่ปรึกษาอนุมัติ จะเริ่มที่สถานะ pending
+	status, err := services.InitialRegistrationStatus(db, registration.StudentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "student not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
+	registration.Status = status
 
-	c.JSON(http.StatusOK, gin.H{"message": "Create registration success"})
+	// ลาพัก/พักการศึกษา/ลาออก/สิ้นสภาพ/จบการศึกษาแล้ว ลงทะเบียนไม่ได้
+	var student entity.Students
+	if err := db.First(&student, "student_id = ?", registration.StudentID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !services.StatusAllowsRegistration(student.StatusStudentID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": services.ErrRegistrationStatus.Error(), "status": student.StatusStudentID})
+		return
+	}
+
+	holds, err := services.BlockingHolds(db, registration.StudentID, services.HoldActionRegistration)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(holds) > 0 {
+		c.JSON(http.StatusForbidden, gin.H{"error": "registration is blocked by active holds", "holds": holds})
+		return
+	}
+	registration.AdvisorComment = ""
+	registration.ReviewedBy = ""
+	registration.ReviewedAt = nil
+
+	// เพิ่มรายวิชาแล้วคำนวณบิลของเทอมนั้นใหม่ (ถ้ามีบิลอยู่แล้ว)
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&registration).Error; err != nil {
+			return err
+		}
+		return services.RefreshBillForRegistration(tx, *registration)
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Create registration success", "status": registration.Status})
 }
 func GetRegistrationAll(c *gin.Context) {
 	var registrations []entity.Registration
@@ -90,6 +136,8 @@ func GetRegistrationByStudentID(c *gin.Context) {
             Credit:         credit,
             StartAt:        startAt,
             EndAt:          endAt,
+            Status:         reg.Status,
+            AdvisorComment: reg.AdvisorComment,
         })
     }
     c.JSON(http.StatusOK, response)
@@ -110,9 +158,14 @@ func UpdateRegistration(c *gin.Context) {
 		return
 	}
 
-	result := db.Save(&registration)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&registration).Error; err != nil {
+			return err
+		}
+		return services.RefreshBillForRegistration(tx, registration)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -128,9 +181,18 @@ func DeleteRegistration(c *gin.Context) {
 		return
 	}
 
-	result := db.Delete(&registration)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+	// ถอนรายวิชาแล้วคำนวณบิลของเทอมนั้นใหม่ ถอนหลังช่วงเพิ่ม-ถอนจะคิดค่าธรรมเนียมส่วนที่ไม่คืนตามนโยบาย
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := services.ApplyDropPolicy(tx, registration, time.Now()); err != nil {
+			return err
+		}
+		if err := tx.Delete(&registration).Error; err != nil {
+			return err
+		}
+		return services.RefreshBillForRegistration(tx, registration)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -145,6 +207,8 @@ type RegistrationResponse struct {
     Credit         int       `json:"Credit"`
     StartAt        time.Time `json:"StartAt"`
     EndAt          time.Time `json:"EndAt"`
+    Status         string    `json:"Status"`
+    AdvisorComment string    `json:"AdvisorComment"`
 }
 
 func GetStudentBySubjectID(c *gin.Context) {
@@ -152,7 +216,9 @@ func GetStudentBySubjectID(c *gin.Context) {
 	var registrations []entity.Registration
 
 	db := config.DB()
+	// รายชื่อในรายวิชานับเฉพาะการลงทะเบียนที่อนุมัติแล้ว
 	result := db.
+		Scopes(services.ApprovedRegistrations).
 		Preload("Student").
 		Preload("Student.Major").
 		Preload("Student.Faculty").
@@ -1,51 +1,118 @@
 package reports
 
 import (
+    "errors"
     "net/http"
     "path/filepath"
     "regexp"
     "fmt"
+    "mime/multipart"
     "strconv"
     "strings"
     "time"
     "os"
 
     "github.com/gin-gonic/gin"
+    "github.com/google/uuid"
     "gorm.io/gorm"
 
     "reg_system/config"
     "reg_system/entity"
+    "reg_system/services"
 )
 
 // ---------- Helpers ----------
 func preloadReport(q *gorm.DB) *gorm.DB {
-    return q.Preload("ReportType").Preload("Reviewer.User").Preload("Attachments")
+    return q.Preload("ReportType.Steps", func(db *gorm.DB) *gorm.DB { return db.Order("seq") }).
+        Preload("Reviewer.User").Preload("Attachments").
+        Preload("ActionLogs", func(db *gorm.DB) *gorm.DB { return db.Order("seq") })
 }
 // บางสภาพแวดล้อมอาจยังไม่มีคอลัมน์ created_at จากฐานข้อมูลเก่า
 // เพื่อลดความเสี่ยง 500 ในการ Order ให้เรียงตาม submittion_date อย่างเดียว
 func orderReport(q *gorm.DB) *gorm.DB { return q.Order("submittion_date DESC") }
 
+// กรองคำร้องตามประเภทและคำตอบในแบบฟอร์ม: ?report_type_id=RT01&field.<key>=<value>
+func filterReport(q *gorm.DB, c *gin.Context) *gorm.DB {
+    if rt := strings.TrimSpace(c.Query("report_type_id")); rt != "" {
+        q = q.Where("reports.report_type_id = ?", rt)
+    }
+    for k, vs := range c.Request.URL.Query() {
+        key, ok := strings.CutPrefix(k, "field.")
+        if !ok || key == "" || len(vs) == 0 { continue }
+        q = q.Where("EXISTS (SELECT 1 FROM report_field_values v WHERE v.report_id = reports.report_id AND v.field_key = ? AND v.field_value = ?)", key, strings.TrimSpace(vs[0]))
+    }
+    return q
+}
+
+func writeWorkflowError(c *gin.Context, err error) {
+    switch {
+    case errors.Is(err, gorm.ErrRecordNotFound):
+        c.JSON(http.StatusNotFound, gin.H{"error": "report or report type not found"})
+    case errors.Is(err, services.ErrReportActor):
+        c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+    case errors.Is(err, services.ErrReportTransition):
+        c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+    case errors.Is(err, services.ErrReportForm):
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+    case errors.Is(err, services.ErrDocumentTooLarge):
+        c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+    case errors.Is(err, services.ErrDocumentMIME):
+        c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+    case errors.Is(err, services.ErrReportAttachments), errors.Is(err, services.ErrReportReviewer), errors.Is(err, services.ErrReportAction),
+        errors.Is(err, services.ErrReportWorkflow):
+        c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+    default:
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+    }
+}
+
 // ---------- Reports ----------
 
 // POST /reports (multipart form)
-// fields: student_id, report_type_id, reviewer_id, details, file(optional)
+// fields: student_id, report_type_id, reviewer_id, details, file(optional), attachment_kind(optional)
+//         form_data (JSON object ตาม FormSchema), field_<key> (ไฟล์ของช่องชนิด file)
+// ประเภทคำร้องที่มีขั้นการพิจารณาจะมอบหมายผู้พิจารณาขั้นแรกให้เอง (ไม่ต้องส่ง reviewer_id)
+// นักศึกษายื่นได้เฉพาะคำร้องของตัวเอง (student_id ใช้รหัสจาก token)
 func CreateReport(c *gin.Context) {
     studentID := strings.TrimSpace(c.PostForm("student_id"))
+    if claims := services.CurrentClaims(c); claims.Role == "student" {
+        studentID = claims.Username
+    }
     reportTypeID := strings.TrimSpace(c.PostForm("report_type_id"))
     reviewerID := strings.TrimSpace(c.PostForm("reviewer_id"))
     details := c.PostForm("details")
 
-    if studentID == "" || reportTypeID == "" || reviewerID == "" {
-        c.JSON(http.StatusBadRequest, gin.H{"error": "student_id, report_type_id, reviewer_id are required"})
+    if studentID == "" || reportTypeID == "" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "student_id, report_type_id are required"})
+        return
+    }
+
+    formValues, err := services.ParseReportFormData(c.PostForm("form_data"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
         return
     }
 
     // Reject multiple files in create endpoint
+    formFiles := map[string]*multipart.FileHeader{}
     if form, err := c.MultipartForm(); err == nil && form != nil {
-        if files := form.File["file"]; len(files) > 1 {
-            c.JSON(http.StatusBadRequest, gin.H{"error": "only one file allowed"})
+        for name, files := range form.File {
+            if len(files) > 1 {
+                c.JSON(http.StatusBadRequest, gin.H{"error": "only one file allowed per field"})
+                return
+            }
+            if key, ok := strings.CutPrefix(name, "field_"); ok {
+                formFiles[key] = files[0]
+            }
+        }
+    }
+    hasFile := map[string]bool{}
+    for key, file := range formFiles {
+        if err := services.CheckReportFormFile(key, file); err != nil {
+            writeWorkflowError(c, err)
             return
         }
+        hasFile[key] = true
     }
 
     var att *entity.Attachment
@@ -63,6 +130,7 @@ func CreateReport(c *gin.Context) {
             Attachment_id: "ATT-" + time.Now().Format("150405000000000"),
             File_Name:     file.Filename,
             File_Path:     "/" + savePath,
+            Kind:          strings.TrimSpace(c.PostForm("attachment_kind")),
             Uploaded_date: time.Now(),
         }
     }
@@ -91,21 +159,51 @@ func CreateReport(c *gin.Context) {
 
     db := config.DB()
     var rep entity.Report
+    var saved []string
     if err := db.Transaction(func(tx *gorm.DB) error {
+        rt, err := services.LoadReportType(tx, reportTypeID)
+        if err != nil { return err }
+        formData, err := services.ValidateReportForm(tx, rt, formValues, hasFile)
+        if err != nil { return err }
         nid, err := nextID(tx)
         if err != nil { return err }
+
+        // ไฟล์ของแบบฟอร์มเก็บเป็น Attachment (Kind = key ของช่อง) และเก็บรหัสไว้ในคำตอบ
+        var formAtts []entity.Attachment
+        for key, file := range formFiles {
+            name, err := services.SaveUpload(file)
+            if err != nil { return err }
+            saved = append(saved, name)
+            a := entity.Attachment{
+                Attachment_id: "ATT-" + uuid.New().String(),
+                File_Name:     file.Filename,
+                File_Path:     "/uploads/" + name,
+                Kind:          key,
+                Uploaded_date: now,
+                Report_id:     nid,
+            }
+            formData[key] = a.Attachment_id
+            formAtts = append(formAtts, a)
+        }
+
         rep = entity.Report{
             Report_id:       nid,
             Report_details:  details,
+            FormData:        formData,
             Submittion_date: now,
-            Status:          "รอดำเนินการ",
             StudentID:       studentID,
             Reviewer_id:     reviewerID,
             ReportType_id:   reportTypeID,
             Created_at:      now,
             Updated_at:      now,
         }
+        if err := services.StartReportWorkflow(tx, &rep, rt); err != nil { return err }
         if err := tx.Create(&rep).Error; err != nil { return err }
+        if err := services.SaveReportFieldValues(tx, rep.Report_id, formData); err != nil { return err }
+        if len(formAtts) > 0 {
+            if err := tx.Create(&formAtts).Error; err != nil { return err }
+        }
+        if err := services.LogReportSubmitted(tx, &rep, rt, services.CurrentClaims(c).Username); err != nil { return err }
         if att != nil {
             att.Report_id = rep.Report_id
             if att.Attachment_id == "" {
@@ -115,7 +213,8 @@ func CreateReport(c *gin.Context) {
         }
         return nil
     }); err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        services.RemoveUploads(saved)
+        writeWorkflowError(c, err)
         return
     }
 
@@ -127,7 +226,7 @@ func CreateReport(c *gin.Context) {
 }
 
 // POST /reports/:id/attachments
-// form field: file (single)
+// form field: file (single), kind (ชนิดเอกสารตาม RequiredAttachments ของประเภทคำร้อง)
 func AddReportAttachment(c *gin.Context) {
     reportID := strings.TrimSpace(c.Param("id"))
     if reportID == "" {
@@ -160,6 +259,7 @@ func AddReportAttachment(c *gin.Context) {
         Attachment_id: "ATT-" + time.Now().Format("150405000000000"),
         File_Name:     file.Filename,
         File_Path:     "/" + savePath,
+        Kind:          strings.TrimSpace(c.PostForm("kind")),
         Uploaded_date: time.Now(),
         Report_id:     reportID,
     }
@@ -187,13 +287,13 @@ func DeleteReportAttachment(c *gin.Context) {
     c.JSON(http.StatusOK, gin.H{"ok": true})
 }
 
-// GET /reports
+// GET /reports?role=&username=&report_type_id=&field.<key>=
 func GetReports(c *gin.Context) {
     db := config.DB()
     role := strings.ToLower(strings.TrimSpace(c.Query("role")))
     username := strings.ToLower(strings.TrimSpace(c.Query("username")))
     var items []entity.Report
-    q := preloadReport(orderReport(db))
+    q := filterReport(preloadReport(orderReport(db)), c)
     if role == "admin" || role == "teacher" {
         q = q.Joins("JOIN reviewers r ON r.reviewer_id = reports.reviewer_id").
             Joins("JOIN users u ON u.id = r.user_id").
@@ -222,22 +322,56 @@ func GetReport(c *gin.Context) {
     c.JSON(http.StatusOK, it)
 }
 
-// PUT /reports/:id/status  { status }
+// PUT /reports/:id/status  { status, comment }
+// เปลี่ยนสถานะตาม workflow ของประเภทคำร้อง อนุมัติขั้นที่ยังไม่สุดท้ายจะส่งต่อผู้พิจารณาขั้นถัดไป
+// อนุมัติขั้นสุดท้ายจะทำ Actions ของประเภทคำร้อง ถ้าทำไม่สำเร็จจะไม่อนุมัติ (422)
 func UpdateReportStatus(c *gin.Context) {
     id := c.Param("id")
-    var body struct{ Status string `json:"status"` }
+    var body struct {
+        Status  string `json:"status"`
+        Comment string `json:"comment"`
+    }
     if err := c.ShouldBindJSON(&body); err != nil || strings.TrimSpace(body.Status) == "" {
         c.JSON(http.StatusBadRequest, gin.H{"error": "status is required"})
         return
     }
     db := config.DB()
-    if err := db.Model(&entity.Report{}).
-        Where("report_id = ?", id).
-        Updates(map[string]any{"status": body.Status, "updated_at": time.Now()}).Error; err != nil {
+    var rep entity.Report
+    if err := db.Where("report_id = ?", id).First(&rep).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "report not found"})
+        return
+    }
+    claims := services.CurrentClaims(c)
+    actor := services.ReportActor{Username: claims.Username, Role: claims.Role}
+    if err := db.Transaction(func(tx *gorm.DB) error {
+        return services.ChangeReportStatus(tx, &rep, strings.TrimSpace(body.Status), body.Comment, actor)
+    }); err != nil {
+        writeWorkflowError(c, err)
+        return
+    }
+    _ = preloadReport(db).Where("report_id = ?", id).First(&rep).Error
+    c.JSON(http.StatusOK, rep)
+}
+
+// GET /reports/:id/history - ประวัติการพิจารณาแต่ละขั้น
+func GetReportHistory(c *gin.Context) {
+    id := c.Param("id")
+    db := config.DB()
+    var rep entity.Report
+    if err := db.Select("report_id", "student_id").Where("report_id = ?", id).First(&rep).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "report not found"})
+        return
+    }
+    if claims := services.CurrentClaims(c); claims.Role == "student" && claims.Username != rep.StudentID {
+        c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+        return
+    }
+    var logs []entity.ReportStatusLog
+    if err := db.Where("report_id = ?", id).Order("id").Find(&logs).Error; err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         return
     }
-    c.JSON(http.StatusOK, gin.H{"ok": true})
+    c.JSON(http.StatusOK, logs)
 }
 
 // DELETE /reports/:id
@@ -417,12 +551,12 @@ func GetReviewerIDByUsername(c *gin.Context) {
 
 // ---------- Queries by owner ----------
 
-// GET /reviewers/:rid/reports
+// GET /reviewers/:rid/reports?report_type_id=&field.<key>=
 func GetReportsByReviewer(c *gin.Context) {
     rid := c.Param("rid")
     db := config.DB()
     var items []entity.Report
-    if err := preloadReport(orderReport(db)).
+    if err := filterReport(preloadReport(orderReport(db)), c).
         Where("reviewer_id = ?", rid).
         Find(&items).Error; err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -1,6 +1,7 @@
 package reporttypes
 
 import (
+    "errors"
     "fmt"
     "net/http"
     "regexp"
@@ -12,13 +13,18 @@ import (
 
     "reg_system/config"
     "reg_system/entity"
+    "reg_system/services"
 )
 
+func preloadSteps(q *gorm.DB) *gorm.DB {
+    return q.Preload("Steps", func(db *gorm.DB) *gorm.DB { return db.Order("seq") })
+}
+
 // GET /report-types
 func ListReportTypes(c *gin.Context) {
     db := config.DB()
     var items []entity.ReportType
-    if err := db.Order("report_type_id ASC").Find(&items).Error; err != nil {
+    if err := preloadSteps(db).Order("report_type_id ASC").Find(&items).Error; err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         return
     }
@@ -34,13 +40,102 @@ func GetReportTypeByID(c *gin.Context) {
     }
     db := config.DB()
     var it entity.ReportType
-    if err := db.Where("report_type_id = ?", id).First(&it).Error; err != nil {
+    if err := preloadSteps(db).Where("report_type_id = ?", id).First(&it).Error; err != nil {
         c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
         return
     }
     c.JSON(http.StatusOK, it)
 }
 
+// PUT /report-types/:id/workflow
+// body: { Steps: [{ Name, Role: advisor|department_head|registrar, Reviewer_id }],
+//         Transitions: [{ From, To, By: reviewer|student }] (ว่าง = ค่าเริ่มต้น),
+//         RequiredAttachments: ["..."] }
+func UpdateReportTypeWorkflow(c *gin.Context) {
+    var body struct {
+        Steps               []entity.ReportWorkflowStep `json:"Steps"`
+        Transitions         []entity.ReportTransition   `json:"Transitions"`
+        RequiredAttachments []string                    `json:"RequiredAttachments"`
+    }
+    if err := c.ShouldBindJSON(&body); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+        return
+    }
+    db := config.DB()
+    var rt entity.ReportType
+    if err := db.Where("report_type_id = ?", strings.TrimSpace(c.Param("id"))).First(&rt).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "report type not found"})
+        return
+    }
+    if err := db.Transaction(func(tx *gorm.DB) error {
+        return services.SetReportWorkflow(tx, &rt, body.Steps, body.Transitions, body.RequiredAttachments)
+    }); err != nil {
+        if errors.Is(err, services.ErrReportWorkflow) {
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+    c.JSON(http.StatusOK, rt)
+}
+
+// PUT /report-types/:id/form
+// body: { FormSchema: [{ Key, Label, Type: text|date|subject|semester|number|file, Required, Min, Max }] }
+func UpdateReportTypeForm(c *gin.Context) {
+    var body struct {
+        FormSchema []entity.ReportFormField `json:"FormSchema"`
+    }
+    if err := c.ShouldBindJSON(&body); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+        return
+    }
+    db := config.DB()
+    var rt entity.ReportType
+    if err := preloadSteps(db).Where("report_type_id = ?", strings.TrimSpace(c.Param("id"))).First(&rt).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "report type not found"})
+        return
+    }
+    if err := services.SetReportFormSchema(db, &rt, body.FormSchema); err != nil {
+        if errors.Is(err, services.ErrReportFormSchema) {
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+    c.JSON(http.StatusOK, rt)
+}
+
+// PUT /report-types/:id/actions
+// body: { Actions: [{ Type: change_status|add_registration|drop_registration|waive_bill_item|extend_incomplete,
+//                    Status, SubjectField, SemesterField, DateField, ItemType, Days }] }
+// *Field อ้างอิง key ใน FormSchema ของประเภทคำร้อง
+func UpdateReportTypeActions(c *gin.Context) {
+    var body struct {
+        Actions []entity.ReportAction `json:"Actions"`
+    }
+    if err := c.ShouldBindJSON(&body); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+        return
+    }
+    db := config.DB()
+    var rt entity.ReportType
+    if err := preloadSteps(db).Where("report_type_id = ?", strings.TrimSpace(c.Param("id"))).First(&rt).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "report type not found"})
+        return
+    }
+    if err := services.SetReportActions(db, &rt, body.Actions); err != nil {
+        if errors.Is(err, services.ErrReportActions) {
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+    c.JSON(http.StatusOK, rt)
+}
+
 type reportTypePayload struct {
     ReportType_id         string `json:"ReportType_id"`
     ReportType_Name       string `json:"ReportType_Name"`
@@ -156,6 +251,7 @@ func DeleteReportType(c *gin.Context) {
             // ลบตัวรายงาน
             if err := tx.Where("report_type_id = ?", id).Delete(&entity.Report{}).Error; err != nil { return err }
         }
+        if err := tx.Where("report_type_id = ?", id).Delete(&entity.ReportWorkflowStep{}).Error; err != nil { return err }
         // ลบตัวประเภทคำร้อง
         if err := tx.Where("report_type_id = ?", id).Delete(&entity.ReportType{}).Error; err != nil { return err }
         return nil
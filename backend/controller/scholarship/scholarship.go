@@ -0,0 +1,332 @@
+package scholarship
+
+import (
+	"errors"
+	"net/http"
+
+	"reg_system/config"
+	"reg_system/entity"
+	"reg_system/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ScholarshipReq struct {
+	Name            string  `json:"Name"  binding:"required"`
+	Type            string  `json:"Type"  binding:"required"`
+	Value           int     `json:"Value" binding:"min=0"`
+	Cap             int     `json:"Cap"   binding:"min=0"`
+	MinGPAX         float64 `json:"MinGPAX"`
+	FacultyID       *string `json:"FacultyID"`
+	StatusStudentID *string `json:"StatusStudentID"`
+	StartYear       int     `json:"StartYear"`
+	StartTerm       int     `json:"StartTerm"`
+	EndYear         int     `json:"EndYear"`
+	EndTerm         int     `json:"EndTerm"`
+}
+
+// ตรวจชนิดทุน ค่าเปอร์เซ็นต์ และช่วงเทอม
+func validateScholarship(db *gorm.DB, req ScholarshipReq) error {
+	switch req.Type {
+	case entity.ScholarshipPercent:
+		if req.Value < 1 || req.Value > 100 {
+			return errors.New("percent value must be between 1 and 100")
+		}
+	case entity.ScholarshipFixed:
+		if req.Value <= 0 {
+			return errors.New("fixed value must be greater than 0")
+		}
+	default:
+		return errors.New("type must be percent or fixed")
+	}
+	if req.MinGPAX < 0 || req.MinGPAX > 4 {
+		return errors.New("MinGPAX must be between 0 and 4")
+	}
+	if req.EndYear != 0 && req.EndYear*10+req.EndTerm < req.StartYear*10+req.StartTerm {
+		return errors.New("end term must be after start term")
+	}
+	if req.FacultyID != nil {
+		if err := db.First(&entity.Faculty{}, "faculty_id = ?", *req.FacultyID).Error; err != nil {
+			return errors.New("invalid FacultyID")
+		}
+	}
+	if req.StatusStudentID != nil {
+		if err := db.First(&entity.StatusStudent{}, "status_student_id = ?", *req.StatusStudentID).Error; err != nil {
+			return errors.New("invalid StatusStudentID")
+		}
+	}
+	return nil
+}
+
+func applyScholarshipReq(s *entity.Scholarship, req ScholarshipReq) {
+	s.Name = req.Name
+	s.Type = req.Type
+	s.Value = req.Value
+	s.Cap = req.Cap
+	s.MinGPAX = req.MinGPAX
+	s.FacultyID = req.FacultyID
+	s.StatusStudentID = req.StatusStudentID
+	s.StartYear = req.StartYear
+	s.StartTerm = req.StartTerm
+	s.EndYear = req.EndYear
+	s.EndTerm = req.EndTerm
+}
+
+// GET /scholarships
+func GetScholarshipAll(c *gin.Context) {
+	var scholarships []entity.Scholarship
+	db := config.DB()
+	if err := db.Preload("Faculty").Preload("StatusStudent").Find(&scholarships).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, scholarships)
+}
+
+// GET /scholarships/:id (รวมรายชื่อผู้ได้รับทุน)
+func GetScholarshipByID(c *gin.Context) {
+	id := c.Param("id")
+	db := config.DB()
+
+	var s entity.Scholarship
+	if err := db.Preload("Faculty").Preload("StatusStudent").First(&s, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "scholarship not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		}
+		return
+	}
+
+	var recipients []entity.StudentScholarship
+	if err := db.Preload("Student").Where("scholarship_id = ?", s.ID).Find(&recipients).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"scholarship": s, "students": recipients})
+}
+
+// POST /scholarships
+func CreateScholarship(c *gin.Context) {
+	var req ScholarshipReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := config.DB()
+	if err := validateScholarship(db, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var s entity.Scholarship
+	applyScholarshipReq(&s, req)
+	if err := db.Create(&s).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, s)
+}
+
+// PUT /scholarships/:id
+func UpdateScholarship(c *gin.Context) {
+	id := c.Param("id")
+	var req ScholarshipReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := config.DB()
+	if err := validateScholarship(db, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var s entity.Scholarship
+	if err := db.First(&s, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "scholarship not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		}
+		return
+	}
+	applyScholarshipReq(&s, req)
+	if err := db.Save(&s).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, s)
+}
+
+// DELETE /scholarships/:id (soft delete เพื่อให้รายการส่วนลดในบิลเดิมยังอ้างอิงได้)
+func DeleteScholarship(c *gin.Context) {
+	id := c.Param("id")
+	db := config.DB()
+
+	result := db.Delete(&entity.Scholarship{}, id)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "scholarship not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Delete scholarship success"})
+}
+
+// POST /scholarships/:id/students
+// body: { StudentID, Note } มอบทุนให้นักศึกษา (ตรวจคุณสมบัติก่อน)
+func AssignScholarship(c *gin.Context) {
+	id := c.Param("id")
+	var payload struct {
+		StudentID string `json:"StudentID" binding:"required"`
+		Note      string `json:"Note"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := config.DB()
+
+	var s entity.Scholarship
+	if err := db.First(&s, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "scholarship not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		}
+		return
+	}
+
+	var student entity.Students
+	if err := db.First(&student, "student_id = ?", payload.StudentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "student not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		}
+		return
+	}
+
+	reason, err := services.ScholarshipEligibility(db, s, student)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if reason != "" {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "student is not eligible: " + reason})
+		return
+	}
+
+	var award entity.StudentScholarship
+	err = db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("student_id = ? AND scholarship_id = ?", student.StudentID, s.ID).First(&award).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		award.StudentID = student.StudentID
+		award.ScholarshipID = s.ID
+		award.Status = entity.StudentScholarshipActive
+		award.Note = payload.Note
+		if err := tx.Save(&award).Error; err != nil {
+			return err
+		}
+		return refreshStudentBills(tx, student.StudentID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, award)
+}
+
+// DELETE /scholarships/:id/students/:sid ยกเลิกทุน (เก็บประวัติไว้เป็นสถานะ revoked)
+func RevokeScholarship(c *gin.Context) {
+	id := c.Param("id")
+	sid := c.Param("sid")
+	db := config.DB()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&entity.StudentScholarship{}).
+			Where("scholarship_id = ? AND student_id = ?", id, sid).
+			Update("status", entity.StudentScholarshipRevoked)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return refreshStudentBills(tx, sid)
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "scholarship assignment not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Revoke scholarship success"})
+}
+
+// POST /scholarships/renewals
+// body: { AcademicYear, Term } ตรวจต่อทุนตามเกรดเฉลี่ยสะสมประจำเทอม
+func RenewScholarships(c *gin.Context) {
+	var payload struct {
+		AcademicYear int `json:"AcademicYear" binding:"required"`
+		Term         int `json:"Term"         binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var results []services.RenewalResult
+	err := config.DB().Transaction(func(tx *gorm.DB) error {
+		var err error
+		results, err = services.RenewScholarships(tx, payload.AcademicYear, payload.Term)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, results)
+}
+
+// GET /students/:id/scholarships
+func GetScholarshipsByStudentID(c *gin.Context) {
+	sid := c.Param("id")
+	claims := services.CurrentClaims(c)
+	if claims.Role != "admin" && claims.Username != sid {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+
+	var awards []entity.StudentScholarship
+	if err := config.DB().Preload("Scholarship").Where("student_id = ?", sid).Find(&awards).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, awards)
+}
+
+// คำนวณบิลที่ยังค้างชำระของนักศึกษาใหม่ ให้ส่วนลดตรงกับสถานะทุนปัจจุบัน
+func refreshStudentBills(tx *gorm.DB, studentID string) error {
+	var bills []entity.Bill
+	if err := tx.Where("student_id = ? AND status_id = ? AND academic_year > 0", studentID, 1).Find(&bills).Error; err != nil {
+		return err
+	}
+	for _, b := range bills {
+		if _, err := services.RefreshBill(tx, studentID, b.AcademicYear, b.Term); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -4,8 +4,10 @@ import (
 	"net/http"
 	"reg_system/config"
 	"reg_system/entity"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 func GetSemesterAll (c *gin.Context){
@@ -18,4 +20,70 @@ func GetSemesterAll (c *gin.Context){
 	}
 	
 	c.JSON(http.StatusOK, semesters)
-}
\ No newline at end of file
+}
+// PUT /semesters/:id - กำหนดวันเปิดภาคเรียน (ใช้กับนโยบายคืนเงินเมื่อถอนรายวิชา)
+func UpdateSemester(c *gin.Context) {
+	var req struct {
+		StartDate *time.Time `json:"StartDate"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := config.DB()
+	var semester entity.Semester
+	if err := db.First(&semester, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "semester not found"})
+		return
+	}
+	if err := db.Model(&semester).Update("start_date", req.StartDate).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	semester.StartDate = req.StartDate
+	c.JSON(http.StatusOK, semester)
+}
+
+// GET /semesters/refund-policies - นโยบายคืนเงินเมื่อถอนรายวิชา
+func GetRefundPolicies(c *gin.Context) {
+	var policies []entity.RefundPolicy
+	if err := config.DB().Order("from_week ASC").Find(&policies).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, policies)
+}
+
+// PUT /semesters/refund-policies - แทนที่นโยบายคืนเงินทั้งชุด
+// body: [{ FromWeek, ToWeek (0 = ไม่มีกำหนดสิ้นสุด), Percent, Note }]
+func ReplaceRefundPolicies(c *gin.Context) {
+	var policies []entity.RefundPolicy
+	if err := c.ShouldBindJSON(&policies); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	for i := range policies {
+		p := &policies[i]
+		if p.FromWeek < 1 || (p.ToWeek != 0 && p.ToWeek < p.FromWeek) || p.Percent < 0 || p.Percent > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid policy: weeks start at 1 and percent is 0-100"})
+			return
+		}
+		p.ID = 0
+	}
+
+	err := config.DB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&entity.RefundPolicy{}).Error; err != nil {
+			return err
+		}
+		if len(policies) == 0 {
+			return nil
+		}
+		return tx.Create(&policies).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, policies)
+}
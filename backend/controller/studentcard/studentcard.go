@@ -0,0 +1,129 @@
+package studentcard
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"reg_system/config"
+	"reg_system/entity"
+	"reg_system/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GET /students/:id/card?format=pdf|png|json - บัตรนักศึกษาพร้อม QR ที่ลงนามแล้ว
+// ออกให้เฉพาะนักศึกษาที่กำลังศึกษา (สถานภาพ 10)
+func GetStudentCard(c *gin.Context) {
+	sid := c.Param("id")
+	if claims := services.CurrentClaims(c); claims.Role != "admin" && claims.Username != sid {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+	format := c.DefaultQuery("format", "pdf")
+	if format != "pdf" && format != "png" && format != "json" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be pdf, png or json"})
+		return
+	}
+
+	card, err := services.IssueStudentCard(config.DB(), sid)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Student not found"})
+		case errors.Is(err, services.ErrCardInactive):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, config.ErrStudentCardKey):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "student cards are not available: signing key is not configured"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	switch format {
+	case "json":
+		c.JSON(http.StatusOK, card)
+	case "png":
+		img, err := services.RenderStudentCardPNG(card)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to render student card"})
+			return
+		}
+		c.Data(http.StatusOK, "image/png", img)
+	default:
+		c.Header("Content-Type", "application/pdf")
+		c.Header("Content-Disposition", fmt.Sprintf("inline; filename=card_%s.pdf", sid))
+		if err := services.RenderStudentCardPDF(c.Writer, card); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to render student card"})
+		}
+	}
+}
+
+// GET /student-cards/public-key - กุญแจสาธารณะ Ed25519 สำหรับตรวจ QR แบบออฟไลน์ (ไม่ต้องเข้าสู่ระบบ)
+func GetPublicKey(c *gin.Context) {
+	pub, err := config.StudentCardPublicKey()
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "signing key is not configured"})
+		return
+	}
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"Algorithm": "Ed25519",
+		"KeyID":     config.StudentCardKeyID(pub),
+		"PublicKey": base64.StdEncoding.EncodeToString(pub),
+		"PEM":       string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+	})
+}
+
+// GET /student-cards/verify?token= - ตรวจ QR บนบัตร (ไม่ต้องเข้าสู่ระบบ)
+// ตรวจลายเซ็นและวันหมดอายุเหมือนเครื่องตรวจออฟไลน์ แล้วแจ้งสถานภาพปัจจุบันในระบบประกอบด้วย
+func VerifyStudentCard(c *gin.Context) {
+	token := c.Query("token")
+	// รองรับกรณีส่งข้อความทั้งหมดที่สแกนได้จาก QR (URL หน้าตรวจ + token)
+	if i := strings.LastIndex(token, "token="); i >= 0 {
+		token = token[i+len("token="):]
+	}
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "token is required"})
+		return
+	}
+
+	claims, err := services.VerifyStudentCardToken(token, time.Now())
+	if errors.Is(err, config.ErrStudentCardKey) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "signing key is not configured"})
+		return
+	}
+	if err != nil && !errors.Is(err, services.ErrCardExpired) {
+		c.JSON(http.StatusBadRequest, gin.H{"Valid": false, "error": err.Error()})
+		return
+	}
+
+	resp := gin.H{
+		"Valid":     err == nil,
+		"Active":    err == nil && claims.Status == entity.StatusStudying,
+		"Claims":    claims,
+		"ExpiresAt": time.Unix(claims.ExpiresAt, 0),
+	}
+	if err != nil {
+		resp["error"] = err.Error()
+	}
+
+	var student entity.Students
+	if config.DB().Select("student_id", "status_student_id").
+		First(&student, "student_id = ?", claims.StudentID).Error == nil {
+		resp["CurrentStatus"] = student.StatusStudentID
+		resp["Active"] = resp["Active"].(bool) && student.StatusStudentID == entity.StatusStudying
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,227 @@
+package studentdocument
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"reg_system/config"
+	"reg_system/entity"
+	"reg_system/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// นักศึกษาเข้าถึงได้เฉพาะเอกสารของตนเอง
+func allowStudent(c *gin.Context) (string, bool) {
+	sid := c.Param("id")
+	if claims := services.CurrentClaims(c); claims.Role != "admin" && claims.Username != sid {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return "", false
+	}
+	return sid, true
+}
+
+func findDocument(c *gin.Context, db *gorm.DB, sid string) (*entity.StudentDocument, bool) {
+	var doc entity.StudentDocument
+	if err := db.Where("id = ? AND student_id = ?", c.Param("did"), sid).First(&doc).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "document not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		}
+		return nil, false
+	}
+	return &doc, true
+}
+
+// POST /students/:id/documents - อัปโหลดเอกสาร (multipart: type, file)
+// type: photo | id_card | house_registration | previous_transcript
+func UploadDocument(c *gin.Context) {
+	sid, ok := allowStudent(c)
+	if !ok {
+		return
+	}
+	db := config.DB()
+	if err := db.Select("student_id").First(&entity.Students{}, "student_id = ?", sid).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "student not found"})
+		return
+	}
+
+	docType := c.PostForm("type")
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file not found"})
+		return
+	}
+	mime, err := services.CheckDocumentUpload(docType, file)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrDocumentTooLarge), errors.Is(err, services.ErrImageDimensions):
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrDocumentMIME):
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrDocumentType):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	fileName, err := services.SaveUpload(file)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	doc := entity.StudentDocument{
+		StudentID:    sid,
+		Type:         docType,
+		FileName:     fileName,
+		OriginalName: filepath.Base(file.Filename),
+		ContentType:  mime,
+		Size:         file.Size,
+		UploadedBy:   services.CurrentClaims(c).Username,
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		return services.SubmitStudentDocument(tx, &doc)
+	})
+	if err != nil {
+		os.Remove(filepath.Join(services.UploadDir, fileName))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, doc)
+}
+
+// GET /students/:id/documents?type=&status= - เอกสารทุกเวอร์ชันของนักศึกษา (ล่าสุดก่อน)
+func GetDocumentsByStudentID(c *gin.Context) {
+	sid, ok := allowStudent(c)
+	if !ok {
+		return
+	}
+	q := config.DB().Where("student_id = ?", sid)
+	if t := c.Query("type"); t != "" {
+		q = q.Where("type = ?", t)
+	}
+	if status := c.Query("status"); status != "" {
+		q = q.Where("status = ?", status)
+	}
+
+	var docs []entity.StudentDocument
+	if err := q.Order("type, version DESC").Find(&docs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, docs)
+}
+
+// GET /student-documents/?status=&type= - รายการเอกสารสำหรับเจ้าหน้าที่ตรวจ (ค่าเริ่มต้น: รอตรวจ)
+func GetDocumentAll(c *gin.Context) {
+	q := config.DB().Where("status = ?", c.DefaultQuery("status", entity.DocumentPending))
+	if t := c.Query("type"); t != "" {
+		q = q.Where("type = ?", t)
+	}
+
+	var docs []entity.StudentDocument
+	if err := q.Order("uploaded_at").Find(&docs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, docs)
+}
+
+// GET /students/:id/documents/:did - ดาวน์โหลดเอกสาร
+func GetDocumentFile(c *gin.Context) {
+	sid, ok := allowStudent(c)
+	if !ok {
+		return
+	}
+	doc, ok := findDocument(c, config.DB(), sid)
+	if !ok {
+		return
+	}
+	if doc.FileName == "" {
+		c.JSON(http.StatusGone, gin.H{"error": "file has been erased"})
+		return
+	}
+	filePath := services.DocumentFilePath(doc)
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "file not found"})
+		return
+	}
+	c.FileAttachment(filePath, doc.OriginalName)
+}
+
+// GET /students/:id/documents/:did/thumbnail?size= - รูปย่อของรูปถ่าย (JPEG)
+func GetDocumentThumbnail(c *gin.Context) {
+	sid, ok := allowStudent(c)
+	if !ok {
+		return
+	}
+	doc, ok := findDocument(c, config.DB(), sid)
+	if !ok {
+		return
+	}
+	size, _ := strconv.Atoi(c.DefaultQuery("size", strconv.Itoa(services.DefaultThumbnailSize)))
+
+	thumb, err := services.PhotoThumbnail(doc, size)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrDocumentNotPhoto):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrImageDimensions):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		case os.IsNotExist(err):
+			c.JSON(http.StatusNotFound, gin.H{"error": "file not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.Header("Cache-Control", "private, max-age=3600")
+	c.Data(http.StatusOK, "image/jpeg", thumb)
+}
+
+// PUT /students/:id/documents/:did - เจ้าหน้าที่ตรวจเอกสาร
+// body: { Status: "verified" | "rejected", Reason }
+func VerifyDocument(c *gin.Context) {
+	var req struct {
+		Status string `json:"Status" binding:"required"`
+		Reason string `json:"Reason"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	switch {
+	case req.Status != entity.DocumentVerified && req.Status != entity.DocumentRejected:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be verified or rejected"})
+		return
+	case req.Status == entity.DocumentRejected && req.Reason == "":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reason is required when rejecting a document"})
+		return
+	}
+
+	db := config.DB()
+	doc, ok := findDocument(c, db, c.Param("id"))
+	if !ok {
+		return
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return services.VerifyStudentDocument(tx, doc, req.Status == entity.DocumentVerified, req.Reason, services.CurrentClaims(c).Username)
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrDocumentState) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, doc)
+}
@@ -0,0 +1,168 @@
+package students
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"reg_system/config"
+	"reg_system/entity"
+	"reg_system/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// หาคำขอแก้ไขข้อมูลตาม :crid นักศึกษาเห็นเฉพาะคำขอของตนเอง
+// เขียน error response ให้เองและคืนค่า false ถ้าไม่พบหรือไม่มีสิทธิ์
+func findChangeRequestForUser(c *gin.Context, db *gorm.DB) (*entity.ProfileChangeRequest, bool) {
+	var cr entity.ProfileChangeRequest
+	if err := db.Preload("Attachments").First(&cr, c.Param("crid")).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "change request not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		}
+		return nil, false
+	}
+	if claims := services.CurrentClaims(c); claims.Role != "admin" && claims.Username != cr.StudentID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return nil, false
+	}
+	return &cr, true
+}
+
+// GET /students/:id/change-requests - ประวัติคำขอแก้ไขข้อมูลของนักศึกษา
+func GetChangeRequestsByStudentID(c *gin.Context) {
+	sid := c.Param("id")
+	if claims := services.CurrentClaims(c); claims.Role != "admin" && claims.Username != sid {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+
+	var requests []entity.ProfileChangeRequest
+	if err := config.DB().Preload("Attachments").Where("student_id = ?", sid).
+		Order("id DESC").Find(&requests).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, requests)
+}
+
+// GET /change-requests/?status= - คิวคำขอแก้ไขข้อมูล (ค่าเริ่มต้น: รออนุมัติ)
+func GetChangeRequestAll(c *gin.Context) {
+	q := config.DB().Preload("Attachments").Preload("Student")
+	if status := c.DefaultQuery("status", entity.ProfileChangePending); status != "all" {
+		q = q.Where("status = ?", status)
+	}
+
+	var requests []entity.ProfileChangeRequest
+	if err := q.Order("requested_at ASC").Find(&requests).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, requests)
+}
+
+// POST /change-requests/:crid/attachments - แนบเอกสารประกอบคำขอ (form-data: file)
+func UploadChangeAttachment(c *gin.Context) {
+	db := config.DB()
+	cr, ok := findChangeRequestForUser(c, db)
+	if !ok {
+		return
+	}
+	if cr.Status != entity.ProfileChangePending {
+		c.JSON(http.StatusConflict, gin.H{"error": "change request has already been reviewed"})
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file not found"})
+		return
+	}
+	fileName, err := services.SaveUpload(file)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	att := entity.ProfileChangeAttachment{
+		ChangeRequestID: cr.ID,
+		FileName:        fileName,
+		OriginalName:    filepath.Base(file.Filename),
+		UploadedAt:      time.Now(),
+	}
+	if err := db.Create(&att).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, att)
+}
+
+// GET /change-requests/:crid/attachments/:aid - ดาวน์โหลดเอกสารประกอบ
+func GetChangeAttachment(c *gin.Context) {
+	db := config.DB()
+	cr, ok := findChangeRequestForUser(c, db)
+	if !ok {
+		return
+	}
+
+	var att entity.ProfileChangeAttachment
+	if err := db.Where("id = ? AND change_request_id = ?", c.Param("aid"), cr.ID).First(&att).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "attachment not found"})
+		return
+	}
+	filePath := filepath.Join(services.UploadDir, filepath.Base(att.FileName))
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "file not found"})
+		return
+	}
+	c.FileAttachment(filePath, att.OriginalName)
+}
+
+// PUT /change-requests/:crid - เจ้าหน้าที่อนุมัติ/ไม่อนุมัติคำขอแก้ไขข้อมูล
+// body: { Status: "approved" | "rejected", Reason }
+func ReviewChangeRequest(c *gin.Context) {
+	var req struct {
+		Status string `json:"Status" binding:"required"`
+		Reason string `json:"Reason"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Status != entity.ProfileChangeApproved && req.Status != entity.ProfileChangeRejected {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be approved or rejected"})
+		return
+	}
+	if req.Status == entity.ProfileChangeRejected && req.Reason == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reason is required when rejecting a change request"})
+		return
+	}
+
+	db := config.DB()
+	cr, ok := findChangeRequestForUser(c, db)
+	if !ok {
+		return
+	}
+
+	reviewer := services.CurrentClaims(c).Username
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return services.ReviewProfileChange(tx, cr, req.Status == entity.ProfileChangeApproved, req.Reason, reviewer)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrProfileChangeReviewed):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrProfileInvalidValue):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, cr)
+}
@@ -3,6 +3,7 @@ package students
 import (
 	"errors"
 	"net/http"
+	"strconv"
 
 	"reg_system/config"
 	"reg_system/entity"
@@ -91,6 +92,13 @@ func GetStudentID(c *gin.Context) {
 	// คำนวณหน่วยกิตรวม
 	totalCredits, _ := services.CalculateTotalCredits(students.StudentID)
 
+	// การระงับสิทธิ์ที่ยังมีผล แสดงในหน้าโปรไฟล์
+	holds, err := services.ActiveHolds(db, students.StudentID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		return
+	}
+
 	// Step 3: สร้าง map สำหรับเก็บข้อมูลที่ต้องการส่งออก
 	//------------------------------------------------------------------
 	response := map[string]interface{}{
@@ -124,6 +132,8 @@ func GetStudentID(c *gin.Context) {
 		"Ethnicity":   students.Ethnicity,
 		"BirthDay":    students.BirthDay,
 		"Parent":      students.Parent,
+
+		"Holds": holds,
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -136,10 +146,45 @@ func CreateStudent(c *gin.Context) {
 		return
 	}
 
-	// สร้าง transaction เพื่อความปลอดภัย
+	if !services.ValidCitizenID(string(student.CitizenID)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrInvalidCitizenID.Error()})
+		return
+	}
+
 	db := config.DB()
+
+	// ไม่ได้กรอกรหัสนักศึกษา ให้ระบบออกรหัสตามรูปแบบ (ปีที่เข้าศึกษาจาก ?entry_year= หรือปีปัจจุบัน)
+	// ถ้ากรอกเอง ต้องตรงกับรูปแบบ
+	idPrefix := ""
+	if student.StudentID == "" {
+		entryYear := services.CurrentEntryYear()
+		if v, err := strconv.Atoi(c.Query("entry_year")); err == nil && v > 0 {
+			entryYear = v
+		}
+		prefix, err := services.StudentIDPrefix(db, student.DegreeID, student.FacultyID, entryYear)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		idPrefix = prefix
+	} else if err := services.ValidateStudentID(db, student.StudentID, student.DegreeID, student.FacultyID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// สร้าง transaction เพื่อความปลอดภัย
 	tx := db.Begin()
 
+	if idPrefix != "" {
+		id, err := services.AllocateStudentID(tx, idPrefix)
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		student.StudentID = id
+	}
+
 	// ตรวจสอบว่ามี username นี้ในระบบเเล้วหรือยัง เพื่อกัน username ซ้ำกัน
 	// โดยเช็คจากตาราง Users
 	existingUser := &entity.Users{}
@@ -166,8 +211,14 @@ func CreateStudent(c *gin.Context) {
 		return
 	}
 
-	// กำหนด Username: รหัสนักศึกษา , Password: เลขบัตรปชช
-	hashPassword, _ := config.HashPassword(student.CitizenID)
+	// กำหนด Username: รหัสนักศึกษา , Password: สุ่ม (แจ้งกลับในผลลัพธ์ครั้งเดียว)
+	password, err := services.GenerateInitialPassword()
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate password"})
+		return
+	}
+	hashPassword, _ := config.HashPassword(password)
 	user := &entity.Users{
 		Username: student.StudentID, // ดึงรหัสนักศึกษาออกมา
 		Password: hashPassword,
@@ -183,10 +234,11 @@ func CreateStudent(c *gin.Context) {
 	tx.Commit()
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":    "Create student success",
-		"Student_id": student.StudentID,
-		"FirstName":  student.FirstName,
-		"LastName":   student.LastName,
+		"message":         "Create student success",
+		"Student_id":      student.StudentID,
+		"FirstName":       student.FirstName,
+		"LastName":        student.LastName,
+		"InitialPassword": password,
 	})
 }
 
@@ -276,46 +328,70 @@ func GetStudentAll(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// PUT /students/:id - นักศึกษาแก้ไขข้อมูลตนเองตามนโยบายรายฟิลด์
+// ข้อมูลติดต่อบันทึกทันที ข้อมูลประจำตัว/การศึกษาสร้างคำขอให้เจ้าหน้าที่อนุมัติ
+// body: ฟิลด์ของ Students และ ChangeNote (เหตุผลประกอบคำขอ ไม่บังคับ)
 func UpdateStudent(c *gin.Context) {
 	sid := c.Param("id")
+	if claims := services.CurrentClaims(c); claims.Role != "admin" && claims.Username != sid {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
 
-	// สร้าง transaction
-	db := config.DB()
-	tx := db.Begin()
-
-	input := new(entity.Students)
-	if err := c.ShouldBind(&input); err != nil {
+	input := map[string]interface{}{}
+	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	note, _ := input["ChangeNote"].(string)
 
-	// หา Student ก่อนจะ update
-	student := &entity.Students{}
-	if err := tx.First(&student , "student_id = ?",sid).Error; err != nil {
-		tx.Rollback()
-		
-		if errors.Is(err , gorm.ErrRecordNotFound){
-			// หาไม่เจอ
-			c.JSON(http.StatusNotFound , gin.H{"error": "student not found"})
-		}else{
-			// database error
-			c.JSON(http.StatusInternalServerError , gin.H{"error": "database error"})
+	db := config.DB()
+	var upd *services.ProfileUpdate
+	var changeRequest *entity.ProfileChangeRequest
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		upd, err = services.ClassifyProfileUpdate(tx, sid, input)
+		if err != nil {
+			return err
+		}
+		if len(upd.Forbidden) > 0 {
+			return services.ErrProfileFieldForbidden
+		}
+		if len(upd.Direct) > 0 {
+			if err := tx.Model(&entity.Students{}).Where("student_id = ?", sid).Updates(upd.Direct).Error; err != nil {
+				return err
+			}
+		}
+		if len(upd.Changes) > 0 {
+			changeRequest, err = services.SubmitProfileChanges(tx, sid, upd.Changes, note)
+		}
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "student not found"})
+		case errors.Is(err, services.ErrProfileFieldForbidden):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "fields": upd.Forbidden})
+		case errors.Is(err, services.ErrProfileInvalidValue):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update student"})
 		}
 		return
 	}
 
-	// อัพเดทข้อมูลนักเรียน
-	err := tx.Model(&student).Updates(&input).Error
-	if err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError , gin.H{"error": "Failed to update student"})
+	student := entity.Students{}
+	if err := db.First(&student, "student_id = ?", sid).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
 		return
 	}
-	
-	// commit transaction
-	tx.Commit()
 
-	c.JSON(http.StatusOK, student)
+	c.JSON(http.StatusOK, gin.H{
+		"student":        student,
+		"applied":        upd.Applied,
+		"change_request": changeRequest,
+	})
 }
 
 func DeleteStudent(c *gin.Context) {
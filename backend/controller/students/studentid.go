@@ -0,0 +1,20 @@
+package students
+
+import (
+	"net/http"
+
+	"reg_system/config"
+	"reg_system/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GET /students/id-audit - รายชื่อนักศึกษาที่รหัสไม่ตรงกับรูปแบบรหัสปัจจุบัน
+func AuditStudentIDs(c *gin.Context) {
+	issues, err := services.AuditStudentIDs(config.DB())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"pattern": config.StudentIDPattern(), "issues": issues})
+}
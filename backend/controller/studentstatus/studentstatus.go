@@ -0,0 +1,268 @@
+package studentstatus
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"reg_system/config"
+	"reg_system/entity"
+	"reg_system/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// หาคำร้องตาม :id นักศึกษาเห็นเฉพาะคำร้องของตนเอง
+// เขียน error response ให้เองและคืนค่า false ถ้าไม่พบหรือไม่มีสิทธิ์
+func findStatusRequestForUser(c *gin.Context, db *gorm.DB) (*entity.StatusRequest, bool) {
+	var req entity.StatusRequest
+	if err := db.Preload("Documents").First(&req, c.Param("id")).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "status request not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
+		}
+		return nil, false
+	}
+	if claims := services.CurrentClaims(c); claims.Role != "admin" && claims.Username != req.StudentID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return nil, false
+	}
+	return &req, true
+}
+
+func writeStatusError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "student, status or semester not found"})
+	case errors.Is(err, services.ErrStatusRequestOpen), errors.Is(err, services.ErrStatusRequestState),
+		errors.Is(err, services.ErrStatusTransition):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrLeaveLimit), errors.Is(err, services.ErrStatusRequestType),
+		errors.Is(err, services.ErrStatusFutureTerm):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// POST /status-requests/ - ยื่นคำร้องลาพัก/กลับเข้าศึกษา/ลาออก/ขอคืนสภาพ
+// body: { Type, Reason, Terms, EffectiveSemesterID, StudentID (เฉพาะแอดมินยื่นแทน) }
+func CreateStatusRequest(c *gin.Context) {
+	var body struct {
+		StudentID           string `json:"StudentID"`
+		Type                string `json:"Type" binding:"required"`
+		Reason              string `json:"Reason" binding:"required"`
+		Terms               int    `json:"Terms"`
+		EffectiveSemesterID int    `json:"EffectiveSemesterID" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	claims := services.CurrentClaims(c)
+	if claims.Role != "admin" || body.StudentID == "" {
+		body.StudentID = claims.Username
+	}
+
+	req := entity.StatusRequest{
+		StudentID:           body.StudentID,
+		Type:                body.Type,
+		Reason:              body.Reason,
+		Terms:               body.Terms,
+		EffectiveSemesterID: body.EffectiveSemesterID,
+		RequestedBy:         claims.Username,
+	}
+	err := config.DB().Transaction(func(tx *gorm.DB) error {
+		return services.CreateStatusRequest(tx, &req)
+	})
+	if err != nil {
+		writeStatusError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, req)
+}
+
+// GET /status-requests/?status=&type= - คิวคำร้องเปลี่ยนสถานภาพ (ค่าเริ่มต้น: รออนุมัติ)
+func GetStatusRequestAll(c *gin.Context) {
+	q := config.DB().Preload("Documents").Preload("Student").Preload("EffectiveSemester")
+	if status := c.DefaultQuery("status", entity.StatusRequestPending); status != "all" {
+		q = q.Where("status = ?", status)
+	}
+	if t := c.Query("type"); t != "" {
+		q = q.Where("type = ?", t)
+	}
+
+	var requests []entity.StatusRequest
+	if err := q.Order("requested_at ASC").Find(&requests).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, requests)
+}
+
+// GET /students/:id/status-requests - คำร้องเปลี่ยนสถานภาพของนักศึกษา
+func GetStatusRequestsByStudentID(c *gin.Context) {
+	sid := c.Param("id")
+	if claims := services.CurrentClaims(c); claims.Role != "admin" && claims.Username != sid {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+
+	var requests []entity.StatusRequest
+	if err := config.DB().Preload("Documents").Preload("EffectiveSemester").
+		Where("student_id = ?", sid).Order("id DESC").Find(&requests).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, requests)
+}
+
+// POST /status-requests/:id/documents - แนบเอกสารประกอบคำร้อง (form-data: file)
+func UploadStatusDocument(c *gin.Context) {
+	db := config.DB()
+	req, ok := findStatusRequestForUser(c, db)
+	if !ok {
+		return
+	}
+	if req.Status != entity.StatusRequestPending {
+		c.JSON(http.StatusConflict, gin.H{"error": services.ErrStatusRequestState.Error()})
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file not found"})
+		return
+	}
+	fileName, err := services.SaveUpload(file)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	doc := entity.StatusRequestDocument{
+		StatusRequestID: req.ID,
+		FileName:        fileName,
+		OriginalName:    filepath.Base(file.Filename),
+		UploadedAt:      time.Now(),
+	}
+	if err := db.Create(&doc).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, doc)
+}
+
+// GET /status-requests/:id/documents/:did - ดาวน์โหลดเอกสารประกอบคำร้อง
+func GetStatusDocument(c *gin.Context) {
+	db := config.DB()
+	req, ok := findStatusRequestForUser(c, db)
+	if !ok {
+		return
+	}
+
+	var doc entity.StatusRequestDocument
+	if err := db.Where("id = ? AND status_request_id = ?", c.Param("did"), req.ID).First(&doc).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "document not found"})
+		return
+	}
+	filePath := filepath.Join(services.UploadDir, filepath.Base(doc.FileName))
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "file not found"})
+		return
+	}
+	c.FileAttachment(filePath, doc.OriginalName)
+}
+
+// PUT /status-requests/:id - เจ้าหน้าที่อนุมัติ/ไม่อนุมัติคำร้อง
+// body: { Status: "approved" | "rejected", Reason }
+func ReviewStatusRequest(c *gin.Context) {
+	var body struct {
+		Status string `json:"Status" binding:"required"`
+		Reason string `json:"Reason"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if body.Status != entity.StatusRequestApproved && body.Status != entity.StatusRequestRejected {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be approved or rejected"})
+		return
+	}
+	if body.Status == entity.StatusRequestRejected && body.Reason == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reason is required when rejecting a status request"})
+		return
+	}
+
+	db := config.DB()
+	req, ok := findStatusRequestForUser(c, db)
+	if !ok {
+		return
+	}
+
+	reviewer := services.CurrentClaims(c).Username
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return services.ReviewStatusRequest(tx, req, body.Status == entity.StatusRequestApproved, body.Reason, reviewer)
+	})
+	if err != nil {
+		writeStatusError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, req)
+}
+
+// PUT /students/:id/status - เจ้าหน้าที่เปลี่ยนสถานภาพโดยตรง (เช่น พักการศึกษาเพราะวินัย, สิ้นสภาพ)
+// body: { StatusStudentID, Reason, EffectiveSemesterID }
+func ChangeStudentStatus(c *gin.Context) {
+	var body struct {
+		StatusStudentID     string `json:"StatusStudentID" binding:"required"`
+		Reason              string `json:"Reason" binding:"required"`
+		EffectiveSemesterID *int   `json:"EffectiveSemesterID"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := config.DB()
+	sid := c.Param("id")
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return services.ChangeStudentStatus(tx, sid, body.StatusStudentID, services.StatusChange{
+			EffectiveSemesterID: body.EffectiveSemesterID,
+			Reason:              body.Reason,
+			ApprovedBy:          services.CurrentClaims(c).Username,
+		})
+	})
+	if err != nil {
+		writeStatusError(c, err)
+		return
+	}
+
+	var student entity.Students
+	if err := db.Preload("StatusStudent").First(&student, "student_id = ?", sid).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, student)
+}
+
+// GET /students/:id/status-history - ประวัติการเปลี่ยนสถานภาพ
+func GetStatusHistory(c *gin.Context) {
+	sid := c.Param("id")
+	if claims := services.CurrentClaims(c); claims.Role != "admin" && claims.Username != sid {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+
+	var logs []entity.StudentStatusLog
+	if err := config.DB().Preload("EffectiveSemester").
+		Where("student_id = ?", sid).Order("id ASC").Find(&logs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, logs)
+}
@@ -2,13 +2,10 @@ package teachers
 
 import (
     "errors"
-    "fmt"
     "net/http"
-    "regexp"
-    "strconv"
-    "strings"
     "reg_system/config"
     "reg_system/entity"
+    "reg_system/services"
 
     "github.com/gin-gonic/gin"
     "gorm.io/gorm"
@@ -103,6 +100,10 @@ func CreateTeacher(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if !services.ValidCitizenID(string(teacher.CitizenID)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrInvalidCitizenID.Error()})
+		return
+	}
 	// สร้าง transaction เพื่อความปลอดภัย
 	db := config.DB()
 	tx := db.Begin()
@@ -128,8 +129,14 @@ func CreateTeacher(c *gin.Context) {
 		return
 	}
 
-	// กำหนด Username: รหัสอาจารย์ , Password: เลขบัตรปชช
-	hashPassword, _ := config.HashPassword(teacher.CitizenID)
+	// กำหนด Username: รหัสอาจารย์ , Password: สุ่ม (แจ้งกลับในผลลัพธ์ครั้งเดียว)
+	password, err := services.GenerateInitialPassword()
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate password"})
+		return
+	}
+	hashPassword, _ := config.HashPassword(password)
 	user := &entity.Users{
 		Username: teacher.TeacherID, // ดึงรหัสนักศึกษาออกมา
 		Password: hashPassword,
@@ -141,36 +148,22 @@ func CreateTeacher(c *gin.Context) {
 		return
 	}
 
-    // ensure reviewer for this teacher's user account
-    // create a reviewer row if not exists (auto id like RV001, RV002, ...)
-    var existed entity.Reviewer
-    if err := tx.Where("user_id = ?", user.ID).First(&existed).Error; errors.Is(err, gorm.ErrRecordNotFound) {
-        var ids []string
-        _ = tx.Model(&entity.Reviewer{}).Select("reviewer_id").Find(&ids).Error
-        re := regexp.MustCompile(`(?i)^RV(\d+)$`)
-        maxN := 0
-        for _, s := range ids {
-            if m := re.FindStringSubmatch(strings.TrimSpace(s)); len(m) == 2 {
-                if n, err := strconv.Atoi(m[1]); err == nil && n > maxN { maxN = n }
-            }
-        }
-        next := fmt.Sprintf("RV%03d", maxN+1)
-        rev := entity.Reviewer{ Reviewer_id: next, UserID: user.ID }
-        if err := tx.Create(&rev).Error; err != nil {
-            tx.Rollback()
-            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-            return
-        }
-    }
+	// สร้าง Reviewer ให้บัญชีอาจารย์ (รหัส RV001, RV002, ...)
+	if err := services.EnsureReviewer(tx, user.ID); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
 	// commit transaction
 	tx.Commit()
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":    "Create teacher success",
-		"Teacher_id": teacher.TeacherID,
-		"FirstName":  teacher.FirstName,
-		"LastName":   teacher.LastName,
+		"message":         "Create teacher success",
+		"Teacher_id":      teacher.TeacherID,
+		"FirstName":       teacher.FirstName,
+		"LastName":        teacher.LastName,
+		"InitialPassword": password,
 	})
 }
 
@@ -298,6 +291,15 @@ func UpdateTeacher(c *gin.Context) {
 		return
 	}
 
+	// เลขประจำตัวประชาชนที่ส่งกลับมาแบบปิดบัง/ค่าเดิม ไม่ต้องแก้ ค่าใหม่ต้องถูกต้องตามหลัก check digit
+	if !services.CitizenIDChanged(teacher.CitizenID, string(input.CitizenID)) {
+		input.CitizenID = ""
+	} else if !services.ValidCitizenID(string(input.CitizenID)) {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrInvalidCitizenID.Error()})
+		return
+	}
+
 	// อัพเดทข้อมูลอาจารย์
 	if err := tx.Model(teacher).Updates(input).Error; err != nil {
 		tx.Rollback()
@@ -1,11 +1,12 @@
 package entity
 
 type Admins struct {
-	AdminID   string `gorm:"primaryKey" json:"AdminID"`
-	FirstName string `json:"FirstName"`
-	LastName  string `json:"LastName"`
-	CitizenID string `json:"CitizenID"`
-	Gender    string `json:"Gender"`
-	Email     string `json:"Email"`
-	Phone     string `json:"Phone"`
+	AdminID       string    `gorm:"primaryKey" json:"AdminID"`
+	FirstName     string    `json:"FirstName"`
+	LastName      string    `json:"LastName"`
+	CitizenID     CitizenID `gorm:"serializer:pii" json:"CitizenID"`
+	CitizenIDHash string    `gorm:"index" json:"-"` // HMAC ของเลขประจำตัวประชาชน ใช้ค้นหา/ตรวจซ้ำ
+	Gender        string    `json:"Gender"`
+	Email         string    `json:"Email"`
+	Phone         string    `json:"Phone"`
 }
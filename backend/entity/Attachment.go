@@ -11,6 +11,7 @@ type Attachment struct {
 	Attachment_id string    `gorm:"primaryKey" json:"Attachment_id"`
 	File_Name     string    `json:"Attachment_File_Name"`
 	File_Path     string    `json:"Attachment_File_Path"`
+	Kind          string    `json:"Attachment_Kind"` // ชนิดเอกสาร ใช้ตรวจ RequiredAttachments ของประเภทคำร้อง
 	Uploaded_date time.Time `json:"Attachment_Uploaded_date"`
 	Deleted_at gorm.DeletedAt `gorm:"column:deleted_at;index"`
 
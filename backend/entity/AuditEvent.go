@@ -0,0 +1,26 @@
+package entity
+
+import "time"
+
+// การกระทำที่ต้องบันทึกไว้ตรวจสอบ
+const (
+	AuditPIIReveal   = "pii.reveal"   // ดูเลขประจำตัวประชาชนเต็ม
+	AuditPDPAExport  = "pdpa.export"  // ส่งออกข้อมูลส่วนบุคคลให้เจ้าของข้อมูล
+	AuditPDPAErasure = "pdpa.erasure" // ลบข้อมูลส่วนบุคคลตามคำขอ
+)
+
+// บันทึกการเข้าถึง/แก้ไขข้อมูลส่วนบุคคล 1 ครั้ง
+type AuditEvent struct {
+	ID int `gorm:"primaryKey;autoIncrement" json:"ID"`
+
+	Actor string `gorm:"index" json:"Actor"`
+	Role  string `json:"Role"`
+
+	Action  string `gorm:"index" json:"Action"`
+	Subject string `gorm:"index" json:"Subject"` // เช่น students/B6616052
+	Field   string `json:"Field,omitempty"`
+	Reason  string `json:"Reason,omitempty"`
+
+	IP        string    `json:"IP"`
+	CreatedAt time.Time `json:"CreatedAt"`
+}
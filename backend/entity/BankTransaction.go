@@ -0,0 +1,56 @@
+package entity
+
+import "time"
+
+// สถานะการจับคู่รายการเดินบัญชีกับบิล
+const (
+	BankTxMatched   = "matched"   // จับคู่และบันทึกรับชำระแล้ว
+	BankTxAmbiguous = "ambiguous" // เข้าได้หลายบิล หรือรหัสอ้างอิงตรงแต่ยอดไม่ตรง รอเจ้าหน้าที่ตรวจ
+	BankTxUnmatched = "unmatched" // หาบิลไม่พบ รอเจ้าหน้าที่ตรวจ
+	BankTxIgnored   = "ignored"   // เจ้าหน้าที่ระบุว่าไม่เกี่ยวกับค่าเล่าเรียน
+)
+
+// การนำเข้า statement 1 ครั้ง
+type BankStatementImport struct {
+	ID         int    `gorm:"primaryKey;autoIncrement" json:"ID"`
+	FileName   string `json:"FileName"`
+	ImportedBy string `json:"ImportedBy"`
+
+	Rows       int `json:"Rows"`
+	Matched    int `json:"Matched"`
+	Ambiguous  int `json:"Ambiguous"`
+	Unmatched  int `json:"Unmatched"`
+	Duplicates int `json:"Duplicates"`
+
+	CreatedAt time.Time `json:"CreatedAt"`
+}
+
+// รายการเงินเข้า 1 รายการจาก statement
+type BankTransaction struct {
+	ID int `gorm:"primaryKey;autoIncrement" json:"ID"`
+
+	ImportID int `gorm:"index" json:"ImportID"`
+	Line     int `json:"Line"` // บรรทัดในไฟล์ที่นำเข้า
+
+	TransactedAt time.Time `json:"TransactedAt"`
+	Amount       int       `json:"Amount"` // บาท
+	Reference    string    `json:"Reference"`
+	Description  string    `json:"Description"`
+	Sequence     string    `json:"Sequence,omitempty"` // เลขที่รายการของธนาคาร (ถ้า statement มี)
+
+	// ใช้กันนำเข้ารายการเดิมซ้ำ (เลขที่รายการของธนาคาร หรือ วันที่ + ยอด + อ้างอิง + รายละเอียด + ลำดับในไฟล์)
+	Fingerprint string `gorm:"uniqueIndex" json:"-"`
+
+	Status     string `gorm:"index" json:"Status"`
+	MatchNote  string `json:"MatchNote"`
+	Candidates string `json:"Candidates"` // id ของบิลที่เข้าเงื่อนไข (กรณี ambiguous) คั่นด้วย ,
+
+	BillID        *int `json:"BillID,omitempty"`
+	InstallmentID *int `json:"InstallmentID,omitempty"`
+	PaymentID     *int `json:"PaymentID,omitempty"`
+
+	ResolvedBy string     `json:"ResolvedBy"`
+	ResolvedAt *time.Time `json:"ResolvedAt"`
+
+	CreatedAt time.Time `json:"CreatedAt"`
+}
@@ -12,12 +12,16 @@ type Bill struct {
     Term         int       `json:"Term"`
 
     TotalPrice   int       `json:"TotalPrice"`
-    Date         time.Time `json:"Date"`
+    Date         time.Time `json:"Date"`       // วันที่ออกบิล ไม่เปลี่ยนเมื่ออัปโหลดหลักฐาน
+    DueDate      *time.Time `json:"DueDate"`   // วันครบกำหนดชำระ (บิลที่ไม่ได้ผ่อนชำระ)
 
     FilePath     string    `json:"FilePath"`
+    UploadedAt   *time.Time `json:"UploadedAt"` // เวลาอัปโหลดหลักฐานล่าสุด
     StatusID     int       `json:"StatusID"`
     Status       *BillStatus `gorm:"foreignKey:StatusID"`
 
     Registration []Registration `gorm:"many2many:bill_registrations;"`
+    Items        []BillItem     `gorm:"foreignKey:BillID" json:"Items"`
+    Installments []Installment  `gorm:"foreignKey:BillID" json:"Installments"`
 }
 
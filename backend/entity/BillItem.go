@@ -0,0 +1,37 @@
+package entity
+
+import "time"
+
+// ประเภทรายการในบิล
+const (
+	BillItemTuition  = "tuition"  // ค่าหน่วยกิตรายวิชา
+	BillItemTermFee  = "term_fee" // ค่าธรรมเนียมเหมาจ่ายรายภาค
+	BillItemLabFee   = "lab_fee"  // ค่าปฏิบัติการรายวิชา
+	BillItemDiscount = "discount" // ส่วนลด/ทุน (ยอดติดลบ)
+	BillItemPenalty  = "penalty"  // ค่าปรับ
+)
+
+// รายการในบิล 1 บรรทัด
+type BillItem struct {
+	ID int `gorm:"primaryKey;autoIncrement" json:"ID"`
+
+	BillID int `gorm:"index" json:"BillID"`
+
+	Type        string `json:"Type"`
+	Description string `json:"Description"`
+	Amount      int    `json:"Amount"` // ส่วนลดเก็บเป็นค่าติดลบ
+
+	SubjectID string `json:"SubjectID,omitempty"`
+
+	RegistrationID *int          `json:"RegistrationID,omitempty"`
+	Registration   *Registration `gorm:"foreignKey:RegistrationID;references:ID" json:"-"`
+
+	FeeScheduleID *int `json:"FeeScheduleID,omitempty"`
+	ScholarshipID *int `json:"ScholarshipID,omitempty"`
+	BillWaiverID  *int `json:"BillWaiverID,omitempty"`
+
+	// รายการที่เจ้าหน้าที่เพิ่มเอง จะไม่ถูกลบตอนคำนวณบิลใหม่
+	Manual bool `json:"Manual"`
+
+	CreatedAt time.Time `json:"CreatedAt"`
+}
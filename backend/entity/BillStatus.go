@@ -1,6 +1,13 @@
 package entity
 
+// รหัสสถานะบิล (ตรงกับข้อมูลตั้งต้นใน test/bill.go)
+const (
+	BillStatusUnpaid  = 1 // ค้างชำระ
+	BillStatusPending = 2 // รอตรวจสอบ
+	BillStatusPaid    = 3 // ชำระแล้ว
+)
+
 type BillStatus struct {
 	ID     int    `gorm:"autoIncrement" json:"ID"`
 	Status string `json:"Status"`
-}
\ No newline at end of file
+}
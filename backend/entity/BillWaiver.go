@@ -0,0 +1,20 @@
+package entity
+
+import "time"
+
+// การยกเว้นรายการในบิลตามคำร้องที่อนุมัติแล้ว
+// ระบบคำนวณเป็นรายการส่วนลดใหม่ทุกครั้งที่คำนวณบิล ยอดจึงตามรายการที่ยังอยู่ในบิล
+// เช่น ถอนรายวิชาที่ได้รับยกเว้นแล้วส่วนลดจะหายไปด้วย
+type BillWaiver struct {
+	ID int `gorm:"primaryKey;autoIncrement" json:"ID"`
+
+	BillID    int    `gorm:"index" json:"BillID"`
+	ItemType  string `json:"ItemType"`            // ประเภทรายการที่ยกเว้น เช่น tuition, lab_fee
+	SubjectID string `json:"SubjectID,omitempty"` // ยกเว้นเฉพาะรายการของรายวิชานี้ (ว่าง = ทุกรายการของประเภทนี้)
+
+	Report_id string `gorm:"index" json:"Report_id,omitempty"`
+	Reason    string `json:"Reason"`
+
+	CreatedBy string    `json:"CreatedBy"`
+	CreatedAt time.Time `json:"CreatedAt"`
+}
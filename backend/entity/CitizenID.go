@@ -0,0 +1,29 @@
+package entity
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// เลขประจำตัวประชาชน 13 หลัก
+// เก็บในฐานข้อมูลแบบเข้ารหัส (serializer:pii ดู config/pii.go) และแสดงผลแบบปิดบังเสมอ เช่น 1-1029-xxxxx-32-4
+// เจ้าหน้าที่ที่มีสิทธิ์ดูเลขเต็มได้ผ่าน endpoint citizen-id ซึ่งบันทึก AuditEvent ทุกครั้ง
+type CitizenID string
+
+func (c CitizenID) Masked() string {
+	s := string(c)
+	if s == "" {
+		return ""
+	}
+	if len(s) == 13 {
+		return s[:1] + "-" + s[1:5] + "-xxxxx-" + s[10:12] + "-" + s[12:]
+	}
+	if len(s) <= 3 {
+		return strings.Repeat("x", len(s))
+	}
+	return strings.Repeat("x", len(s)-3) + s[len(s)-3:]
+}
+
+func (c CitizenID) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.Masked())
+}
@@ -12,6 +12,12 @@ type Curriculum struct {
 	StartYear      int    `json:"StartYear"`
 	Description    string `json:"Description"`
 
+	// หลักสูตรที่ต้องให้อาจารย์ที่ปรึกษาอนุมัติแผนการเรียนก่อนทุกเทอม
+	RequireAdvisorApproval bool `json:"RequireAdvisorApproval"`
+
+	// เกรดเฉลี่ยสะสมขั้นต่ำของนักศึกษาที่ขอย้ายเข้าหลักสูตรนี้ (0 = ไม่กำหนด)
+	TransferMinGPAX float64 `json:"TransferMinGPAX"`
+
 	FacultyID string   `json:"FacultyID"`
 	Faculty   *Faculty `gorm:"foreignKey:FacultyID;references:FacultyID"`
 
@@ -0,0 +1,58 @@
+package entity
+
+import "time"
+
+// ประเภทคำขอของเจ้าของข้อมูลตาม PDPA
+const (
+	DataRequestAccess  = "access"  // ขอสำเนาข้อมูลส่วนบุคคล
+	DataRequestErasure = "erasure" // ขอลบ/ทำให้ไม่สามารถระบุตัวตนได้
+)
+
+// สถานะคำขอ
+const (
+	DataRequestPending   = "pending"
+	DataRequestCompleted = "completed"
+	DataRequestRejected  = "rejected"
+)
+
+// ข้อมูลที่ขอลบได้ (ข้อมูลการศึกษา/การเงินต้องเก็บไว้ตามกฎหมาย)
+const (
+	ErasureScopeContact     = "contact"     // อีเมล โทรศัพท์ ที่อยู่ ผู้ปกครอง
+	ErasureScopeSensitive   = "sensitive"   // สัญชาติ เชื้อชาติ ศาสนา วันเกิด
+	ErasureScopeAttachments = "attachments" // ไฟล์แนบคำร้อง/รายงาน สำเนาบัตรประชาชน/ทะเบียนบ้าน (ไม่รวมหลักฐานการชำระเงิน)
+)
+
+// ข้อมูลที่คงไว้พร้อมเหตุผลทางกฎหมาย
+type RetainedData struct {
+	Item   string `json:"Item"`
+	Reason string `json:"Reason"`
+}
+
+// ผลการดำเนินการลบข้อมูล
+type DataErasureResult struct {
+	Erased   []string       `json:"Erased"`
+	Files    int            `json:"Files"` // จำนวนไฟล์ที่ลบ
+	Retained []RetainedData `json:"Retained"`
+}
+
+// คำขอใช้สิทธิของเจ้าของข้อมูล (ขอสำเนา/ขอลบ) ใช้เป็นบันทึกการดำเนินการด้วย
+type DataSubjectRequest struct {
+	ID int `gorm:"primaryKey;autoIncrement" json:"ID"`
+
+	StudentID string `gorm:"index" json:"StudentID"`
+
+	Type   string   `gorm:"index" json:"Type"`
+	Scopes []string `gorm:"serializer:json" json:"Scopes,omitempty"`
+	Reason string   `json:"Reason"`
+
+	Status       string `gorm:"default:pending;index" json:"Status"`
+	RejectReason string `json:"RejectReason,omitempty"`
+
+	RequestedBy string     `json:"RequestedBy"`
+	RequestedAt time.Time  `json:"RequestedAt"`
+	ReviewedBy  string     `json:"ReviewedBy,omitempty"`
+	ReviewedAt  *time.Time `json:"ReviewedAt,omitempty"`
+	CompletedAt *time.Time `json:"CompletedAt,omitempty"`
+
+	Result *DataErasureResult `gorm:"serializer:json" json:"Result,omitempty"`
+}
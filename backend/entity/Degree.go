@@ -3,6 +3,7 @@ package entity
 type Degree struct {
 	DegreeID int    `gorm:"primaryKey;autoIncrement" json:"DegreeID"`
 	Degree   string `gorm:"unique" json:"Degree"`
+	Prefix   string `json:"Prefix"` // ตัวอักษรนำหน้ารหัสนักศึกษา ({DEGREE}) เช่น B, M, D
 
 	Students []Students `gorm:"foreignKey:DegreeID" json:"-"` // ระบุความสัมพันธ์ 1--many [Degree]
 }
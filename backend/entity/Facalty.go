@@ -4,6 +4,7 @@ type Faculty struct {
 	ID          int    `gorm:"primaryKey;autoIncrement" json:"ID"`
 	FacultyID   string `gorm:"primaryKey;unique" json:"FacultyID"`
 	FacultyName string `json:"FacultyName"`
+	Code        string `json:"Code"` // รหัสคณะ 2 ตัวที่ใช้ในรหัสนักศึกษา ({FACULTY})
 
 	Students []Students `gorm:"foreignKey:FacultyID" json:"-"` // ระบุความสัมพันธ์ 1--many [Students]
 	Teachers []Teachers `gorm:"foreignKey:FacultyID" json:"-"` // ระบุความสัมพันธ์ 1--many [Teachers]
@@ -0,0 +1,38 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ตารางอัตราค่าธรรมเนียมการศึกษา
+// ฟิลด์ขอบเขต (DegreeID, FacultyID, CurriculumID, EntryYear) ถ้าเป็น nil = ใช้กับทุกค่า
+// ตารางที่ระบุขอบเขตละเอียดกว่าจะถูกใช้ก่อน
+type FeeSchedule struct {
+	ID   int    `gorm:"primaryKey;autoIncrement" json:"ID"`
+	Name string `json:"Name"`
+
+	DegreeID *int    `json:"DegreeID"`
+	Degree   *Degree `gorm:"foreignKey:DegreeID;references:DegreeID" json:"Degree,omitempty"`
+
+	FacultyID *string  `json:"FacultyID"`
+	Faculty   *Faculty `gorm:"foreignKey:FacultyID;references:FacultyID" json:"Faculty,omitempty"`
+
+	CurriculumID *string     `json:"CurriculumID"`
+	Curriculum   *Curriculum `gorm:"foreignKey:CurriculumID;references:CurriculumID" json:"Curriculum,omitempty"`
+
+	EntryYear *int `json:"EntryYear"` // รุ่นของนักศึกษา (ปีที่เข้าศึกษา พ.ศ.) เช่น 2566
+
+	RatePerCredit int `json:"RatePerCredit"` // ค่าหน่วยกิต ต่อ 1 หน่วยกิต
+	TermFee       int `json:"TermFee"`       // ค่าธรรมเนียมเหมาจ่ายรายภาค
+
+	EffectiveFrom time.Time  `json:"EffectiveFrom"`
+	EffectiveTo   *time.Time `json:"EffectiveTo"` // nil = ยังใช้อยู่
+
+	LabFees []LabFee `gorm:"foreignKey:FeeScheduleID;constraint:OnDelete:CASCADE" json:"LabFees"`
+
+	CreatedAt time.Time      `json:"CreatedAt"`
+	UpdatedAt time.Time      `json:"UpdatedAt"`
+	DeletedAt gorm.DeletedAt `json:"DeletedAt,omitempty" gorm:"index"`
+}
@@ -1,5 +1,10 @@
 package entity
 
+import "time"
+
+// เกรด I (ไม่สมบูรณ์) ไม่นับใน GPA จนกว่าจะแก้เกรดภายใน IncompleteDeadline
+const GradeIncomplete = "I"
+
 type Grades struct {
 	ID         int     `gorm:"primaryKey;autoIncrement" json:"ID"`
 	TotalScore float32 `json:"TotalScore"`
@@ -11,4 +16,6 @@ type Grades struct {
 	SubjectID string   `gorm:"uniqueIndex:idx_student_subject" json:"SubjectID"` // Foreign Key
 	Subject   *Subject `gorm:"foreignKey:SubjectID;references:SubjectID"`        // ระบุความสัมพันธ์ 1--many [Subject]
 
+	IncompleteDeadline *time.Time `json:"IncompleteDeadline,omitempty"` // กำหนดแก้เกรด I
+
 }
@@ -0,0 +1,41 @@
+package entity
+
+import "time"
+
+// ประเภทการระงับสิทธิ์ (hold)
+const (
+	HoldFinancial    = "financial"    // ค้างชำระ
+	HoldDisciplinary = "disciplinary" // วินัยนักศึกษา
+	HoldLibrary      = "library"      // ค้างคืนหนังสือ/ค่าปรับห้องสมุด
+	HoldAdvising     = "advising"     // ต้องพบอาจารย์ที่ปรึกษา
+)
+
+// การระงับสิทธิ์ของนักศึกษา 1 รายการ
+type Hold struct {
+	ID int `gorm:"primaryKey;autoIncrement" json:"ID"`
+
+	StudentID string    `gorm:"index" json:"StudentID"`
+	Student   *Students `gorm:"foreignKey:StudentID;references:StudentID" json:"Student,omitempty"`
+
+	Type   string `json:"Type"`
+	Reason string `json:"Reason"`
+	Office string `json:"Office"` // หน่วยงานที่ระงับสิทธิ์
+
+	// สิทธิ์ที่ถูกระงับ
+	BlocksRegistration bool `json:"BlocksRegistration"`
+	BlocksTranscript   bool `json:"BlocksTranscript"`
+	BlocksGraduation   bool `json:"BlocksGraduation"`
+
+	// บิลที่ทำให้เกิดการระงับ (กรณีระงับอัตโนมัติจากการค้างชำระ)
+	BillID *int `gorm:"index" json:"BillID,omitempty"`
+
+	PlacedBy string    `json:"PlacedBy"`
+	PlacedAt time.Time `json:"PlacedAt"`
+
+	// วันที่ปลดการระงับตามกำหนด (nil = จนกว่าจะปลด)
+	ReleaseDate *time.Time `json:"ReleaseDate,omitempty"`
+
+	ReleasedBy  string     `json:"ReleasedBy,omitempty"`
+	ReleasedAt  *time.Time `json:"ReleasedAt,omitempty"`
+	ReleaseNote string     `json:"ReleaseNote,omitempty"`
+}
@@ -0,0 +1,28 @@
+package entity
+
+import "time"
+
+// งวดผ่อนชำระของบิล 1 งวด
+type Installment struct {
+	ID int `gorm:"primaryKey;autoIncrement" json:"ID"`
+
+	BillID int `gorm:"uniqueIndex:idx_bill_installment_seq" json:"BillID"`
+	Seq    int `gorm:"uniqueIndex:idx_bill_installment_seq" json:"Seq"` // งวดที่ (เริ่มที่ 1)
+
+	Amount  int       `json:"Amount"`  // ยอดที่ต้องชำระในงวดนี้ (รวมค่าปรับแล้ว)
+	LateFee int       `json:"LateFee"` // ค่าปรับชำระล่าช้าที่บวกเข้างวดนี้
+	DueDate time.Time `json:"DueDate"`
+
+	StatusID int         `gorm:"default:1" json:"StatusID"`
+	Status   *BillStatus `gorm:"foreignKey:StatusID" json:"Status,omitempty"`
+
+	FilePath   string     `json:"FilePath"`
+	UploadedAt *time.Time `json:"UploadedAt"`
+	PaidAt     *time.Time `json:"PaidAt"`
+
+	// รายการค่าปรับในบิลที่สร้างจากงวดนี้
+	PenaltyItemID *int `json:"PenaltyItemID,omitempty"`
+
+	CreatedAt time.Time `json:"CreatedAt"`
+	UpdatedAt time.Time `json:"UpdatedAt"`
+}
@@ -0,0 +1,13 @@
+package entity
+
+// ค่าปฏิบัติการรายวิชา ผูกกับตารางค่าธรรมเนียม
+type LabFee struct {
+	ID int `gorm:"primaryKey;autoIncrement" json:"ID"`
+
+	FeeScheduleID int `gorm:"uniqueIndex:idx_schedule_subject" json:"FeeScheduleID"`
+
+	SubjectID string   `gorm:"uniqueIndex:idx_schedule_subject" json:"SubjectID"`
+	Subject   *Subject `gorm:"foreignKey:SubjectID;references:SubjectID" json:"Subject,omitempty"`
+
+	Amount int `json:"Amount"`
+}
@@ -8,6 +8,8 @@ type Majors struct {
 	FacultyID string   `json:"FacultyID"`                                 // Foreign Key
 	Faculty   *Faculty `gorm:"foreignKey:FacultyID;references:FacultyID"` // ระบุความสัมพันธ์ 1--1 [Faculty]
 
+	HeadTeacherID string `json:"HeadTeacherID"` // หัวหน้าสาขาวิชา (ผู้พิจารณาคำร้องขั้น department_head)
+
 	Students []Students `gorm:"foreignKey:MajorID" json:"-"` // ระบุความสัมพันธ์ 1--many [Students]
 	Teachers []Teachers `gorm:"foreignKey:MajorID" json:"-"` // ระบุความสัมพันธ์ 1--many [Teachers]
 
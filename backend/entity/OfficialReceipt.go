@@ -0,0 +1,48 @@
+package entity
+
+import "time"
+
+// เลขที่ใบเสร็จล่าสุดของแต่ละปีงบประมาณ (ปี พ.ศ. เริ่ม 1 ตุลาคม)
+type ReceiptSequence struct {
+	FiscalYear int `gorm:"primaryKey;autoIncrement:false" json:"FiscalYear"`
+	LastNumber int `json:"LastNumber"`
+}
+
+// รายการในใบเสร็จ (เก็บสำเนา ณ วันที่ออก ไม่เปลี่ยนตามบิล)
+type OfficialReceiptLine struct {
+	Type        string `json:"Type"`
+	Description string `json:"Description"`
+	SubjectID   string `json:"SubjectID,omitempty"`
+	Amount      int    `json:"Amount"`
+}
+
+// ใบเสร็จรับเงินอย่างเป็นทางการ ออกให้ 1 ใบต่อการรับชำระ 1 รายการ
+type OfficialReceipt struct {
+	ID int `gorm:"primaryKey;autoIncrement" json:"ID"`
+
+	ReceiptNo  string `gorm:"uniqueIndex" json:"ReceiptNo"` // เช่น RC2569-000001
+	FiscalYear int    `gorm:"uniqueIndex:idx_receipt_year_seq" json:"FiscalYear"`
+	Seq        int    `gorm:"uniqueIndex:idx_receipt_year_seq" json:"Seq"`
+
+	PaymentID int      `gorm:"uniqueIndex" json:"PaymentID"`
+	Payment   *Payment `gorm:"foreignKey:PaymentID" json:"-"`
+
+	BillID    int    `gorm:"index" json:"BillID"`
+	StudentID string `gorm:"index" json:"StudentID"`
+
+	StudentName  string `json:"StudentName"`
+	AcademicYear int    `json:"AcademicYear"`
+	Term         int    `json:"Term"`
+
+	InstallmentSeq int `json:"InstallmentSeq,omitempty"` // งวดที่ชำระ (0 = ชำระทั้งบิล)
+
+	Lines     []OfficialReceiptLine `gorm:"serializer:json" json:"Lines"`
+	BillTotal int                   `json:"BillTotal"`
+	Amount    int                   `json:"Amount"` // ยอดที่รับชำระในใบเสร็จนี้
+	Method    string                `json:"Method"`
+	Reference string                `json:"Reference"`
+
+	ApprovedBy string    `json:"ApprovedBy"` // เจ้าหน้าที่ผู้อนุมัติการชำระ
+	PaidAt     time.Time `json:"PaidAt"`
+	IssuedAt   time.Time `json:"IssuedAt"`
+}
@@ -1,13 +1,49 @@
 package entity
 
+import "time"
+
+// ประเภทรายการในสมุดบัญชีรับเงินของบิล
+const (
+	PaymentTypePayment        = "payment"         // รับชำระ
+	PaymentTypeRefund         = "refund"          // คืนเงินส่วนที่ชำระเกิน
+	PaymentTypeCredit         = "credit"          // นำเครดิตจากบิลอื่นมาชำระ
+	PaymentTypeCreditTransfer = "credit_transfer" // โอนเครดิตของบิลนี้ไปชำระบิลอื่น
+)
+
+// ช่องทางการชำระเงิน
+const (
+	PaymentMethodCash      = "cash"
+	PaymentMethodTransfer  = "transfer"
+	PaymentMethodPromptPay = "promptpay"
+	PaymentMethodCard      = "card"
+	PaymentMethodCredit    = "credit"
+)
+
+// รายการรับ/คืนเงิน 1 รายการของบิล
 type Payment struct {
-	ID          int    `gorm:"primaryKey;autoIncrement" json:"ID"`
+	ID int `gorm:"primaryKey;autoIncrement" json:"ID"`
+
+	PaymentID string `gorm:"uniqueIndex" json:"PaymentID"` // เลขที่รายการ
+
+	BillID int   `gorm:"index" json:"BillID"`
+	Bill   *Bill `gorm:"foreignKey:BillID" json:"Bill,omitempty"`
+
+	// งวดที่รายการนี้ชำระ (ถ้าเป็นบิลผ่อนชำระ)
+	InstallmentID *int         `json:"InstallmentID,omitempty"`
+	Installment   *Installment `gorm:"foreignKey:InstallmentID" json:"-"`
+
+	// บิลอีกฝั่งของการโอนเครดิต
+	RelatedBillID *int `json:"RelatedBillID,omitempty"`
 
-	PaymentID  string `gorm:"unique" json:"PaymentID"`
+	Type      string `gorm:"default:payment" json:"Type"`
+	Method    string `json:"Method"`
+	Amount    int    `json:"Amount"` // จำนวนเงินเป็นบวกเสมอ ทิศทางดูจาก Type
+	Reference string `json:"Reference"`
+	Note      string `json:"Note"`
 
-	PaymentMethod string `json:"PaymentMethod"` // e.g., "Credit Card", "Bank Transfer"
-	PaymentStatus   string `json:"PaymentStatus"` // e.g., "Pending", "Completed", "Failed"
+	PayerID    string `json:"PayerID"`    // ผู้ชำระ (ปกติคือรหัสนักศึกษา)
+	RecordedBy string `json:"RecordedBy"` // ผู้บันทึกรายการ
 
-	BillID string `json:"BillID"` // Reference to the bill associated with this payment
-	Bill *Bill `gorm:"foreignKey:BillID;reference:BillID" json:"Bill"` // Association with the Bill entity
-}
\ No newline at end of file
+	PaidAt    time.Time `json:"PaidAt"`
+	CreatedAt time.Time `json:"CreatedAt"`
+}
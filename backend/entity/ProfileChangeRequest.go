@@ -0,0 +1,48 @@
+package entity
+
+import "time"
+
+// สถานะคำขอแก้ไขข้อมูลนักศึกษา
+const (
+	ProfileChangePending  = "pending"
+	ProfileChangeApproved = "approved"
+	ProfileChangeRejected = "rejected"
+)
+
+// ค่าก่อน/หลังของฟิลด์ที่ขอแก้ไข 1 ฟิลด์ (Field ใช้ชื่อเดียวกับ JSON ของ Students)
+type ProfileFieldChange struct {
+	Field  string `json:"Field"`
+	Before string `json:"Before"`
+	After  string `json:"After"`
+}
+
+// คำขอแก้ไขข้อมูลประจำตัว/ข้อมูลการศึกษา ที่ต้องให้เจ้าหน้าที่อนุมัติ
+type ProfileChangeRequest struct {
+	ID int `gorm:"primaryKey;autoIncrement" json:"ID"`
+
+	StudentID string    `gorm:"index" json:"StudentID"`
+	Student   *Students `gorm:"foreignKey:StudentID;references:StudentID" json:"Student,omitempty"`
+
+	Changes []ProfileFieldChange `gorm:"serializer:json" json:"Changes"`
+	Note    string               `json:"Note"`
+
+	Status       string `gorm:"default:pending;index" json:"Status"`
+	RejectReason string `json:"RejectReason,omitempty"`
+
+	RequestedAt time.Time  `json:"RequestedAt"`
+	ReviewedBy  string     `json:"ReviewedBy,omitempty"`
+	ReviewedAt  *time.Time `json:"ReviewedAt,omitempty"`
+
+	Attachments []ProfileChangeAttachment `gorm:"foreignKey:ChangeRequestID" json:"Attachments"`
+}
+
+// เอกสารประกอบคำขอแก้ไขข้อมูล (เช่น สำเนาใบเปลี่ยนชื่อ)
+type ProfileChangeAttachment struct {
+	ID int `gorm:"primaryKey;autoIncrement" json:"ID"`
+
+	ChangeRequestID int `gorm:"index" json:"ChangeRequestID"`
+
+	FileName     string    `json:"FileName"` // ชื่อไฟล์ที่บันทึกใน uploads
+	OriginalName string    `json:"OriginalName"`
+	UploadedAt   time.Time `json:"UploadedAt"`
+}
@@ -0,0 +1,71 @@
+package entity
+
+import "time"
+
+// สถานะคำขอย้ายสาขา/หลักสูตร
+const (
+	ProgramTransferPending   = "pending"          // รอคณะปลายทางพิจารณา
+	ProgramTransferAccepted  = "faculty_accepted" // คณะปลายทางรับแล้ว รอเจ้าหน้าที่อนุมัติ
+	ProgramTransferScheduled = "scheduled"        // อนุมัติแล้ว รอภาคการศึกษาที่มีผลเริ่มจึงย้ายข้อมูล
+	ProgramTransferApproved  = "approved"         // อนุมัติและย้ายข้อมูลนักศึกษาแล้ว
+	ProgramTransferRejected  = "rejected"
+)
+
+// คำขอย้ายสาขา/หลักสูตร เก็บไว้ทุกรายการเป็นประวัติการย้าย
+type ProgramTransfer struct {
+	ID int `gorm:"primaryKey;autoIncrement" json:"ID"`
+
+	StudentID string    `gorm:"index" json:"StudentID"`
+	Student   *Students `gorm:"foreignKey:StudentID;references:StudentID" json:"Student,omitempty"`
+
+	FromCurriculumID string `json:"FromCurriculumID"`
+	FromMajorID      string `json:"FromMajorID"`
+	FromFacultyID    string `json:"FromFacultyID"`
+
+	ToCurriculumID string      `json:"ToCurriculumID"`
+	ToCurriculum   *Curriculum `gorm:"foreignKey:ToCurriculumID;references:CurriculumID" json:"ToCurriculum,omitempty"`
+	ToMajorID      string      `json:"ToMajorID"`
+	ToFacultyID    string      `gorm:"index" json:"ToFacultyID"`
+
+	Reason string `json:"Reason"`
+
+	// เกรดเฉลี่ย ณ วันที่ยื่น และเกณฑ์ของหลักสูตรปลายทาง
+	GPAX         float64 `json:"GPAX"`
+	RequiredGPAX float64 `json:"RequiredGPAX"`
+
+	Status string `gorm:"default:pending;index" json:"Status"`
+
+	// การพิจารณาของคณะปลายทาง
+	FacultyReviewedBy string     `json:"FacultyReviewedBy,omitempty"`
+	FacultyReviewedAt *time.Time `json:"FacultyReviewedAt,omitempty"`
+	FacultyComment    string     `json:"FacultyComment,omitempty"`
+
+	// การอนุมัติของเจ้าหน้าที่
+	ReviewedBy   string     `json:"ReviewedBy,omitempty"`
+	ReviewedAt   *time.Time `json:"ReviewedAt,omitempty"`
+	RejectReason string     `json:"RejectReason,omitempty"`
+
+	// ภาคการศึกษาที่การย้ายมีผล
+	EffectiveSemesterID *int      `json:"EffectiveSemesterID,omitempty"`
+	EffectiveSemester   *Semester `gorm:"foreignKey:EffectiveSemesterID;references:ID" json:"EffectiveSemester,omitempty"`
+
+	RequestedAt time.Time `json:"RequestedAt"`
+
+	CreditMappings []TransferCreditMapping `gorm:"foreignKey:ProgramTransferID" json:"CreditMappings"`
+}
+
+// การเทียบโอนรายวิชาที่เรียนแล้วเข้าหลักสูตรปลายทาง (ใช้ในการตรวจสอบการจบ)
+type TransferCreditMapping struct {
+	ID int `gorm:"primaryKey;autoIncrement" json:"ID"`
+
+	ProgramTransferID int    `gorm:"index" json:"ProgramTransferID"`
+	StudentID         string `gorm:"index" json:"StudentID"`
+
+	SubjectID string `json:"SubjectID"` // วิชาที่เรียนแล้ว
+	Grade     string `json:"Grade"`
+	Credit    int    `json:"Credit"`
+
+	// วิชาในหลักสูตรปลายทางที่เทียบได้ (ว่าง = นับเป็นหน่วยกิตเลือกเสรี)
+	TargetSubjectID string `json:"TargetSubjectID,omitempty"`
+	Counted         bool   `json:"Counted"` // นับหน่วยกิตในหลักสูตรปลายทางหรือไม่
+}
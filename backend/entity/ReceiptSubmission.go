@@ -0,0 +1,30 @@
+package entity
+
+import "time"
+
+// สถานะหลักฐานการชำระเงินที่นักศึกษาส่ง
+const (
+	ReceiptSubmitted  = "submitted"  // รอตรวจสอบ
+	ReceiptApproved   = "approved"   // อนุมัติ (บันทึกรับชำระแล้ว)
+	ReceiptRejected   = "rejected"   // ไม่อนุมัติ ต้องส่งใหม่
+	ReceiptSuperseded = "superseded" // ถูกแทนที่ด้วยไฟล์ที่ส่งใหม่ก่อนตรวจ
+)
+
+// หลักฐานการชำระเงิน 1 ครั้ง (เก็บทุกเวอร์ชัน ไม่เขียนทับ)
+type ReceiptSubmission struct {
+	ID int `gorm:"primaryKey;autoIncrement" json:"ID"`
+
+	BillID        int  `gorm:"index" json:"BillID"`
+	InstallmentID *int `json:"InstallmentID,omitempty"`
+	Version       int  `json:"Version"` // ลำดับการส่งของบิล/งวดนั้น
+
+	FilePath     string    `json:"FilePath"`
+	OriginalName string    `json:"OriginalName"`
+	SubmittedBy  string    `json:"SubmittedBy"`
+	SubmittedAt  time.Time `json:"SubmittedAt"`
+
+	Status     string     `gorm:"index" json:"Status"`
+	Reason     string     `json:"Reason"` // เหตุผลที่ไม่อนุมัติ
+	ReviewedBy string     `json:"ReviewedBy"`
+	ReviewedAt *time.Time `json:"ReviewedAt"`
+}
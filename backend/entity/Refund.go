@@ -0,0 +1,56 @@
+package entity
+
+import "time"
+
+// สถานะคำขอคืนเงิน
+const (
+	RefundPending  = "pending"  // รอฝ่ายการเงินพิจารณา
+	RefundApproved = "approved" // อนุมัติและบันทึกคืนเงินในสมุดบัญชีแล้ว
+	RefundRejected = "rejected" // ไม่อนุมัติ
+)
+
+// เหตุผลของคำขอคืนเงิน
+const (
+	RefundReasonDrop        = "drop"        // ถอนรายวิชาหลังชำระเงิน
+	RefundReasonOverpayment = "overpayment" // ชำระเกิน/ชำระซ้ำ
+)
+
+// นโยบายคืนเงินค่าหน่วยกิตเมื่อถอนรายวิชา ตามสัปดาห์ที่ถอนนับจากวันเปิดภาค
+type RefundPolicy struct {
+	ID       int    `gorm:"primaryKey;autoIncrement" json:"ID"`
+	FromWeek int    `json:"FromWeek"`
+	ToWeek   int    `json:"ToWeek"` // 0 = ไม่มีกำหนดสิ้นสุด
+	Percent  int    `json:"Percent"`
+	Note     string `json:"Note"`
+}
+
+// คำขอคืนเงินของบิล
+type RefundRequest struct {
+	ID int `gorm:"primaryKey;autoIncrement" json:"ID"`
+
+	BillID    int    `gorm:"index" json:"BillID"`
+	StudentID string `gorm:"index" json:"StudentID"`
+
+	// รายการรับชำระที่ขอคืน (เช่น ชำระซ้ำ) ไม่บังคับ
+	PaymentID *int `json:"PaymentID,omitempty"`
+
+	Reason string `json:"Reason"`
+	Note   string `json:"Note"`
+
+	Amount         int    `json:"Amount"`         // ยอดที่ขอคืน
+	ApprovedAmount int    `json:"ApprovedAmount"` // ยอดที่อนุมัติคืน
+	Method         string `json:"Method"`
+	BankAccount    string `json:"BankAccount"`
+
+	Status       string `gorm:"default:pending;index" json:"Status"`
+	RejectReason string `json:"RejectReason,omitempty"`
+
+	RequestedBy string     `json:"RequestedBy"`
+	RequestedAt time.Time  `json:"RequestedAt"`
+	ReviewedBy  string     `json:"ReviewedBy,omitempty"`
+	ReviewedAt  *time.Time `json:"ReviewedAt,omitempty"`
+
+	// รายการคืนเงินในสมุดบัญชีที่สร้างเมื่ออนุมัติ
+	RefundPaymentID *int     `json:"RefundPaymentID,omitempty"`
+	RefundPayment   *Payment `gorm:"foreignKey:RefundPaymentID" json:"RefundPayment,omitempty"`
+}
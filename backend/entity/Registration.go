@@ -7,6 +7,13 @@ import (
     "gorm.io/gorm"
 )
 
+// สถานะการลงทะเบียน (แผนการเรียนที่ต้องผ่านอาจารย์ที่ปรึกษา)
+const (
+    RegistrationPending  = "pending"  // รออาจารย์ที่ปรึกษาอนุมัติ
+    RegistrationApproved = "approved" // อนุมัติแล้ว นับรวมในบิลและรายชื่อนักศึกษาในรายวิชา
+    RegistrationRejected = "rejected" // ไม่อนุมัติ
+)
+
 type Registration struct {
     ID int `gorm:"primaryKey;autoIncrement" json:"ID"`
 
@@ -21,6 +28,12 @@ type Registration struct {
 
     StudentID string    `json:"StudentID"`
     Student   *Students `gorm:"foreignKey:StudentID;references:StudentID"`
+
+    // การอนุมัติจากอาจารย์ที่ปรึกษา (เรคคอร์ดเดิมถือว่าอนุมัติแล้ว)
+    Status         string     `gorm:"default:approved;index" json:"Status"`
+    AdvisorComment string     `json:"AdvisorComment"`
+    ReviewedBy     string     `json:"ReviewedBy"`
+    ReviewedAt     *time.Time `json:"ReviewedAt"`
 }
 
 // หลังสร้างเรคคอร์ด กำหนด RegistrationID จากเลข ID ให้เป็นรูปแบบ REG###
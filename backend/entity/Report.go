@@ -11,6 +11,10 @@ type Report struct {
 	Report_details  string    `json:"Report_details"`
 	Submittion_date time.Time `json:"ReportSubmission_date"`
 	Status          string    `json:"ReportStatus"`
+	CurrentStep     int       `json:"CurrentStep"` // Seq ของขั้นที่รอพิจารณา (0 = ไม่มี workflow)
+
+	// คำตอบตาม FormSchema ของประเภทคำร้อง (key -> ค่าที่ตรวจและจัดรูปแล้ว)
+	FormData map[string]string `gorm:"serializer:json" json:"FormData,omitempty"`
 
 	Created_at time.Time      `gorm:"column:created_at;autoCreateTime"`
 	Updated_at time.Time      `gorm:"column:updated_at;autoUpdateTime"`
@@ -28,4 +32,6 @@ type Report struct {
 	Attachments []Attachment `gorm:"foreignKey:Report_id;references:Report_id" json:"attachments"`
 
 	Comments []ReviewerComment `gorm:"foreignKey:Report_id;references:Report_id" json:"-"`
+
+	ActionLogs []ReportActionLog `gorm:"foreignKey:Report_id;references:Report_id" json:"ActionLogs,omitempty"`
 }
@@ -0,0 +1,38 @@
+package entity
+
+import "time"
+
+// การดำเนินการอัตโนมัติเมื่อคำร้องได้รับอนุมัติขั้นสุดท้าย
+const (
+	ReportActionChangeStatus     = "change_status"     // เปลี่ยนสถานภาพนักศึกษาเป็น Status (มีผลภาคการศึกษาตาม SemesterField, ลาพักกี่ภาคตาม TermsField)
+	ReportActionAddRegistration  = "add_registration"  // ลงทะเบียนรายวิชาตาม SubjectField (อนุมัติแล้ว)
+	ReportActionDropRegistration = "drop_registration" // ถอนรายวิชาตาม SubjectField ตามนโยบายคืนเงิน
+	ReportActionWaiveBillItem    = "waive_bill_item"   // ยกเว้นรายการ ItemType ในบิลของเทอมตาม SemesterField (เฉพาะรายวิชาตาม SubjectField ถ้ากำหนด)
+	ReportActionExtendIncomplete = "extend_incomplete" // ขยายกำหนดแก้เกรด I ของรายวิชาตาม SubjectField ถึงวันที่ตาม DateField หรือ +Days วัน
+)
+
+// การดำเนินการ 1 รายการของประเภทคำร้อง พารามิเตอร์ *Field อ้างอิง key ใน FormSchema
+type ReportAction struct {
+	Type          string `json:"Type"`
+	Status        string `json:"Status,omitempty"`
+	SubjectField  string `json:"SubjectField,omitempty"`
+	SemesterField string `json:"SemesterField,omitempty"`
+	DateField     string `json:"DateField,omitempty"`
+	TermsField    string `json:"TermsField,omitempty"`
+	ItemType      string `json:"ItemType,omitempty"`
+	Days          int    `json:"Days,omitempty"`
+}
+
+// ผลของการดำเนินการอัตโนมัติที่ทำกับคำร้อง
+type ReportActionLog struct {
+	ID int `gorm:"primaryKey;autoIncrement" json:"ID"`
+
+	Report_id string `gorm:"index" json:"Report_id"`
+	Seq       int    `json:"Seq"`
+	Type      string `json:"Type"`
+	Detail    string `json:"Detail"`
+	Ref       string `json:"Ref,omitempty"` // รหัสเรคคอร์ดที่ถูกสร้าง/แก้ไข เช่น Registration, BillItem, Grades
+
+	Actor     string    `json:"Actor"`
+	CreatedAt time.Time `json:"CreatedAt"`
+}
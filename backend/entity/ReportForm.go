@@ -0,0 +1,29 @@
+package entity
+
+// ชนิดช่องข้อมูลในแบบฟอร์มคำร้อง
+const (
+	ReportFieldText     = "text"
+	ReportFieldDate     = "date"     // YYYY-MM-DD
+	ReportFieldSubject  = "subject"  // รหัสรายวิชา (SubjectID)
+	ReportFieldSemester = "semester" // รหัสภาคการศึกษา (SemesterID)
+	ReportFieldNumber   = "number"
+	ReportFieldFile     = "file" // แนบไฟล์ เก็บเป็นรหัส Attachment
+)
+
+// ช่องข้อมูลหนึ่งช่องใน FormSchema ของประเภทคำร้อง
+type ReportFormField struct {
+	Key      string   `json:"Key"`
+	Label    string   `json:"Label"`
+	Type     string   `json:"Type"`
+	Required bool     `json:"Required"`
+	Min      *float64 `json:"Min,omitempty"` // ใช้กับ number
+	Max      *float64 `json:"Max,omitempty"`
+}
+
+// คำตอบของคำร้องแยกเป็นแถวต่อช่อง เพื่อให้ค้นหา/กรองคำร้องตามคำตอบได้
+type ReportFieldValue struct {
+	ID        uint   `gorm:"primaryKey" json:"ID"`
+	Report_id string `gorm:"index" json:"Report_id"`
+	Key       string `gorm:"column:field_key;index:idx_report_field_key_value" json:"Key"`
+	Value     string `gorm:"column:field_value;index:idx_report_field_key_value" json:"Value"`
+}
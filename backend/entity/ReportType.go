@@ -5,5 +5,16 @@ type ReportType struct {
 	ReportType_Name string `json:"ReportType_Name"`
 	Description     string `json:"ReportTypeDescription"`
 
+	// ขั้นการพิจารณา การเปลี่ยนสถานะที่อนุญาต (ว่าง = ใช้ค่าเริ่มต้น) และเอกสารแนบที่ต้องมีก่อนอนุมัติ
+	Steps               []ReportWorkflowStep `gorm:"foreignKey:ReportType_id;references:ReportType_id" json:"Steps,omitempty"`
+	Transitions         []ReportTransition   `gorm:"serializer:json" json:"Transitions,omitempty"`
+	RequiredAttachments []string             `gorm:"serializer:json" json:"RequiredAttachments,omitempty"`
+
+	// ช่องข้อมูลที่นักศึกษาต้องกรอกเมื่อยื่นคำร้องประเภทนี้
+	FormSchema []ReportFormField `gorm:"serializer:json" json:"FormSchema,omitempty"`
+
+	// สิ่งที่ระบบทำให้อัตโนมัติเมื่อคำร้องได้รับอนุมัติขั้นสุดท้าย
+	Actions []ReportAction `gorm:"serializer:json" json:"Actions,omitempty"`
+
 	Reports []Report `gorm:"foreignKey:ReportType_id;references:ReportType_id"  json:"-"`
 }
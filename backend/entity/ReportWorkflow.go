@@ -0,0 +1,61 @@
+package entity
+
+import "time"
+
+// สถานะคำร้อง (ค่าเดิมที่หน้าจอใช้อยู่ + สถานะเพิ่มเติมของ workflow)
+const (
+	ReportStatusPending   = "รอดำเนินการ"
+	ReportStatusApproved  = "อนุมัติ"
+	ReportStatusRejected  = "ไม่อนุมัติ"
+	ReportStatusReturned  = "ส่งกลับแก้ไข" // ส่งกลับให้นักศึกษาแก้ไข/แนบเอกสารเพิ่ม แล้วยื่นใหม่ที่ขั้นเดิม
+	ReportStatusCancelled = "ยกเลิก"
+)
+
+// ผู้พิจารณาของแต่ละขั้น
+const (
+	ReportStepAdvisor        = "advisor"         // อาจารย์ที่ปรึกษาของนักศึกษา
+	ReportStepDepartmentHead = "department_head" // หัวหน้าสาขาวิชาของนักศึกษา
+	ReportStepRegistrar      = "registrar"       // เจ้าหน้าที่ทะเบียน
+)
+
+// ผู้ที่เปลี่ยนสถานะได้ในแต่ละ transition
+const (
+	ReportActorReviewer = "reviewer" // ผู้พิจารณาของขั้นปัจจุบัน (หรือแอดมิน)
+	ReportActorStudent  = "student"  // นักศึกษาเจ้าของคำร้อง
+)
+
+// การเปลี่ยนสถานะที่อนุญาต 1 แบบ
+type ReportTransition struct {
+	From string `json:"From"`
+	To   string `json:"To"`
+	By   string `json:"By"`
+}
+
+// ขั้นการพิจารณาของคำร้องประเภทหนึ่ง เรียงตาม Seq
+// Reviewer_id กำหนดผู้พิจารณาตายตัวได้ ถ้าว่างจะหาจาก Role ตามนักศึกษาที่ยื่น
+type ReportWorkflowStep struct {
+	ID int `gorm:"primaryKey;autoIncrement" json:"ID"`
+
+	ReportType_id string `gorm:"index" json:"ReportType_id"`
+	Seq           int    `json:"Seq"`
+	Name          string `json:"Name"`
+	Role          string `json:"Role"`
+	Reviewer_id   string `json:"Reviewer_id,omitempty"`
+}
+
+// ประวัติการพิจารณาคำร้องแต่ละครั้ง
+type ReportStatusLog struct {
+	ID int `gorm:"primaryKey;autoIncrement" json:"ID"`
+
+	Report_id   string `gorm:"index" json:"Report_id"`
+	Step        int    `json:"Step"`
+	StepName    string `json:"StepName,omitempty"`
+	Reviewer_id string `json:"Reviewer_id,omitempty"`
+
+	FromStatus string `json:"FromStatus"`
+	ToStatus   string `json:"ToStatus"`
+	Comment    string `json:"Comment,omitempty"`
+
+	Actor     string    `json:"Actor"`
+	CreatedAt time.Time `json:"CreatedAt"`
+}
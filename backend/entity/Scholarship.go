@@ -0,0 +1,42 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// รูปแบบการให้ทุน/ส่วนลด
+const (
+	ScholarshipPercent = "percent" // ลดเป็นเปอร์เซ็นต์ของยอดบิล
+	ScholarshipFixed   = "fixed"   // ลดเป็นจำนวนเงินคงที่
+)
+
+// ทุนการศึกษา / ส่วนลด / การยกเว้นค่าธรรมเนียม
+type Scholarship struct {
+	ID   int    `gorm:"primaryKey;autoIncrement" json:"ID"`
+	Name string `json:"Name"`
+
+	Type  string `json:"Type"`  // percent | fixed
+	Value int    `json:"Value"` // เปอร์เซ็นต์ (1-100) หรือจำนวนเงิน (บาท)
+	Cap   int    `json:"Cap"`   // ยอดลดสูงสุดต่อเทอม (0 = ไม่จำกัด)
+
+	// เงื่อนไขผู้มีสิทธิ์ (ค่าว่าง = ไม่กำหนด)
+	MinGPAX         float64        `json:"MinGPAX"`
+	FacultyID       *string        `json:"FacultyID"`
+	Faculty         *Faculty       `gorm:"foreignKey:FacultyID;references:FacultyID" json:"Faculty,omitempty"`
+	StatusStudentID *string        `json:"StatusStudentID"`
+	StatusStudent   *StatusStudent `gorm:"foreignKey:StatusStudentID;references:StatusStudentID" json:"StatusStudent,omitempty"`
+
+	// ช่วงเทอมที่ใช้ได้ (EndYear = 0 คือไม่มีกำหนดสิ้นสุด)
+	StartYear int `json:"StartYear"`
+	StartTerm int `json:"StartTerm"`
+	EndYear   int `json:"EndYear"`
+	EndTerm   int `json:"EndTerm"`
+
+	Students []StudentScholarship `gorm:"foreignKey:ScholarshipID" json:"-"`
+
+	CreatedAt time.Time      `json:"CreatedAt"`
+	UpdatedAt time.Time      `json:"UpdatedAt"`
+	DeletedAt gorm.DeletedAt `json:"DeletedAt,omitempty" gorm:"index"`
+}
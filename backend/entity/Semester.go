@@ -1,9 +1,14 @@
 package entity
 
+import "time"
+
 type Semester struct {
 	ID           string `gorm:"primaryKey;autoIncrement" json:"SemesterID"`
 	Term         int `json:"Term"`
 	AcademicYear int `json:"AcademicYear"`
 
+	// วันเปิดภาคเรียน ใช้นับสัปดาห์ตามนโยบายคืนเงินเมื่อถอนรายวิชา (nil = ยังไม่กำหนด คืนเต็มจำนวน)
+	StartDate *time.Time `json:"StartDate,omitempty"`
+
 	Subject []Subject `gorm:"foreignKey:SemesterID;references:ID" json:"-"` // ระบุความสัมพันธ์เเบบ 1--many[Subject]
 }
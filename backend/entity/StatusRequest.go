@@ -0,0 +1,55 @@
+package entity
+
+import "time"
+
+// ประเภทคำร้องเปลี่ยนสถานภาพ
+const (
+	StatusRequestLeave     = "leave"     // ลาพักการศึกษา
+	StatusRequestReturn    = "return"    // กลับเข้าศึกษาหลังลาพัก
+	StatusRequestWithdraw  = "withdraw"  // ลาออก
+	StatusRequestReinstate = "reinstate" // ขอคืนสภาพนักศึกษา
+)
+
+// สถานะคำร้อง
+const (
+	StatusRequestPending   = "pending"
+	StatusRequestScheduled = "scheduled" // อนุมัติแล้ว รอภาคการศึกษาที่มีผลเริ่มจึงเปลี่ยนสถานภาพ
+	StatusRequestApproved  = "approved"
+	StatusRequestRejected  = "rejected"
+)
+
+// คำร้องเปลี่ยนสถานภาพนักศึกษา
+type StatusRequest struct {
+	ID int `gorm:"primaryKey;autoIncrement" json:"ID"`
+
+	StudentID string    `gorm:"index" json:"StudentID"`
+	Student   *Students `gorm:"foreignKey:StudentID;references:StudentID" json:"Student,omitempty"`
+
+	Type   string `json:"Type"`
+	Reason string `json:"Reason"`
+	Terms  int    `json:"Terms"` // จำนวนภาคการศึกษาที่ขอลาพัก
+
+	EffectiveSemesterID int       `json:"EffectiveSemesterID"`
+	EffectiveSemester   *Semester `gorm:"foreignKey:EffectiveSemesterID;references:ID" json:"EffectiveSemester,omitempty"`
+
+	Status       string `gorm:"default:pending;index" json:"Status"`
+	RejectReason string `json:"RejectReason,omitempty"`
+
+	RequestedBy string     `json:"RequestedBy"`
+	RequestedAt time.Time  `json:"RequestedAt"`
+	ReviewedBy  string     `json:"ReviewedBy,omitempty"`
+	ReviewedAt  *time.Time `json:"ReviewedAt,omitempty"`
+
+	Documents []StatusRequestDocument `gorm:"foreignKey:StatusRequestID" json:"Documents"`
+}
+
+// เอกสารประกอบคำร้อง (เช่น ใบรับรองแพทย์)
+type StatusRequestDocument struct {
+	ID int `gorm:"primaryKey;autoIncrement" json:"ID"`
+
+	StatusRequestID int `gorm:"index" json:"StatusRequestID"`
+
+	FileName     string    `json:"FileName"`
+	OriginalName string    `json:"OriginalName"`
+	UploadedAt   time.Time `json:"UploadedAt"`
+}
@@ -1,5 +1,17 @@
 package entity
 
+// รหัสสถานภาพนักศึกษา
+const (
+	StatusStudying            = "10" // กำลังศึกษาอยู่
+	StatusGraduationRequested = "20" // แจ้งจบการศึกษา
+	StatusGraduated           = "30" // สำเร็จการศึกษา
+	StatusGraduationRejected  = "40" // ไม่อนุมัติให้สำเร็จการศึกษา
+	StatusOnLeave             = "50" // ลาพักการศึกษา
+	StatusSuspended           = "60" // ถูกพักการศึกษา
+	StatusWithdrawn           = "70" // ลาออก
+	StatusTerminated          = "00" // สิ้นสภาพการศึกษา
+)
+
 type StatusStudent struct {
 	StatusStudentID string `gorm:"primaryKey;" json:"StatusStudentID"`
 	Status          string `json:"Status"`
@@ -9,13 +9,14 @@ import (
 type Students struct {
 	ID int `gorm:"autoIncrement" json:"ID"`
 
-	StudentID   string    `gorm:"primaryKey" json:"StudentID"`
-	FirstName   string    `json:"FirstName"`
-	LastName    string    `json:"LastName"`
-	CitizenID   string    `json:"CitizenID"`
-	Email       string    `json:"Email"`
-	Phone       string    `json:"Phone"`
-	GraduteDate time.Time `json:"GraduteDate"`
+	StudentID     string    `gorm:"primaryKey" json:"StudentID"`
+	FirstName     string    `json:"FirstName"`
+	LastName      string    `json:"LastName"`
+	CitizenID     CitizenID `gorm:"serializer:pii" json:"CitizenID"`
+	CitizenIDHash string    `gorm:"index" json:"-"` // HMAC ของเลขประจำตัวประชาชน ใช้ค้นหา/ตรวจซ้ำ
+	Email         string    `json:"Email"`
+	Phone         string    `json:"Phone"`
+	GraduteDate   time.Time `json:"GraduteDate"`
 
 	GenderID int     `json:"GenderID"`
 	Gender   *Gender `gorm:"foreignKey:GenderID;references:ID"`
@@ -45,6 +46,9 @@ type Students struct {
 	/*TeacherID string    `json:"TeacherID"`
 	Teacher   *Teachers `gorm:"foreignKey:TeacherID;references:TeacherID"`*/
 
+	AdvisorID string    `json:"AdvisorID"`                                 // อาจารย์ที่ปรึกษา
+	Advisor   *Teachers `gorm:"foreignKey:AdvisorID;references:TeacherID"` // ระบุความสัมพันธ์ many--1 [Teachers]
+
 	Address     string `json:"Address"`
 	Nationality string `json:"Nationality"`
 	Ethnicity   string `json:"Ethnicity"`
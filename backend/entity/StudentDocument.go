@@ -0,0 +1,41 @@
+package entity
+
+import "time"
+
+// ประเภทเอกสารประจำตัวนักศึกษา
+const (
+	DocumentPhoto              = "photo"               // รูปถ่ายติดบัตร
+	DocumentIDCard             = "id_card"             // สำเนาบัตรประชาชน
+	DocumentHouseRegistration  = "house_registration"  // สำเนาทะเบียนบ้าน
+	DocumentPreviousTranscript = "previous_transcript" // ใบแสดงผลการเรียนระดับก่อนหน้า
+)
+
+// สถานะการตรวจเอกสาร
+const (
+	DocumentPending    = "pending"
+	DocumentVerified   = "verified"
+	DocumentRejected   = "rejected"
+	DocumentSuperseded = "superseded" // ถูกแทนที่ด้วยเวอร์ชันใหม่ก่อนตรวจ
+)
+
+// เอกสารของนักศึกษา 1 เวอร์ชัน (อัปโหลดใหม่จะเพิ่มเวอร์ชัน ไม่เขียนทับ)
+type StudentDocument struct {
+	ID int `gorm:"primaryKey;autoIncrement" json:"ID"`
+
+	StudentID string `gorm:"index:idx_student_document" json:"StudentID"`
+	Type      string `gorm:"index:idx_student_document" json:"Type"`
+	Version   int    `json:"Version"`
+
+	FileName     string `json:"FileName"` // ชื่อไฟล์ที่บันทึกใน uploads
+	OriginalName string `json:"OriginalName"`
+	ContentType  string `json:"ContentType"` // ตรวจจากเนื้อไฟล์ ไม่ใช่จากนามสกุล
+	Size         int64  `json:"Size"`
+
+	Status       string `gorm:"default:pending;index" json:"Status"`
+	RejectReason string `json:"RejectReason,omitempty"`
+
+	UploadedBy string     `json:"UploadedBy"`
+	UploadedAt time.Time  `json:"UploadedAt"`
+	VerifiedBy string     `json:"VerifiedBy,omitempty"`
+	VerifiedAt *time.Time `json:"VerifiedAt,omitempty"`
+}
@@ -0,0 +1,7 @@
+package entity
+
+// เลขรันล่าสุดของรหัสนักศึกษาแต่ละชุด (Prefix = รหัสที่แทนเลขรันด้วย #)
+type StudentIDSequence struct {
+	Prefix     string `gorm:"primaryKey" json:"Prefix"`
+	LastNumber int    `json:"LastNumber"`
+}
@@ -0,0 +1,32 @@
+package entity
+
+import "time"
+
+// สถานะทุนของนักศึกษา
+const (
+	StudentScholarshipActive    = "active"    // ใช้สิทธิ์ได้
+	StudentScholarshipSuspended = "suspended" // ระงับ (เช่น เกรดไม่ถึงเกณฑ์ต่อทุน)
+	StudentScholarshipRevoked   = "revoked"   // ยกเลิกโดยเจ้าหน้าที่
+)
+
+// การมอบทุนให้นักศึกษา
+type StudentScholarship struct {
+	ID int `gorm:"primaryKey;autoIncrement" json:"ID"`
+
+	StudentID string    `gorm:"uniqueIndex:idx_student_scholarship" json:"StudentID"`
+	Student   *Students `gorm:"foreignKey:StudentID;references:StudentID" json:"Student,omitempty"`
+
+	ScholarshipID int          `gorm:"uniqueIndex:idx_student_scholarship" json:"ScholarshipID"`
+	Scholarship   *Scholarship `gorm:"foreignKey:ScholarshipID" json:"Scholarship,omitempty"`
+
+	Status string `gorm:"default:active" json:"Status"`
+	Note   string `json:"Note"`
+
+	// ผลการตรวจต่อทุนครั้งล่าสุด
+	LastCheckedYear int     `json:"LastCheckedYear"`
+	LastCheckedTerm int     `json:"LastCheckedTerm"`
+	LastCheckedGPAX float64 `json:"LastCheckedGPAX"`
+
+	CreatedAt time.Time `json:"CreatedAt"`
+	UpdatedAt time.Time `json:"UpdatedAt"`
+}
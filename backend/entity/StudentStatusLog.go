@@ -0,0 +1,24 @@
+package entity
+
+import "time"
+
+// ประวัติการเปลี่ยนสถานภาพนักศึกษา 1 ครั้ง
+type StudentStatusLog struct {
+	ID int `gorm:"primaryKey;autoIncrement" json:"ID"`
+
+	StudentID string `gorm:"index" json:"StudentID"`
+
+	FromStatusID string `json:"FromStatusID"`
+	ToStatusID   string `json:"ToStatusID"`
+
+	EffectiveSemesterID *int      `json:"EffectiveSemesterID,omitempty"`
+	EffectiveSemester   *Semester `gorm:"foreignKey:EffectiveSemesterID;references:ID" json:"EffectiveSemester,omitempty"`
+
+	Reason     string `json:"Reason"`
+	ApprovedBy string `json:"ApprovedBy"`
+
+	// คำร้องที่ทำให้เกิดการเปลี่ยนสถานภาพ (ถ้ามี) เอกสารประกอบอยู่ที่คำร้อง
+	StatusRequestID *int `json:"StatusRequestID,omitempty"`
+
+	CreatedAt time.Time `json:"CreatedAt"`
+}
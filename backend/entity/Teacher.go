@@ -7,13 +7,14 @@ import (
 )
 
 type Teachers struct {
-	ID        int    `gorm:"autoIncrement" json:"ID"`
-	TeacherID string `gorm:"primaryKey" json:"TeacherID"`
-	FirstName string `json:"FirstName"`
-	LastName  string `json:"LastName"`
-	CitizenID string `json:"CitizenID"`
-	Email     string `json:"Email"`
-	Phone     string `json:"Phone"`
+	ID            int       `gorm:"autoIncrement" json:"ID"`
+	TeacherID     string    `gorm:"primaryKey" json:"TeacherID"`
+	FirstName     string    `json:"FirstName"`
+	LastName      string    `json:"LastName"`
+	CitizenID     CitizenID `gorm:"serializer:pii" json:"CitizenID"`
+	CitizenIDHash string    `gorm:"index" json:"-"` // HMAC ของเลขประจำตัวประชาชน ใช้ค้นหา/ตรวจซ้ำ
+	Email         string    `json:"Email"`
+	Phone         string    `json:"Phone"`
 
 	GenderID int     `json:"GenderID"`
 	Gender   *Gender `gorm:"foreignKey:GenderID;references:ID"`
@@ -30,6 +31,7 @@ type Teachers struct {
 	Subject []Subject `gorm:"foreignKey:TeacherID;references:TeacherID" json:"Subject"`  // ระบุความสัมพันธ์เเบบ 1--many[Subject]
 
 	//Student []Students `gorm:"foreignKey:TeacherID;references:TeacherID" json:"Student"`  // ระบุความสัมพันธ์เเบบ 1--many[Student]
+	Advisees []Students `gorm:"foreignKey:AdvisorID;references:TeacherID" json:"-"` // นักศึกษาในที่ปรึกษา 1--many[Student]
 
 	Address     string `json:"Address"`
 	Nationality string `json:"Nationality"`
@@ -1,12 +1,16 @@
 package main
 
 import (
+	"log"
 	"net/http"
 	"reg_system/config"
+	"reg_system/services"
 	"reg_system/test"
+	"time"
 
 	// Controllers
 	"reg_system/controller/admins"
+	"reg_system/controller/audit"
 	"reg_system/controller/bill"
 	"reg_system/controller/curriculum"
 	"reg_system/controller/gender"
@@ -16,14 +20,24 @@ import (
 
 	"reg_system/controller/degree"
 	"reg_system/controller/faculty"
+	"reg_system/controller/fee"
+	"reg_system/controller/finance"
 	"reg_system/controller/graduation"
+	"reg_system/controller/hold"
+	"reg_system/controller/imports"
 	"reg_system/controller/major"
+	"reg_system/controller/pdpa"
 	"reg_system/controller/position"
+	"reg_system/controller/programtransfer"
 	"reg_system/controller/registration"
 	"reg_system/controller/reports"
 	"reg_system/controller/reporttypes"
+	"reg_system/controller/scholarship"
 	"reg_system/controller/status"
+	"reg_system/controller/studentcard"
+	"reg_system/controller/studentdocument"
 	"reg_system/controller/students"
+	"reg_system/controller/studentstatus"
 	subjects "reg_system/controller/subject"
 	"reg_system/controller/subjectcurriculum"
 	"reg_system/controller/subjectstudytime"
@@ -38,6 +52,11 @@ import (
 const port = "8000"
 
 func main() {
+	// -------------------- Secrets --------------------
+	if err := config.RequirePIIKey(); err != nil {
+		log.Fatal(err)
+	}
+
 	// -------------------- Database --------------------
 	config.ConnectionDB()
 	config.SetupDatabase()
@@ -45,6 +64,10 @@ func main() {
 	// -------------------- Seed/Test Data --------------------
 	test.ExampleData()
 
+	// -------------------- Scheduled Jobs --------------------
+	services.StartLateFeeSweep(config.DB(), time.Duration(config.LateFeeSweepHours())*time.Hour)
+	services.StartEffectiveChangeSweep(config.DB(), time.Duration(config.EffectiveChangeSweepHours())*time.Hour)
+
 	// -------------------- Gin Setup --------------------
 	r := gin.Default()
 	r.RedirectTrailingSlash = true
@@ -63,6 +86,13 @@ func main() {
 		userGroup.PUT("/:id", users.ChangePassword)
 	}
 
+	// -------------------- Student Card (ตรวจบัตรโดยไม่ต้องเข้าสู่ระบบ) --------------------
+	studentCardGroup := r.Group("/student-cards")
+	{
+		studentCardGroup.GET("/public-key", studentcard.GetPublicKey)
+		studentCardGroup.GET("/verify", studentcard.VerifyStudentCard)
+	}
+
 	// AuthMiddleware ตรวจ token ก่อน
 	r.Use(middlewares.AuthMiddleware())
 
@@ -73,6 +103,7 @@ func main() {
 	adminGroup := r.Group("/admin")
 	{
 		adminGroup.GET("/:id", admins.GetAdminID)
+		adminGroup.GET("/:id/citizen-id", audit.RevealAdminCitizenID)
 	}
 
 	// -------------------- Students --------------------
@@ -80,9 +111,30 @@ func main() {
 	{
 		studentGroup.GET("/:id", students.GetStudentID)
 		studentGroup.POST("/", students.CreateStudent)
+		studentGroup.POST("/import", imports.ImportStudents)
+		studentGroup.GET("/id-audit", students.AuditStudentIDs)
+		studentGroup.GET("/:id/citizen-id", audit.RevealStudentCitizenID)
 		studentGroup.GET("/", students.GetStudentAll)
 		studentGroup.PUT("/:id", students.UpdateStudent)
 		studentGroup.DELETE("/:id", students.DeleteStudent)
+		studentGroup.GET("/:id/scholarships", scholarship.GetScholarshipsByStudentID)
+		studentGroup.GET("/:id/holds", hold.GetHoldsByStudentID)
+		studentGroup.GET("/:id/account", bill.GetStudentAccount)
+		studentGroup.GET("/:id/change-requests", students.GetChangeRequestsByStudentID)
+		studentGroup.GET("/:id/program-transfers", programtransfer.GetProgramTransfersByStudentID)
+		studentGroup.GET("/:id/degree-audit", programtransfer.GetDegreeAudit)
+		studentGroup.PUT("/:id/status", studentstatus.ChangeStudentStatus)
+		studentGroup.GET("/:id/status-history", studentstatus.GetStatusHistory)
+		studentGroup.GET("/:id/status-requests", studentstatus.GetStatusRequestsByStudentID)
+		studentGroup.GET("/:id/transcript", grade.GetTranscript)
+		studentGroup.GET("/:id/data-export", pdpa.ExportStudentData)
+		studentGroup.GET("/:id/data-requests", pdpa.GetDataRequestsByStudentID)
+		studentGroup.GET("/:id/card", studentcard.GetStudentCard)
+		studentGroup.POST("/:id/documents", studentdocument.UploadDocument)
+		studentGroup.GET("/:id/documents", studentdocument.GetDocumentsByStudentID)
+		studentGroup.GET("/:id/documents/:did", studentdocument.GetDocumentFile)
+		studentGroup.GET("/:id/documents/:did/thumbnail", studentdocument.GetDocumentThumbnail)
+		studentGroup.PUT("/:id/documents/:did", studentdocument.VerifyDocument)
 
 		studentGroup.GET("/:id/grades", grade.GetGradeByStudentID)
 		studentGroup.GET("/:id/scores", scores.GetScoreByStudentID)
@@ -91,11 +143,61 @@ func main() {
 		studentGroup.GET("/reports/:sid", reports.GetReportsByStu)
 	}
 
+	// -------------------- Profile Change Requests --------------------
+	changeRequestGroup := r.Group("/change-requests")
+	{
+		changeRequestGroup.GET("/", students.GetChangeRequestAll)
+		changeRequestGroup.PUT("/:crid", students.ReviewChangeRequest)
+		changeRequestGroup.POST("/:crid/attachments", students.UploadChangeAttachment)
+		changeRequestGroup.GET("/:crid/attachments/:aid", students.GetChangeAttachment)
+	}
+
+	// -------------------- Program Transfers --------------------
+	programTransferGroup := r.Group("/program-transfers")
+	{
+		programTransferGroup.GET("/", programtransfer.GetProgramTransferAll)
+		programTransferGroup.POST("/", programtransfer.CreateProgramTransfer)
+		programTransferGroup.PUT("/:id/faculty-review", programtransfer.FacultyReviewTransfer)
+		programTransferGroup.PUT("/:id", programtransfer.ReviewProgramTransfer)
+	}
+
+	// -------------------- Status Requests (ลาพัก/กลับเข้าศึกษา/ลาออก/คืนสภาพ) --------------------
+	statusRequestGroup := r.Group("/status-requests")
+	{
+		statusRequestGroup.GET("/", studentstatus.GetStatusRequestAll)
+		statusRequestGroup.POST("/", studentstatus.CreateStatusRequest)
+		statusRequestGroup.PUT("/:id", studentstatus.ReviewStatusRequest)
+		statusRequestGroup.POST("/:id/documents", studentstatus.UploadStatusDocument)
+		statusRequestGroup.GET("/:id/documents/:did", studentstatus.GetStatusDocument)
+	}
+
+	// -------------------- Student Documents --------------------
+	studentDocumentGroup := r.Group("/student-documents")
+	{
+		studentDocumentGroup.GET("/", studentdocument.GetDocumentAll)
+	}
+
+	// -------------------- PDPA Data Subject Requests --------------------
+	dataRequestGroup := r.Group("/data-requests")
+	{
+		dataRequestGroup.GET("/", pdpa.GetDataRequestAll)
+		dataRequestGroup.POST("/", pdpa.CreateErasureRequest)
+		dataRequestGroup.PUT("/:id", pdpa.ReviewErasureRequest)
+	}
+
+	// -------------------- Audit Events --------------------
+	auditGroup := r.Group("/audit-events")
+	{
+		auditGroup.GET("/", audit.GetAuditEvents)
+	}
+
 	// -------------------- Teachers --------------------
 	teacherGroup := r.Group("/teachers")
 	{
 		teacherGroup.GET("/:id", teachers.GetTeacherID)
 		teacherGroup.POST("/", teachers.CreateTeacher)
+		teacherGroup.POST("/import", imports.ImportTeachers)
+		teacherGroup.GET("/:id/citizen-id", audit.RevealTeacherCitizenID)
 		teacherGroup.GET("/", teachers.GetTeacherAll)
 		teacherGroup.PUT("/:id", teachers.UpdateTeacher)
 		teacherGroup.DELETE("/:id", teachers.DeleteTeacher)
@@ -105,6 +207,10 @@ func main() {
 		teacherGroup.GET("/:id/students", teachers.GetStudentByTeacherID)
 
 		teacherGroup.POST("/scores", scores.CreateScores)
+
+		// อาจารย์ที่ปรึกษาอนุมัติแผนการเรียน
+		teacherGroup.GET("/:id/advisees/registrations", registration.GetAdviseeRegistrations)
+		teacherGroup.PUT("/:id/advisees/:sid/registrations", registration.ReviewAdviseeRegistrations)
 	}
 
 	// -------------------- Majors --------------------
@@ -112,6 +218,7 @@ func main() {
 	{
 		majorGroup.GET("/", major.GetMajorAll)
 		majorGroup.POST("/", major.CreateMajor)
+		majorGroup.PUT("/:id/head", major.UpdateMajorHead)
 	}
 
 	// -------------------- Faculties --------------------
@@ -145,6 +252,9 @@ func main() {
 	semesterGroup := r.Group("/semesters/")
 	{
 		semesterGroup.GET("", semester.GetSemesterAll)
+		semesterGroup.PUT(":id", semester.UpdateSemester)
+		semesterGroup.GET("refund-policies", semester.GetRefundPolicies)
+		semesterGroup.PUT("refund-policies", semester.ReplaceRefundPolicies)
 	}
 	//---------------------------------------------------------
 	registrationGroup := r.Group("/registrations")
@@ -223,6 +333,7 @@ func main() {
 		reportGroup.POST("/", reports.CreateReport)
 		reportGroup.POST("/:id/attachments", reports.AddReportAttachment)
 		reportGroup.PUT("/:id/status", reports.UpdateStatus)
+		reportGroup.GET("/:id/history", reports.GetReportHistory)
 		reportGroup.GET("/:id/comments", reports.GetReportComments)
 		reportGroup.POST("/:id/comments", reports.CreateReportComment)
 		reportGroup.DELETE("/:id/attachments/:attId", reports.DeleteReportAttachment)
@@ -238,6 +349,9 @@ func main() {
 		reportTypeGroup.POST("/", reporttypes.CreateReportType)
 		reportTypeGroup.POST("", reporttypes.CreateReportType)
 		reportTypeGroup.PUT("/:id", reporttypes.UpdateReportType)
+		reportTypeGroup.PUT("/:id/workflow", reporttypes.UpdateReportTypeWorkflow)
+		reportTypeGroup.PUT("/:id/form", reporttypes.UpdateReportTypeForm)
+		reportTypeGroup.PUT("/:id/actions", reporttypes.UpdateReportTypeActions)
 		reportTypeGroup.DELETE("/:id", reporttypes.DeleteReportType)
 	}
 
@@ -258,6 +372,66 @@ func main() {
 		billGroup.GET("/preview/:id", bill.ShowFile)
 		billGroup.GET("/admin/all", bill.GetAllBills)
 		billGroup.PUT("/:id", bill.UpdateBillStatus)
+		billGroup.GET("/:id/installments", bill.GetInstallments)
+		billGroup.POST("/:id/installments", bill.CreateInstallments)
+		billGroup.DELETE("/:id/installments", bill.DeleteInstallments)
+		billGroup.PUT("/:id/installments/:iid", bill.ReviewInstallment)
+		billGroup.POST("/admin/late-fees", bill.ApplyLateFees)
+		billGroup.GET("/:id/payments", bill.GetPayments)
+		billGroup.POST("/:id/payments", bill.CreatePayment)
+		billGroup.GET("/:id/promptpay", bill.GetPromptPay)
+		billGroup.GET("/:id/promptpay.png", bill.GetPromptPayPNG)
+		billGroup.POST("/admin/reconcile", bill.ImportStatement)
+		billGroup.GET("/admin/reconcile", bill.GetBankTransactions)
+		billGroup.PUT("/admin/reconcile/:txid", bill.ResolveBankTransaction)
+		billGroup.GET("/:id/receipts", bill.GetReceipts)
+		billGroup.PUT("/:id/receipts/:rid", bill.ReviewReceipt)
+		billGroup.GET("/:id/official-receipts", bill.GetOfficialReceipts)
+		billGroup.GET("/:id/official-receipts/:rid/pdf", bill.DownloadOfficialReceipt)
+		billGroup.GET("/:id/refunds", bill.GetRefunds)
+		billGroup.POST("/:id/refunds", bill.CreateRefund)
+		billGroup.PUT("/:id/refunds/:rfid", bill.ReviewRefund)
+		billGroup.GET("/admin/refunds", bill.GetRefundQueue)
+	}
+
+	// -------------------- Fee Schedules --------------------
+	feeScheduleGroup := r.Group("/fee-schedules")
+	{
+		feeScheduleGroup.GET("/", fee.GetFeeScheduleAll)
+		feeScheduleGroup.GET("/:id", fee.GetFeeScheduleByID)
+		feeScheduleGroup.POST("/", fee.CreateFeeSchedule)
+		feeScheduleGroup.PUT("/:id", fee.UpdateFeeSchedule)
+		feeScheduleGroup.DELETE("/:id", fee.DeleteFeeSchedule)
+	}
+
+	// -------------------- Scholarships --------------------
+	scholarshipGroup := r.Group("/scholarships")
+	{
+		scholarshipGroup.GET("/", scholarship.GetScholarshipAll)
+		scholarshipGroup.GET("/:id", scholarship.GetScholarshipByID)
+		scholarshipGroup.POST("/", scholarship.CreateScholarship)
+		scholarshipGroup.PUT("/:id", scholarship.UpdateScholarship)
+		scholarshipGroup.DELETE("/:id", scholarship.DeleteScholarship)
+		scholarshipGroup.POST("/:id/students", scholarship.AssignScholarship)
+		scholarshipGroup.DELETE("/:id/students/:sid", scholarship.RevokeScholarship)
+		scholarshipGroup.POST("/renewals", scholarship.RenewScholarships)
+	}
+
+	// -------------------- Finance Reports --------------------
+	financeGroup := r.Group("/finance")
+	{
+		financeGroup.GET("/reports/revenue", finance.GetRevenueReport)
+		financeGroup.GET("/reports/aging", finance.GetAgingReport)
+		financeGroup.GET("/reports/collection", finance.GetCollectionReport)
+	}
+
+	// -------------------- Holds --------------------
+	holdGroup := r.Group("/holds")
+	{
+		holdGroup.GET("/", hold.GetHoldAll)
+		holdGroup.POST("/", hold.CreateHold)
+		holdGroup.PUT("/:id/release", hold.ReleaseHold)
+		holdGroup.POST("/overdue", hold.PlaceOverdueHolds)
 	}
 
 	//---------------------------------------------------------
@@ -9,28 +9,84 @@ import (
 // Route -> Permission Mapping
 var RoutePermission = map[string][]string{
 	// admin
-	"GET /admin/:id": {"admin", "student"},
+	"GET /admin/:id":            {"admin", "student"},
+	"GET /admin/:id/citizen-id": {"admin"},
 	// student
-	"GET /students/":             {"admin"},
-	"GET /students/:id":          {"student"},
-	"PUT /students/:id":          {"student"},
-	"DELETE /students/:id":       {"admin"},
-	"POST /students/":            {"admin"},
-	"GET /students/:id/grades":   {"student"},
-	"GET /students/:id/scores":   {"student"},
-	"GET /students/reports/:sid": {"student"},
+	"GET /students/":                    {"admin"},
+	"GET /students/:id":                 {"student"},
+	"PUT /students/:id":                 {"student"},
+	"DELETE /students/:id":              {"admin"},
+	"POST /students/":                   {"admin"},
+	"POST /students/import":             {"admin"},
+	"GET /students/id-audit":            {"admin"},
+	"GET /students/:id/citizen-id":      {"admin"},
+	"GET /students/:id/grades":          {"student"},
+	"GET /students/:id/scores":          {"student"},
+	"GET /students/reports/:sid":        {"student"},
+	"GET /students/:id/change-requests": {"admin", "student"},
+
+	// profile change request
+	"GET /change-requests/":                       {"admin"},
+	"PUT /change-requests/:crid":                  {"admin"},
+	"POST /change-requests/:crid/attachments":     {"student"},
+	"GET /change-requests/:crid/attachments/:aid": {"admin", "student"},
+
+	// program transfer
+	"GET /program-transfers/":                   {"admin", "teacher"},
+	"POST /program-transfers/":                  {"admin", "student"},
+	"PUT /program-transfers/:id/faculty-review": {"admin", "teacher"},
+	"PUT /program-transfers/:id":                {"admin"},
+	"GET /students/:id/program-transfers":       {"admin", "student"},
+	"GET /students/:id/degree-audit":            {"admin", "student"},
+
+	// student status
+	"PUT /students/:id/status":                {"admin"},
+	"GET /students/:id/status-history":        {"admin", "student"},
+	"GET /students/:id/status-requests":       {"admin", "student"},
+	"GET /status-requests/":                   {"admin"},
+	"POST /status-requests/":                  {"admin", "student"},
+	"PUT /status-requests/:id":                {"admin"},
+	"POST /status-requests/:id/documents":     {"admin", "student"},
+	"GET /status-requests/:id/documents/:did": {"admin", "student"},
 
 	// teacher
 	//"GET /teachers/":                  "teacher.read",
 	//"GET /teachers/:id":               "teacher.read.self",
-	"PUT /teachers/:id":               {"teacher"},
-	"DELETE /teachers/:id":            {"admin"},
-	"POST /teachers/":                 {"admin"},
+	"PUT /teachers/:id":            {"teacher"},
+	"DELETE /teachers/:id":         {"admin"},
+	"POST /teachers/":              {"admin"},
+	"POST /teachers/import":        {"admin"},
+	"GET /teachers/:id/citizen-id": {"admin"},
+
+	// student card
+	"GET /students/:id/card": {"admin", "student"},
+
+	// student document
+	"POST /students/:id/documents":               {"admin", "student"},
+	"GET /students/:id/documents":                {"admin", "student"},
+	"GET /students/:id/documents/:did":           {"admin", "student"},
+	"GET /students/:id/documents/:did/thumbnail": {"admin", "student"},
+	"PUT /students/:id/documents/:did":           {"admin"},
+	"GET /student-documents/":                    {"admin"},
+
+	// pdpa
+	"GET /students/:id/data-export":   {"admin", "student"},
+	"GET /students/:id/data-requests": {"admin", "student"},
+	"GET /data-requests/":             {"admin"},
+	"POST /data-requests/":            {"admin", "student"},
+	"PUT /data-requests/:id":          {"admin"},
+
+	// audit
+	"GET /audit-events/":              {"admin"},
 	"GET /teachers/:id/subjects":      {"teacher"},
 	"GET /registrations/subjects/:id": {"teacher"},
 	"POST /teachers/grades":           {"teacher"},
 	"POST /teachers/scores":           {"teacher"},
 
+	// advisor approval
+	"GET /teachers/:id/advisees/registrations":      {"teacher", "admin"},
+	"PUT /teachers/:id/advisees/:sid/registrations": {"teacher", "admin"},
+
 	// curriculum
 	"POST /curriculums/":                {"admin"},
 	"PUT /curriculums/:curriculumId":    {"admin"},
@@ -54,18 +110,70 @@ var RoutePermission = map[string][]string{
 	"PUT /subjects/:subjectId/times/:timeId":    {"admin"},
 
 	// bill
-	"GET /bills/:id":                     {"student"},
-	"POST /bills/:id/create":             {"student"},
-	"POST /bills/upload/:id/:year/:term": {"student"},
-	"GET /bills/preview/:id":             {"admin"},
-	"GET /bills/admin/all":               {"admin"},
-	"PUT /bills/:id":                     {"admin"},
+	"GET /bills/:id":                            {"student"},
+	"POST /bills/:id/create":                    {"student"},
+	"POST /bills/upload/:id/:year/:term":        {"student"},
+	"GET /bills/preview/:id":                    {"admin"},
+	"GET /bills/admin/all":                      {"admin"},
+	"PUT /bills/:id":                            {"admin"},
+	"GET /bills/:id/installments":               {"admin", "student"},
+	"POST /bills/:id/installments":              {"admin"},
+	"DELETE /bills/:id/installments":            {"admin"},
+	"PUT /bills/:id/installments/:iid":          {"admin"},
+	"POST /bills/admin/late-fees":               {"admin"},
+	"GET /bills/:id/payments":                   {"admin", "student"},
+	"POST /bills/:id/payments":                  {"admin"},
+	"GET /bills/:id/promptpay":                  {"admin", "student"},
+	"GET /bills/:id/promptpay.png":              {"admin", "student"},
+	"POST /bills/admin/reconcile":               {"admin"},
+	"GET /bills/admin/reconcile":                {"admin"},
+	"PUT /bills/admin/reconcile/:txid":          {"admin"},
+	"GET /bills/:id/receipts":                   {"admin", "student"},
+	"PUT /bills/:id/receipts/:rid":              {"admin"},
+	"GET /bills/:id/official-receipts":          {"admin", "student"},
+	"GET /bills/:id/official-receipts/:rid/pdf": {"admin", "student"},
+	"GET /bills/:id/refunds":                    {"admin", "student"},
+	"POST /bills/:id/refunds":                   {"admin", "student"},
+	"PUT /bills/:id/refunds/:rfid":              {"admin"},
+	"GET /bills/admin/refunds":                  {"admin"},
+	"GET /students/:id/account":                 {"admin", "student"},
+
+	// fee schedule
+	"GET /fee-schedules/":       {"admin"},
+	"GET /fee-schedules/:id":    {"admin"},
+	"POST /fee-schedules/":      {"admin"},
+	"PUT /fee-schedules/:id":    {"admin"},
+	"DELETE /fee-schedules/:id": {"admin"},
+
+	// scholarship
+	"GET /scholarships/":                     {"admin"},
+	"GET /scholarships/:id":                  {"admin"},
+	"POST /scholarships/":                    {"admin"},
+	"PUT /scholarships/:id":                  {"admin"},
+	"DELETE /scholarships/:id":               {"admin"},
+	"POST /scholarships/:id/students":        {"admin"},
+	"DELETE /scholarships/:id/students/:sid": {"admin"},
+	"POST /scholarships/renewals":            {"admin"},
+	"GET /students/:id/scholarships":         {"admin", "student"},
+
+	// finance reports
+	"GET /finance/reports/revenue":    {"admin"},
+	"GET /finance/reports/aging":      {"admin"},
+	"GET /finance/reports/collection": {"admin"},
+
+	// hold
+	"GET /holds/":                  {"admin"},
+	"POST /holds/":                 {"admin"},
+	"PUT /holds/:id/release":       {"admin"},
+	"POST /holds/overdue":          {"admin"},
+	"GET /students/:id/holds":      {"admin", "student"},
+	"GET /students/:id/transcript": {"admin", "student"},
 
 	// graduation
 	"GET /graduations/":    {"admin"},
 	"POST /graduations/":   {"student"},
 	"GET /graduations/:id": {"student"},
-	"PUT /graduations/:id": {"admin" , "student"},
+	"PUT /graduations/:id": {"admin", "student"},
 
 	// registration
 	"GET /registrations/:id":    {"student"},
@@ -73,24 +181,32 @@ var RoutePermission = map[string][]string{
 	"DELETE /registrations/:id": {"student"},
 
 	// report
-	"GET /reports/":              {"admin", "teacher"},
-	"GET /reports/:id":           {"admin", "teacher"},
-	"POST /reports/":             {"student"},
-	"GET /reports/:id/comments":  {"admin", "teacher", "student"},
-	"POST /reports/:id/comments": {"teacher", "admin"},
-	"PUT /reports/:id/status":    {"teacher", "admin"},
+	"GET /reports/":                 {"admin", "teacher"},
+	"GET /reports/:id":              {"admin", "teacher"},
+	"POST /reports/":                {"student"},
+	"GET /reports/:id/comments":     {"admin", "teacher", "student"},
+	"POST /reports/:id/comments":    {"teacher", "admin"},
+	"PUT /reports/:id/status":       {"teacher", "admin", "student"},
+	"GET /reports/:id/history":      {"admin", "teacher", "student"},
+	"POST /reports/:id/attachments": {"student", "admin"},
 
 	// report type
 	//"GET /report-types/"
-	"POST /report-types/":      {"admin"},
-	"DELETE /report-types/:id": {"admin"},
-	"PUT /report-types/:id":    {"admin"},
+	"POST /report-types/":            {"admin"},
+	"DELETE /report-types/:id":       {"admin"},
+	"PUT /report-types/:id":          {"admin"},
+	"PUT /report-types/:id/workflow": {"admin"},
+	"PUT /report-types/:id/form":     {"admin"},
+	"PUT /report-types/:id/actions":  {"admin"},
 
 	// reviewer
 	"GET /reviewers/":                      {"student"},
 	"GET /reviewers/by-username/:username": {"teacher", "student"},
 	"GET /reviewers/:rid/reports":          {"teacher"},
 
+	// major
+	"PUT /majors/:id/head": {"admin"},
+
 	// anyone
 	"GET /majors/":                     {"admin", "student", "teacher"},
 	"GET /faculties/":                  {"admin", "student", "teacher"},
@@ -107,6 +223,9 @@ var RoutePermission = map[string][]string{
 	"GET /report-types/":               {"admin", "student", "teacher"},
 	"GET /teachers/:id":                {"admin", "student", "teacher"},
 	"GET /semesters/":                  {"admin", "student", "teacher"},
+	"PUT /semesters/:id":               {"admin"},
+	"GET /semesters/refund-policies":   {"admin", "student"},
+	"PUT /semesters/refund-policies":   {"admin"},
 }
 
 func PermissionMiddleware() gin.HandlerFunc {
@@ -0,0 +1,12 @@
+package services
+
+import (
+	"reg_system/entity"
+
+	"gorm.io/gorm"
+)
+
+// บันทึกเหตุการณ์สำหรับตรวจสอบ ควรเรียกใน transaction เดียวกับการกระทำ
+func RecordAudit(tx *gorm.DB, e *entity.AuditEvent) error {
+	return tx.Create(e).Error
+}
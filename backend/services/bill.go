@@ -1,17 +1,242 @@
 package services
 
+import (
+	"errors"
+	"time"
+
+	"reg_system/config"
+	"reg_system/entity"
+
+	"gorm.io/gorm"
+)
+
+// ข้อมูลรายวิชาที่ใช้คำนวณค่าใช้จ่าย (ดู CalculateFees ใน fee.go)
 type Subject struct {
 	SubjectID   string
 	SubjectName string
 	Credit      int
 }
 
-// คำนวณค่าใช้จ่ายทั้งหมด
-func CalculateTotalPrice(subject []Subject, ratePerCredit int) int {
-	totalCredit := 0
-	for _, subj := range subject {
-		totalCredit += subj.Credit
+// รายการลงทะเบียนที่อนุมัติแล้วของนักศึกษาใน (ปีการศึกษา, เทอม) อ้างอิงเทอมจากรายวิชา
+func TermRegistrations(tx *gorm.DB, studentID string, year, term int) ([]entity.Registration, error) {
+	var regs []entity.Registration
+	err := tx.Scopes(ApprovedRegistrations).
+		Joins("JOIN subjects ON subjects.subject_id = registrations.subject_id").
+		Joins("JOIN semesters ON semesters.id = subjects.semester_id").
+		Where("registrations.student_id = ? AND semesters.academic_year = ? AND semesters.term = ?", studentID, year, term).
+		Preload("Subject").
+		Find(&regs).Error
+	return regs, err
+}
+
+// ปีการศึกษา/เทอม ของรายการลงทะเบียน (ok = false ถ้ารายวิชาไม่มีเทอม)
+func RegistrationTerm(tx *gorm.DB, reg entity.Registration) (year, term int, ok bool) {
+	var subject entity.Subject
+	if err := tx.Preload("Semester").First(&subject, "subject_id = ?", reg.SubjectID).Error; err != nil {
+		return 0, 0, false
+	}
+	if subject.Semester == nil {
+		return 0, 0, false
+	}
+	return subject.Semester.AcademicYear, subject.Semester.Term, true
+}
+
+// สร้างบิลของ (นักศึกษา, ปีการศึกษา, เทอม) ถ้ายังไม่มี แล้วคำนวณรายการใหม่
+func BuildBill(tx *gorm.DB, studentID string, year, term int) (*entity.Bill, error) {
+	return rebuildBill(tx, studentID, year, term, true)
+}
+
+// คำนวณบิลที่มีอยู่แล้วใหม่ (ใช้ตอนเพิ่ม/ถอนรายวิชา) ถ้ายังไม่มีบิลจะไม่สร้าง คืนค่า nil
+func RefreshBill(tx *gorm.DB, studentID string, year, term int) (*entity.Bill, error) {
+	return rebuildBill(tx, studentID, year, term, false)
+}
+
+// คำนวณบิลใหม่ของเทอมที่รายการลงทะเบียนนี้อยู่
+func RefreshBillForRegistration(tx *gorm.DB, reg entity.Registration) error {
+	year, term, ok := RegistrationTerm(tx, reg)
+	if !ok {
+		return nil
+	}
+	_, err := RefreshBill(tx, reg.StudentID, year, term)
+	return err
+}
+
+// วันที่ใช้เลือกตารางค่าธรรมเนียมของบิล: วันเปิดภาคเรียนของปี/เทอมนั้น
+// ถ้าภาคเรียนไม่ได้กำหนดวันเปิดให้ใช้วันที่ออกบิลเดิม และใช้วันนี้เมื่อยังไม่มีบิล
+// เพื่อไม่ให้การคำนวณบิลใหม่กลางเทอมเปลี่ยนไปใช้อัตราที่ประกาศภายหลัง
+func BillPricingDate(tx *gorm.DB, year, term int, bill *entity.Bill) (time.Time, error) {
+	var semester entity.Semester
+	err := tx.Where("academic_year = ? AND term = ?", year, term).First(&semester).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return time.Time{}, err
+	}
+	if err == nil && semester.StartDate != nil {
+		return *semester.StartDate, nil
+	}
+	if bill != nil && !bill.Date.IsZero() {
+		return bill.Date, nil
+	}
+	return time.Now(), nil
+}
+
+// วันครบกำหนดชำระของบิล บิลที่สร้างก่อนมีคอลัมน์ due_date ใช้วันที่ออกบิล
+func BillDueDate(b entity.Bill) time.Time {
+	if b.DueDate != nil {
+		return *b.DueDate
+	}
+	return b.Date
+}
+
+func rebuildBill(tx *gorm.DB, studentID string, year, term int, create bool) (*entity.Bill, error) {
+	var student entity.Students
+	if err := tx.First(&student, "student_id = ?", studentID).Error; err != nil {
+		return nil, err
+	}
+
+	var bill entity.Bill
+	err := tx.Where("student_id = ? AND academic_year = ? AND term = ?", studentID, year, term).
+		Order("id DESC").First(&bill).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	exists := err == nil
+	if !exists && !create {
+		return nil, nil
+	}
+
+	regs, err := TermRegistrations(tx, studentID, year, term)
+	if err != nil {
+		return nil, err
+	}
+
+	subjects := []Subject{}
+	regBySubject := map[string]int{}
+	for _, reg := range regs {
+		if reg.Subject == nil {
+			continue
+		}
+		subjects = append(subjects, Subject{
+			SubjectID:   reg.SubjectID,
+			SubjectName: reg.Subject.SubjectName,
+			Credit:      reg.Subject.Credit,
+		})
+		regBySubject[reg.SubjectID] = reg.ID
+	}
+
+	var existing *entity.Bill
+	if exists {
+		existing = &bill
+	}
+	pricedAt, err := BillPricingDate(tx, year, term, existing)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := CalculateFees(tx, student, subjects, pricedAt)
+	if err != nil {
+		return nil, err
+	}
+	// การยกเว้นตามคำร้องคำนวณก่อนทุน ทุนแบบร้อยละจึงคิดจากยอดหลังยกเว้น
+	if exists {
+		waivers, err := BillWaiverDiscounts(tx, bill.ID, lines)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, waivers...)
+	}
+	discounts, err := ScholarshipDiscounts(tx, student, year, term, lines)
+	if err != nil {
+		return nil, err
+	}
+	lines = append(lines, discounts...)
+
+	if !exists {
+		issued := time.Now()
+		due := issued.AddDate(0, 0, config.BillDueDays())
+		bill = entity.Bill{
+			StudentID:    studentID,
+			AcademicYear: year,
+			Term:         term,
+			Date:         issued,
+			DueDate:      &due,
+			StatusID:     entity.BillStatusUnpaid,
+		}
+		if err := tx.Create(&bill).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	// ลบเฉพาะรายการที่ระบบคำนวณ รายการที่เจ้าหน้าที่เพิ่มเองคงไว้
+	if err := tx.Where("bill_id = ? AND manual = ?", bill.ID, false).Delete(&entity.BillItem{}).Error; err != nil {
+		return nil, err
+	}
+
+	items := make([]entity.BillItem, 0, len(lines))
+	for _, l := range lines {
+		item := entity.BillItem{
+			BillID:      bill.ID,
+			Type:        l.Type,
+			Description: l.Description,
+			Amount:      l.Amount,
+			SubjectID:   l.SubjectID,
+		}
+		if l.FeeScheduleID != 0 {
+			fsID := l.FeeScheduleID
+			item.FeeScheduleID = &fsID
+		}
+		if l.ScholarshipID != 0 {
+			schID := l.ScholarshipID
+			item.ScholarshipID = &schID
+		}
+		if l.BillWaiverID != 0 {
+			wID := l.BillWaiverID
+			item.BillWaiverID = &wID
+		}
+		if regID, ok := regBySubject[l.SubjectID]; ok && l.SubjectID != "" {
+			item.RegistrationID = &regID
+		}
+		items = append(items, item)
+	}
+	if len(items) > 0 {
+		if err := tx.Create(&items).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Model(&bill).Association("Registration").Replace(regs); err != nil {
+		return nil, err
+	}
+
+	total, err := billItemsTotal(tx, bill.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Model(&bill).Update("total_price", total).Error; err != nil {
+		return nil, err
+	}
+	if err := rebalanceInstallments(tx, bill.ID, total); err != nil {
+		return nil, err
+	}
+	if err := SyncBillStatus(tx, bill.ID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Preload("Items").Preload("Status").Preload("Installments").First(&bill, bill.ID).Error; err != nil {
+		return nil, err
 	}
+	return &bill, nil
+}
 
-	return totalCredit * ratePerCredit
+// ยอดรวมของรายการในบิล (ยอดติดลบของส่วนลดจะถูกหักอัตโนมัติ) ไม่ต่ำกว่า 0
+func billItemsTotal(tx *gorm.DB, billID int) (int, error) {
+	var total int
+	if err := tx.Model(&entity.BillItem{}).
+		Where("bill_id = ?", billID).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&total).Error; err != nil {
+		return 0, err
+	}
+	if total < 0 {
+		total = 0
+	}
+	return total, nil
 }
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"fmt"
+
+	"reg_system/entity"
+
+	"gorm.io/gorm"
+)
+
+// รายการส่วนลดจากการยกเว้นของบิล คิดจากรายการที่คำนวณได้ (lines) รวมกับรายการที่เจ้าหน้าที่เพิ่มเอง เช่น ค่าปรับ
+// การยกเว้นที่ไม่เหลือรายการให้ยกเว้นแล้ว (เช่น ถอนรายวิชาไปแล้ว) จะไม่สร้างส่วนลด
+func BillWaiverDiscounts(tx *gorm.DB, billID int, lines []FeeLine) ([]FeeLine, error) {
+	var waivers []entity.BillWaiver
+	if err := tx.Where("bill_id = ?", billID).Order("id ASC").Find(&waivers).Error; err != nil {
+		return nil, err
+	}
+	if len(waivers) == 0 {
+		return nil, nil
+	}
+
+	var manual []entity.BillItem
+	if err := tx.Where("bill_id = ? AND manual = ? AND amount > 0", billID, true).Find(&manual).Error; err != nil {
+		return nil, err
+	}
+	candidates := make([]FeeLine, 0, len(lines)+len(manual))
+	candidates = append(candidates, lines...)
+	for _, it := range manual {
+		candidates = append(candidates, FeeLine{Type: it.Type, SubjectID: it.SubjectID, Amount: it.Amount})
+	}
+
+	discounts := []FeeLine{}
+	for _, w := range waivers {
+		amount := 0
+		for _, l := range candidates {
+			if l.Type == w.ItemType && l.Amount > 0 && (w.SubjectID == "" || l.SubjectID == w.SubjectID) {
+				amount += l.Amount
+			}
+		}
+		if amount == 0 {
+			continue
+		}
+		discounts = append(discounts, FeeLine{
+			Type:         entity.BillItemDiscount,
+			Description:  billWaiverDescription(w),
+			SubjectID:    w.SubjectID,
+			Amount:       -amount,
+			BillWaiverID: w.ID,
+		})
+	}
+	return discounts, nil
+}
+
+func billWaiverDescription(w entity.BillWaiver) string {
+	if w.SubjectID != "" {
+		return fmt.Sprintf("ยกเว้น %s รายวิชา %s %s", w.ItemType, w.SubjectID, w.Reason)
+	}
+	return fmt.Sprintf("ยกเว้น %s %s", w.ItemType, w.Reason)
+}
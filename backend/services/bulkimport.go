@@ -0,0 +1,518 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"reg_system/config"
+	"reg_system/entity"
+
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+var ErrImportFormat = errors.New("invalid import file")
+
+// การจับคู่คอลัมน์ของไฟล์นำเข้า: ชื่อฟิลด์ -> ชื่อหัวคอลัมน์ในไฟล์
+// ฟิลด์ที่ไม่ได้ระบุจะค้นหัวคอลัมน์ที่ชื่อตรงกับชื่อฟิลด์ (ไม่สนตัวพิมพ์)
+type ImportMapping map[string]string
+
+// ข้อผิดพลาดของแถวในไฟล์ (Row นับแบบเดียวกับในโปรแกรมตารางคำนวณ หัวตาราง = 1)
+type ImportRowError struct {
+	Row   int    `json:"row"`
+	Field string `json:"field,omitempty"`
+	Value string `json:"value,omitempty"`
+	Error string `json:"error"`
+}
+
+// ผลการนำเข้า ถ้า DryRun จะตรวจอย่างเดียวไม่บันทึก
+type ImportResult struct {
+	DryRun  bool             `json:"dry_run"`
+	Rows    int              `json:"rows"`
+	Valid   int              `json:"valid"`
+	Invalid int              `json:"invalid"`
+	Created []string         `json:"created"`
+	Errors  []ImportRowError `json:"errors"`
+
+	// รหัสผ่านเริ่มต้นแบบสุ่มของบัญชีที่สร้าง (username -> password) แสดงครั้งเดียว
+	InitialPasswords map[string]string `json:"initial_passwords,omitempty"`
+}
+
+// อ่านตารางจากไฟล์ CSV หรือ XLSX (ดูจากนามสกุลไฟล์) sheet ว่าง = ชีตแรก
+func ReadImportTable(r io.Reader, fileName, sheet string) ([][]string, error) {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".csv", ".txt":
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		rows, err := reader.ReadAll()
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) > 0 && len(rows[0]) > 0 {
+			rows[0][0] = strings.TrimPrefix(rows[0][0], "\ufeff")
+		}
+		return rows, nil
+	case ".xlsx":
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		f, err := excelize.OpenReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		if sheet == "" {
+			sheet = f.GetSheetName(0)
+		}
+		return f.GetRows(sheet)
+	}
+	return nil, fmt.Errorf("%w: unsupported file type, use .csv or .xlsx", ErrImportFormat)
+}
+
+type importField struct {
+	Name     string
+	Required bool
+}
+
+// แถวข้อมูลหลังจับคู่คอลัมน์แล้ว
+type importRow struct {
+	Row    int
+	Values map[string]string
+}
+
+func mapImportRows(rows [][]string, fields []importField, mapping ImportMapping) ([]importRow, error) {
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: file has no header row", ErrImportFormat)
+	}
+	header := rows[0]
+
+	columns := map[string]int{}
+	missing := []string{}
+	for _, f := range fields {
+		want := f.Name
+		if h, ok := mapping[f.Name]; ok && h != "" {
+			want = h
+		}
+		col := -1
+		for i, h := range header {
+			if strings.EqualFold(strings.TrimSpace(h), want) {
+				col = i
+				break
+			}
+		}
+		if col >= 0 {
+			columns[f.Name] = col
+		} else if f.Required {
+			missing = append(missing, f.Name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: missing required columns: %s", ErrImportFormat, strings.Join(missing, ", "))
+	}
+
+	out := []importRow{}
+	for i, row := range rows[1:] {
+		if strings.TrimSpace(strings.Join(row, "")) == "" {
+			continue
+		}
+		values := map[string]string{}
+		for name, col := range columns {
+			if col < len(row) {
+				values[name] = strings.TrimSpace(row[col])
+			}
+		}
+		out = append(out, importRow{Row: i + 2, Values: values})
+	}
+	return out, nil
+}
+
+// ตรวจฟิลด์บังคับและตัวเลข คืนค่าข้อผิดพลาดของแถว
+func checkImportRow(r importRow, fields []importField, numeric ...string) []ImportRowError {
+	errs := []ImportRowError{}
+	for _, f := range fields {
+		if f.Required && r.Values[f.Name] == "" {
+			errs = append(errs, ImportRowError{Row: r.Row, Field: f.Name, Error: "required"})
+		}
+	}
+	for _, name := range numeric {
+		if v := r.Values[name]; v != "" {
+			if _, err := strconv.Atoi(v); err != nil {
+				errs = append(errs, ImportRowError{Row: r.Row, Field: name, Value: v, Error: "must be a number"})
+			}
+		}
+	}
+	if v := r.Values["CitizenID"]; v != "" && !ValidCitizenID(v) {
+		errs = append(errs, ImportRowError{Row: r.Row, Field: "CitizenID", Value: entity.CitizenID(v).Masked(), Error: "invalid citizen ID checksum"})
+	}
+	return errs
+}
+
+func isNumber(s string) bool {
+	_, err := strconv.Atoi(s)
+	return err == nil
+}
+
+func atoiOrZero(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// ข้อมูลอ้างอิงที่ใช้ตรวจ FK ของทุกแถว (โหลดครั้งเดียว)
+type importLookups struct {
+	faculties   map[string]string // faculty -> รหัสคณะในรหัสนักศึกษา
+	majors      map[string]string // major -> faculty
+	degrees     map[int]string    // degree -> ตัวอักษรนำหน้ารหัสนักศึกษา
+	curriculums map[string]string // curriculum -> major
+	genders     map[int]bool
+	positions   map[int]bool
+	teachers    map[string]bool
+	usernames   map[string]bool
+}
+
+func loadImportLookups(tx *gorm.DB) (*importLookups, error) {
+	l := &importLookups{
+		faculties: map[string]string{}, majors: map[string]string{}, degrees: map[int]string{},
+		curriculums: map[string]string{}, genders: map[int]bool{}, positions: map[int]bool{},
+		teachers: map[string]bool{}, usernames: map[string]bool{},
+	}
+
+	var faculties []entity.Faculty
+	var majors []entity.Majors
+	var degrees []entity.Degree
+	var curriculums []entity.Curriculum
+	var genders []entity.Gender
+	var positions []entity.Position
+	var teacherIDs, usernames []string
+	for _, q := range []*gorm.DB{
+		tx.Find(&faculties), tx.Find(&majors), tx.Find(&degrees), tx.Find(&curriculums),
+		tx.Find(&genders), tx.Find(&positions),
+		tx.Unscoped().Model(&entity.Teachers{}).Pluck("teacher_id", &teacherIDs),
+		tx.Unscoped().Model(&entity.Users{}).Pluck("username", &usernames),
+	} {
+		if q.Error != nil {
+			return nil, q.Error
+		}
+	}
+
+	for _, f := range faculties {
+		l.faculties[f.FacultyID] = f.Code
+	}
+	for _, m := range majors {
+		l.majors[m.MajorID] = m.FacultyID
+	}
+	for _, d := range degrees {
+		l.degrees[d.DegreeID] = d.Prefix
+	}
+	for _, c := range curriculums {
+		l.curriculums[c.CurriculumID] = c.MajorID
+	}
+	for _, g := range genders {
+		l.genders[g.ID] = true
+	}
+	for _, p := range positions {
+		l.positions[p.ID] = true
+	}
+	for _, id := range teacherIDs {
+		l.teachers[id] = true
+	}
+	for _, u := range usernames {
+		l.usernames[u] = true
+	}
+	return l, nil
+}
+
+// ตรวจคณะ/สาขา และสาขาต้องอยู่ในคณะที่ระบุ
+func (l *importLookups) checkFacultyMajor(r importRow) []ImportRowError {
+	errs := []ImportRowError{}
+	facultyID, majorID := r.Values["FacultyID"], r.Values["MajorID"]
+	_, facultyOK := l.faculties[facultyID]
+	if facultyID != "" && !facultyOK {
+		errs = append(errs, ImportRowError{Row: r.Row, Field: "FacultyID", Value: facultyID, Error: "faculty not found"})
+	}
+	if majorID != "" {
+		if f, ok := l.majors[majorID]; !ok {
+			errs = append(errs, ImportRowError{Row: r.Row, Field: "MajorID", Value: majorID, Error: "major not found"})
+		} else if facultyOK && f != facultyID {
+			errs = append(errs, ImportRowError{Row: r.Row, Field: "MajorID", Value: majorID, Error: "major does not belong to faculty " + facultyID})
+		}
+	}
+	if v := r.Values["GenderID"]; v != "" && isNumber(v) && !l.genders[atoiOrZero(v)] {
+		errs = append(errs, ImportRowError{Row: r.Row, Field: "GenderID", Value: v, Error: "gender not found"})
+	}
+	return errs
+}
+
+var studentImportFields = []importField{
+	{"StudentID", false}, {"EntryYear", false}, {"FirstName", true}, {"LastName", true}, {"CitizenID", true},
+	{"Email", false}, {"Phone", false}, {"GenderID", false},
+	{"DegreeID", true}, {"FacultyID", true}, {"MajorID", true}, {"CurriculumID", true},
+	{"AdvisorID", false}, {"Address", false}, {"Nationality", false}, {"Ethnicity", false},
+	{"Religion", false}, {"BirthDay", false}, {"Parent", false},
+}
+
+var teacherImportFields = []importField{
+	{"TeacherID", true}, {"FirstName", true}, {"LastName", true}, {"CitizenID", true},
+	{"Email", false}, {"Phone", false}, {"GenderID", false},
+	{"FacultyID", true}, {"MajorID", true}, {"PositionID", false},
+	{"Address", false}, {"Nationality", false}, {"Ethnicity", false},
+	{"Religion", false}, {"BirthDay", false},
+}
+
+// นำเข้านักศึกษาจากตาราง ตรวจทุกแถวก่อน แล้วบันทึกเฉพาะแถวที่ถูกต้อง (ถ้าไม่ใช่ dry run)
+// แถวที่ไม่มีรหัสนักศึกษาจะได้รหัสจาก GenerateStudentID (ปีที่เข้าศึกษาจากคอลัมน์ EntryYear หรือปีปัจจุบัน)
+// พร้อมสร้างบัญชีผู้ใช้ รหัสผ่านเริ่มต้นเป็นเลขประจำตัวประชาชนเช่นเดียวกับ CreateStudent
+func ImportStudents(tx *gorm.DB, rows [][]string, mapping ImportMapping, dryRun bool) (*ImportResult, error) {
+	records, err := mapImportRows(rows, studentImportFields, mapping)
+	if err != nil {
+		return nil, err
+	}
+	lookups, err := loadImportLookups(tx)
+	if err != nil {
+		return nil, err
+	}
+	var studentIDs, citizenIDs []string // citizenIDs เป็น hash (ดู config.HashPII)
+	if err := tx.Unscoped().Model(&entity.Students{}).Pluck("student_id", &studentIDs).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Unscoped().Model(&entity.Students{}).Pluck("citizen_id_hash", &citizenIDs).Error; err != nil {
+		return nil, err
+	}
+	takenID := map[string]int{}
+	takenCitizen := map[string]int{}
+	for _, id := range studentIDs {
+		takenID[id] = 0
+	}
+	for _, id := range citizenIDs {
+		takenCitizen[id] = 0
+	}
+
+	result := &ImportResult{DryRun: dryRun, Rows: len(records), Created: []string{}, Errors: []ImportRowError{}}
+	valid := []entity.Students{}
+	entryYears := []int{}
+	for _, r := range records {
+		errs := checkImportRow(r, studentImportFields, "GenderID", "DegreeID", "EntryYear")
+		errs = append(errs, lookups.checkFacultyMajor(r)...)
+		v := r.Values
+
+		prefix, degreeOK := lookups.degrees[atoiOrZero(v["DegreeID"])]
+		if d := v["DegreeID"]; d != "" && isNumber(d) && !degreeOK {
+			errs = append(errs, ImportRowError{Row: r.Row, Field: "DegreeID", Value: d, Error: "degree not found"})
+		}
+		if id := v["StudentID"]; id != "" {
+			if err := matchStudentID(id, prefix, lookups.faculties[v["FacultyID"]]); err != nil {
+				errs = append(errs, ImportRowError{Row: r.Row, Field: "StudentID", Value: id, Error: err.Error()})
+			}
+		}
+		if cur := v["CurriculumID"]; cur != "" {
+			if m, ok := lookups.curriculums[cur]; !ok {
+				errs = append(errs, ImportRowError{Row: r.Row, Field: "CurriculumID", Value: cur, Error: "curriculum not found"})
+			} else if v["MajorID"] != "" && m != "" && m != v["MajorID"] {
+				errs = append(errs, ImportRowError{Row: r.Row, Field: "CurriculumID", Value: cur, Error: "curriculum does not belong to major " + v["MajorID"]})
+			}
+		}
+		if a := v["AdvisorID"]; a != "" && !lookups.teachers[a] {
+			errs = append(errs, ImportRowError{Row: r.Row, Field: "AdvisorID", Value: a, Error: "advisor not found"})
+		}
+		if id := v["StudentID"]; id != "" {
+			if row, ok := takenID[id]; ok {
+				errs = append(errs, ImportRowError{Row: r.Row, Field: "StudentID", Value: id, Error: duplicateMessage(row)})
+			} else if lookups.usernames[id] {
+				errs = append(errs, ImportRowError{Row: r.Row, Field: "StudentID", Value: id, Error: "username already exists"})
+			} else {
+				takenID[id] = r.Row
+			}
+		}
+		if cid := v["CitizenID"]; cid != "" {
+			if row, ok := takenCitizen[config.HashPII(cid)]; ok {
+				errs = append(errs, ImportRowError{Row: r.Row, Field: "CitizenID", Value: entity.CitizenID(cid).Masked(), Error: duplicateMessage(row)})
+			} else {
+				takenCitizen[config.HashPII(cid)] = r.Row
+			}
+		}
+
+		if len(errs) > 0 {
+			result.Errors = append(result.Errors, errs...)
+			result.Invalid++
+			continue
+		}
+		entryYear := atoiOrZero(v["EntryYear"])
+		if entryYear == 0 {
+			entryYear = CurrentEntryYear()
+		}
+		entryYears = append(entryYears, entryYear)
+		valid = append(valid, entity.Students{
+			StudentID:       v["StudentID"],
+			FirstName:       v["FirstName"],
+			LastName:        v["LastName"],
+			CitizenID:       entity.CitizenID(v["CitizenID"]),
+			Email:           v["Email"],
+			Phone:           v["Phone"],
+			GenderID:        atoiOrZero(v["GenderID"]),
+			DegreeID:        atoiOrZero(v["DegreeID"]),
+			FacultyID:       v["FacultyID"],
+			MajorID:         v["MajorID"],
+			CurriculumID:    v["CurriculumID"],
+			AdvisorID:       v["AdvisorID"],
+			StatusStudentID: entity.StatusStudying,
+			Address:         v["Address"],
+			Nationality:     v["Nationality"],
+			Ethnicity:       v["Ethnicity"],
+			Religion:        v["Religion"],
+			BirthDay:        v["BirthDay"],
+			Parent:          v["Parent"],
+		})
+	}
+	result.Valid = len(valid)
+	if dryRun {
+		return result, nil
+	}
+
+	for i := range valid {
+		s := &valid[i]
+		if s.StudentID == "" {
+			id, err := GenerateStudentID(tx, s.DegreeID, s.FacultyID, entryYears[i])
+			if err != nil {
+				return nil, err
+			}
+			s.StudentID = id
+		}
+		if err := tx.Create(s).Error; err != nil {
+			return nil, err
+		}
+		if err := createImportUser(tx, result, s.StudentID, "student"); err != nil {
+			return nil, err
+		}
+		result.Created = append(result.Created, s.StudentID)
+	}
+	return result, nil
+}
+
+// นำเข้าอาจารย์จากตาราง แบบเดียวกับ ImportStudents พร้อมสร้าง Reviewer ให้บัญชีอาจารย์
+func ImportTeachers(tx *gorm.DB, rows [][]string, mapping ImportMapping, dryRun bool) (*ImportResult, error) {
+	records, err := mapImportRows(rows, teacherImportFields, mapping)
+	if err != nil {
+		return nil, err
+	}
+	lookups, err := loadImportLookups(tx)
+	if err != nil {
+		return nil, err
+	}
+	var citizenIDs []string // hash (ดู config.HashPII)
+	if err := tx.Unscoped().Model(&entity.Teachers{}).Pluck("citizen_id_hash", &citizenIDs).Error; err != nil {
+		return nil, err
+	}
+	takenID := map[string]int{}
+	takenCitizen := map[string]int{}
+	for id := range lookups.teachers {
+		takenID[id] = 0
+	}
+	for _, id := range citizenIDs {
+		takenCitizen[id] = 0
+	}
+
+	result := &ImportResult{DryRun: dryRun, Rows: len(records), Created: []string{}, Errors: []ImportRowError{}}
+	valid := []entity.Teachers{}
+	for _, r := range records {
+		errs := checkImportRow(r, teacherImportFields, "GenderID", "PositionID")
+		errs = append(errs, lookups.checkFacultyMajor(r)...)
+		v := r.Values
+
+		if p := v["PositionID"]; p != "" && isNumber(p) && !lookups.positions[atoiOrZero(p)] {
+			errs = append(errs, ImportRowError{Row: r.Row, Field: "PositionID", Value: p, Error: "position not found"})
+		}
+		if id := v["TeacherID"]; id != "" {
+			if row, ok := takenID[id]; ok {
+				errs = append(errs, ImportRowError{Row: r.Row, Field: "TeacherID", Value: id, Error: duplicateMessage(row)})
+			} else if lookups.usernames[id] {
+				errs = append(errs, ImportRowError{Row: r.Row, Field: "TeacherID", Value: id, Error: "username already exists"})
+			} else {
+				takenID[id] = r.Row
+			}
+		}
+		if cid := v["CitizenID"]; cid != "" {
+			if row, ok := takenCitizen[config.HashPII(cid)]; ok {
+				errs = append(errs, ImportRowError{Row: r.Row, Field: "CitizenID", Value: entity.CitizenID(cid).Masked(), Error: duplicateMessage(row)})
+			} else {
+				takenCitizen[config.HashPII(cid)] = r.Row
+			}
+		}
+
+		if len(errs) > 0 {
+			result.Errors = append(result.Errors, errs...)
+			result.Invalid++
+			continue
+		}
+		valid = append(valid, entity.Teachers{
+			TeacherID:   v["TeacherID"],
+			FirstName:   v["FirstName"],
+			LastName:    v["LastName"],
+			CitizenID:   entity.CitizenID(v["CitizenID"]),
+			Email:       v["Email"],
+			Phone:       v["Phone"],
+			GenderID:    atoiOrZero(v["GenderID"]),
+			FacultyID:   v["FacultyID"],
+			MajorID:     v["MajorID"],
+			PositionID:  atoiOrZero(v["PositionID"]),
+			Address:     v["Address"],
+			Nationality: v["Nationality"],
+			Ethnicity:   v["Ethnicity"],
+			Religion:    v["Religion"],
+			BirthDay:    v["BirthDay"],
+		})
+	}
+	result.Valid = len(valid)
+	if dryRun {
+		return result, nil
+	}
+
+	for i := range valid {
+		t := &valid[i]
+		if err := tx.Create(t).Error; err != nil {
+			return nil, err
+		}
+		if err := createImportUser(tx, result, t.TeacherID, "teacher"); err != nil {
+			return nil, err
+		}
+		result.Created = append(result.Created, t.TeacherID)
+	}
+	return result, nil
+}
+
+func duplicateMessage(row int) string {
+	if row == 0 {
+		return "already exists"
+	}
+	return fmt.Sprintf("duplicate of row %d", row)
+}
+
+// สร้างบัญชีผู้ใช้ด้วยรหัสผ่านสุ่ม และเก็บรหัสผ่านไว้ในผลการนำเข้าเพื่อแจ้งผู้ใช้
+func createImportUser(tx *gorm.DB, result *ImportResult, username, role string) error {
+	password, err := GenerateInitialPassword()
+	if err != nil {
+		return err
+	}
+	hash, err := config.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	user := entity.Users{Username: username, Password: hash, Role: role}
+	if err := tx.Create(&user).Error; err != nil {
+		return err
+	}
+	if result.InitialPasswords == nil {
+		result.InitialPasswords = map[string]string{}
+	}
+	result.InitialPasswords[username] = password
+	if role == "teacher" {
+		return EnsureReviewer(tx, user.ID)
+	}
+	return nil
+}
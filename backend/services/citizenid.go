@@ -0,0 +1,31 @@
+package services
+
+import (
+	"errors"
+
+	"reg_system/entity"
+)
+
+var ErrInvalidCitizenID = errors.New("invalid citizen ID: must be 13 digits with a valid check digit")
+
+// ตรวจเลขประจำตัวประชาชน 13 หลักตามหลัก check digit
+func ValidCitizenID(id string) bool {
+	if len(id) != 13 {
+		return false
+	}
+	sum := 0
+	for i := 0; i < 13; i++ {
+		if id[i] < '0' || id[i] > '9' {
+			return false
+		}
+		if i < 12 {
+			sum += int(id[i]-'0') * (13 - i)
+		}
+	}
+	return (11-sum%11)%10 == int(id[12]-'0')
+}
+
+// ค่าที่ส่งมาแก้ไขเลขประจำตัวประชาชน ถ้าเป็นค่าเดิมหรือค่าที่ปิดบังไว้ (ฟอร์มส่งค่าที่แสดงกลับมา) ถือว่าไม่เปลี่ยน
+func CitizenIDChanged(current entity.CitizenID, input string) bool {
+	return input != "" && input != string(current) && input != current.Masked()
+}
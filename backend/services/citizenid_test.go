@@ -0,0 +1,23 @@
+package services
+
+import "testing"
+
+func TestValidCitizenID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{"1102900069324", true},
+		{"3567890123451", true},
+		{"1102900069325", false}, // check digit ผิด
+		{"110290006932", false},  // 12 หลัก
+		{"11029000693241", false},
+		{"1102-00069324", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := ValidCitizenID(tt.id); got != tt.want {
+			t.Errorf("ValidCitizenID(%q) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package services
+
+import "github.com/gin-gonic/gin"
+
+// claims ของผู้ใช้ที่เข้าสู่ระบบ (middleware Authorizes เก็บไว้ใน context "user")
+// ถ้าไม่มีคืน JwtClaim ว่าง เพื่อให้ผู้เรียกตรวจ Role/Username ได้โดยไม่ต้องเช็ค nil
+func CurrentClaims(c *gin.Context) *JwtClaim {
+	if claimsI, exists := c.Get("user"); exists {
+		if claims, ok := claimsI.(*JwtClaim); ok {
+			return claims
+		}
+	}
+	return &JwtClaim{}
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"reg_system/entity"
+
+	"gorm.io/gorm"
+)
+
+// ภาคการศึกษาเริ่มแล้วหรือยัง (ภาคที่ไม่ได้กำหนดวันเปิดถือว่าเริ่มแล้ว)
+func semesterStarted(semester entity.Semester, now time.Time) bool {
+	return semester.StartDate == nil || !semester.StartDate.After(now)
+}
+
+// ทำการเปลี่ยนแปลงที่อนุมัติไว้ล่วงหน้าซึ่งภาคการศึกษาที่มีผลเริ่มแล้ว
+func ApplyDueEffectiveChanges(tx *gorm.DB, now time.Time) (int, error) {
+	transfers, err := ApplyDueProgramTransfers(tx, now)
+	if err != nil {
+		return 0, err
+	}
+	statuses, err := ApplyDueStatusRequests(tx, now)
+	if err != nil {
+		return 0, err
+	}
+	return transfers + statuses, nil
+}
+
+// ตรวจการเปลี่ยนแปลงที่ถึงภาคการศึกษาที่มีผลทันทีและทุกรอบ every (เรียกครั้งเดียวตอนเริ่มเซิร์ฟเวอร์)
+func StartEffectiveChangeSweep(db *gorm.DB, every time.Duration) {
+	if every <= 0 {
+		return
+	}
+	sweep := func(now time.Time) {
+		var count int
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			count, err = ApplyDueEffectiveChanges(tx, now)
+			return err
+		})
+		if err != nil {
+			log.Println("effective change sweep failed:", err)
+		} else if count > 0 {
+			log.Println("effective change sweep: applied", count, "changes")
+		}
+	}
+	go func() {
+		sweep(time.Now())
+		ticker := time.NewTicker(every)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			sweep(now)
+		}
+	}()
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"reg_system/entity"
+
+	"gorm.io/gorm"
+)
+
+var ErrNoFeeSchedule = errors.New("no applicable fee schedule")
+
+// รายการค่าใช้จ่าย 1 บรรทัด ที่ได้จากการคำนวณ (Type ตามค่าคงที่ BillItem* ใน entity)
+type FeeLine struct {
+	Type          string `json:"type"`
+	Description   string `json:"description"`
+	SubjectID     string `json:"subject_id,omitempty"`
+	Amount        int    `json:"amount"`
+	FeeScheduleID int    `json:"fee_schedule_id,omitempty"`
+	ScholarshipID int    `json:"scholarship_id,omitempty"`
+	BillWaiverID  int    `json:"bill_waiver_id,omitempty"`
+}
+
+// ปีที่เข้าศึกษา (พ.ศ.) จากรหัสนักศึกษา เช่น B6616052 -> 2566
+// อ่านจาก {YY} ของรูปแบบรหัส (config.StudentIDPattern) ถ้าไม่ตรงรูปแบบใช้ 2 หลักหลังตัวอักษรแรก
+// คืนค่า 0 ถ้ารูปแบบรหัสไม่ตรง
+func StudentEntryYear(studentID string) int {
+	if parts, ok := parseStudentID(studentID); ok && parts["yy"] != "" {
+		yy, _ := strconv.Atoi(parts["yy"])
+		return 2500 + yy
+	}
+	if len(studentID) < 3 {
+		return 0
+	}
+	yy, err := strconv.Atoi(studentID[1:3])
+	if err != nil {
+		return 0
+	}
+	return 2500 + yy
+}
+
+// ตรวจว่าตารางค่าธรรมเนียมใช้กับนักศึกษาคนนี้ได้หรือไม่ คืนค่าระดับความเจาะจง (-1 = ใช้ไม่ได้)
+func feeScheduleSpecificity(fs entity.FeeSchedule, student entity.Students) int {
+	score := 0
+	if fs.DegreeID != nil {
+		if *fs.DegreeID != student.DegreeID {
+			return -1
+		}
+		score++
+	}
+	if fs.FacultyID != nil {
+		if *fs.FacultyID != student.FacultyID {
+			return -1
+		}
+		score++
+	}
+	if fs.CurriculumID != nil {
+		if *fs.CurriculumID != student.CurriculumID {
+			return -1
+		}
+		score++
+	}
+	if fs.EntryYear != nil {
+		if *fs.EntryYear != StudentEntryYear(student.StudentID) {
+			return -1
+		}
+		score++
+	}
+	return score
+}
+
+// ดึงตารางค่าธรรมเนียมที่ใช้กับนักศึกษา ณ วันที่ at เรียงจากเจาะจงมากไปน้อย
+func ApplicableFeeSchedules(db *gorm.DB, student entity.Students, at time.Time) ([]entity.FeeSchedule, error) {
+	var schedules []entity.FeeSchedule
+	if err := db.Preload("LabFees").
+		Where("effective_from <= ?", at).
+		Where("effective_to IS NULL OR effective_to >= ?", at).
+		Find(&schedules).Error; err != nil {
+		return nil, err
+	}
+
+	type scored struct {
+		fs    entity.FeeSchedule
+		score int
+	}
+	var matched []scored
+	for _, fs := range schedules {
+		if s := feeScheduleSpecificity(fs, student); s >= 0 {
+			matched = append(matched, scored{fs, s})
+		}
+	}
+	// ระดับเท่ากันให้ตารางที่เริ่มใช้ล่าสุดมาก่อน
+	sort.SliceStable(matched, func(i, j int) bool {
+		if matched[i].score != matched[j].score {
+			return matched[i].score > matched[j].score
+		}
+		return matched[i].fs.EffectiveFrom.After(matched[j].fs.EffectiveFrom)
+	})
+
+	out := make([]entity.FeeSchedule, 0, len(matched))
+	for _, m := range matched {
+		out = append(out, m.fs)
+	}
+	return out, nil
+}
+
+// คำนวณค่าใช้จ่ายรายเทอมเป็นรายการ (แทน CalculateTotalPrice เดิม)
+// แต่ละองค์ประกอบ (ค่าหน่วยกิต, ค่าธรรมเนียมรายภาค, ค่าแล็บรายวิชา) ใช้ค่าจากตารางที่เจาะจงที่สุดที่กำหนดไว้
+func CalculateFees(db *gorm.DB, student entity.Students, subjects []Subject, at time.Time) ([]FeeLine, error) {
+	schedules, err := ApplicableFeeSchedules(db, student, at)
+	if err != nil {
+		return nil, err
+	}
+	if len(schedules) == 0 {
+		return nil, ErrNoFeeSchedule
+	}
+
+	var rateSchedule, termSchedule *entity.FeeSchedule
+	for i := range schedules {
+		if rateSchedule == nil && schedules[i].RatePerCredit > 0 {
+			rateSchedule = &schedules[i]
+		}
+		if termSchedule == nil && schedules[i].TermFee > 0 {
+			termSchedule = &schedules[i]
+		}
+	}
+
+	lines := []FeeLine{}
+	for _, subj := range subjects {
+		if rateSchedule != nil {
+			lines = append(lines, FeeLine{
+				Type:          entity.BillItemTuition,
+				Description:   fmt.Sprintf("ค่าหน่วยกิต %s %s (%d หน่วยกิต)", subj.SubjectID, subj.SubjectName, subj.Credit),
+				SubjectID:     subj.SubjectID,
+				Amount:        subj.Credit * rateSchedule.RatePerCredit,
+				FeeScheduleID: rateSchedule.ID,
+			})
+		}
+
+		for _, fs := range schedules {
+			found := false
+			for _, lab := range fs.LabFees {
+				if lab.SubjectID == subj.SubjectID {
+					lines = append(lines, FeeLine{
+						Type:          entity.BillItemLabFee,
+						Description:   fmt.Sprintf("ค่าปฏิบัติการ %s %s", subj.SubjectID, subj.SubjectName),
+						SubjectID:     subj.SubjectID,
+						Amount:        lab.Amount,
+						FeeScheduleID: fs.ID,
+					})
+					found = true
+					break
+				}
+			}
+			if found {
+				break
+			}
+		}
+	}
+
+	if termSchedule != nil && len(subjects) > 0 {
+		lines = append(lines, FeeLine{
+			Type:          entity.BillItemTermFee,
+			Description:   "ค่าธรรมเนียมการศึกษารายภาค",
+			Amount:        termSchedule.TermFee,
+			FeeScheduleID: termSchedule.ID,
+		})
+	}
+
+	return lines, nil
+}
+
+// รวมยอดเงินจากรายการค่าใช้จ่าย
+func SumFees(lines []FeeLine) int {
+	total := 0
+	for _, l := range lines {
+		total += l.Amount
+	}
+	return total
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"reg_system/entity"
+)
+
+func TestCalculateFeesSpecificity(t *testing.T) {
+	db := newTestDB(t, &entity.FeeSchedule{}, &entity.LabFee{})
+
+	degree, otherDegree, entryYear := 1, 2, 2566
+	from := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	ended := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	at := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	schedules := []entity.FeeSchedule{
+		// ตารางทั่วไป: ค่าธรรมเนียมรายภาคมาจากตารางนี้เพราะตารางที่เจาะจงกว่าไม่ได้กำหนด
+		{Name: "general", RatePerCredit: 1000, TermFee: 5000, EffectiveFrom: from,
+			LabFees: []entity.LabFee{{SubjectID: "LAB01", Amount: 800}, {SubjectID: "LAB02", Amount: 300}}},
+		// ระดับการศึกษาเดียวกัน ระดับความเจาะจงเท่ากับตารางถัดไป แต่เริ่มใช้ก่อน
+		{Name: "degree old", DegreeID: &degree, RatePerCredit: 1200, EffectiveFrom: from},
+		{Name: "degree", DegreeID: &degree, RatePerCredit: 1500, EffectiveFrom: from.AddDate(1, 0, 0),
+			LabFees: []entity.LabFee{{SubjectID: "LAB01", Amount: 1200}}},
+		// เจาะจงที่สุดแต่ไม่ได้กำหนดค่าใด ๆ ที่ใช้
+		{Name: "degree and year", DegreeID: &degree, EntryYear: &entryYear, EffectiveFrom: from},
+		{Name: "other degree", DegreeID: &otherDegree, RatePerCredit: 9999, TermFee: 9999, EffectiveFrom: from},
+		{Name: "expired", DegreeID: &degree, EntryYear: &entryYear, RatePerCredit: 7777, EffectiveFrom: from, EffectiveTo: &ended},
+	}
+	if err := db.Create(&schedules).Error; err != nil {
+		t.Fatal(err)
+	}
+	ids := map[string]int{}
+	for _, fs := range schedules {
+		ids[fs.Name] = fs.ID
+	}
+
+	student := entity.Students{StudentID: "B6616052", DegreeID: degree}
+	subjects := []Subject{
+		{SubjectID: "LAB01", SubjectName: "Lab One", Credit: 3},
+		{SubjectID: "LAB02", SubjectName: "Lab Two", Credit: 1},
+	}
+	lines, err := CalculateFees(db, student, subjects, at)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []FeeLine{
+		{Type: entity.BillItemTuition, SubjectID: "LAB01", Amount: 4500, FeeScheduleID: ids["degree"]},
+		{Type: entity.BillItemLabFee, SubjectID: "LAB01", Amount: 1200, FeeScheduleID: ids["degree"]},
+		{Type: entity.BillItemTuition, SubjectID: "LAB02", Amount: 1500, FeeScheduleID: ids["degree"]},
+		{Type: entity.BillItemLabFee, SubjectID: "LAB02", Amount: 300, FeeScheduleID: ids["general"]},
+		{Type: entity.BillItemTermFee, Amount: 5000, FeeScheduleID: ids["general"]},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d: %+v", len(lines), len(want), lines)
+	}
+	for i, w := range want {
+		l := lines[i]
+		if l.Type != w.Type || l.SubjectID != w.SubjectID || l.Amount != w.Amount || l.FeeScheduleID != w.FeeScheduleID {
+			t.Errorf("line %d = %+v, want %+v", i, l, w)
+		}
+	}
+	if total := SumFees(lines); total != 12500 {
+		t.Errorf("SumFees = %d, want 12500", total)
+	}
+}
+
+func TestCalculateFeesNoSchedule(t *testing.T) {
+	db := newTestDB(t, &entity.FeeSchedule{}, &entity.LabFee{})
+	_, err := CalculateFees(db, entity.Students{StudentID: "B6616052"}, nil, time.Now())
+	if !errors.Is(err, ErrNoFeeSchedule) {
+		t.Fatalf("err = %v, want ErrNoFeeSchedule", err)
+	}
+}
@@ -0,0 +1,262 @@
+package services
+
+import (
+	"sort"
+	"time"
+
+	"reg_system/entity"
+
+	"gorm.io/gorm"
+)
+
+// ยอดเรียกเก็บเทียบยอดรับชำระ แยกตามเทอม คณะ และสาขา
+type RevenueRow struct {
+	AcademicYear int    `json:"academic_year"`
+	Term         int    `json:"term"`
+	FacultyID    string `json:"faculty_id"`
+	FacultyName  string `json:"faculty_name"`
+	MajorID      string `json:"major_id"`
+	MajorName    string `json:"major_name"`
+	Bills        int    `json:"bills"`
+	Billed       int    `json:"billed"`
+	Collected    int    `json:"collected"`
+	Outstanding  int    `json:"outstanding"`
+}
+
+// ช่วงอายุลูกหนี้ (นับจากวันครบกำหนด)
+const (
+	AgingNotDue = "not_due"
+	Aging0To30  = "0-30"
+	Aging31To60 = "31-60"
+	Aging61To90 = "61-90"
+	AgingOver90 = "90+"
+)
+
+var AgingBuckets = []string{AgingNotDue, Aging0To30, Aging31To60, Aging61To90, AgingOver90}
+
+// ยอดค้างชำระในแต่ละช่วงอายุ
+type AgingRow struct {
+	Bucket   string `json:"bucket"`
+	Bills    int    `json:"bills"`
+	Students int    `json:"students"`
+	Amount   int    `json:"amount"`
+}
+
+// อัตราการเก็บเงินของนักศึกษาแต่ละรุ่น (ปีที่เข้าศึกษา)
+type CollectionRow struct {
+	Cohort    int     `json:"cohort"`
+	Students  int     `json:"students"`
+	Billed    int     `json:"billed"`
+	Collected int     `json:"collected"`
+	Rate      float64 `json:"rate"` // ร้อยละของยอดเรียกเก็บที่รับชำระแล้ว
+}
+
+// บิลพร้อมยอดคงเหลือจากสมุดบัญชี สำหรับรายงาน
+type reportBill struct {
+	entity.Bill
+	Balance BillBalance
+}
+
+func loadReportBills(db *gorm.DB) ([]reportBill, error) {
+	var bills []entity.Bill
+	if err := db.Preload("Student").Preload("Student.Faculty").Preload("Student.Major").
+		Preload("Installments").Find(&bills).Error; err != nil {
+		return nil, err
+	}
+	var payments []entity.Payment
+	if err := db.Find(&payments).Error; err != nil {
+		return nil, err
+	}
+	byBill := map[int][]entity.Payment{}
+	for _, p := range payments {
+		byBill[p.BillID] = append(byBill[p.BillID], p)
+	}
+
+	out := make([]reportBill, 0, len(bills))
+	for _, b := range bills {
+		out = append(out, reportBill{Bill: b, Balance: computeBalance(b, byBill[b.ID])})
+	}
+	return out, nil
+}
+
+// ยอดรับชำระสุทธิที่นับเป็นรายได้ของบิล (ไม่รวมส่วนที่ชำระเกิน)
+func collectedAmount(b BillBalance) int {
+	if b.Net > b.Total {
+		return b.Total
+	}
+	return b.Net
+}
+
+// รายงานยอดเรียกเก็บ/รับชำระ เรียงตามเทอมล่าสุดก่อน
+func RevenueReport(db *gorm.DB) ([]RevenueRow, error) {
+	bills, err := loadReportBills(db)
+	if err != nil {
+		return nil, err
+	}
+
+	type key struct {
+		year, term     int
+		faculty, major string
+	}
+	rows := map[key]*RevenueRow{}
+	for _, b := range bills {
+		k := key{year: b.AcademicYear, term: b.Term}
+		row := &RevenueRow{AcademicYear: b.AcademicYear, Term: b.Term}
+		if s := b.Student; s != nil {
+			k.faculty, k.major = s.FacultyID, s.MajorID
+			row.FacultyID, row.MajorID = s.FacultyID, s.MajorID
+			if s.Faculty != nil {
+				row.FacultyName = s.Faculty.FacultyName
+			}
+			if s.Major != nil {
+				row.MajorName = s.Major.MajorName
+			}
+		}
+		if existing, ok := rows[k]; ok {
+			row = existing
+		} else {
+			rows[k] = row
+		}
+		row.Bills++
+		row.Billed += b.Balance.Total
+		row.Collected += collectedAmount(b.Balance)
+		row.Outstanding += b.Balance.Outstanding
+	}
+
+	out := make([]RevenueRow, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, *r)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.AcademicYear != b.AcademicYear {
+			return a.AcademicYear > b.AcademicYear
+		}
+		if a.Term != b.Term {
+			return a.Term > b.Term
+		}
+		if a.FacultyID != b.FacultyID {
+			return a.FacultyID < b.FacultyID
+		}
+		return a.MajorID < b.MajorID
+	})
+	return out, nil
+}
+
+func agingBucket(due, now time.Time) string {
+	if !due.Before(now) {
+		return AgingNotDue
+	}
+	days := int(now.Sub(due).Hours() / 24)
+	switch {
+	case days <= 30:
+		return Aging0To30
+	case days <= 60:
+		return Aging31To60
+	case days <= 90:
+		return Aging61To90
+	default:
+		return AgingOver90
+	}
+}
+
+// รายงานอายุลูกหนี้ ณ วันที่ now
+// บิลผ่อนชำระนับแต่ละงวดที่ยังไม่ชำระจากวันครบกำหนด บิลปกตินับจากวันที่ออกบิล
+func AgingReport(db *gorm.DB, now time.Time) ([]AgingRow, error) {
+	bills, err := loadReportBills(db)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := map[string]*AgingRow{}
+	for _, name := range AgingBuckets {
+		rows[name] = &AgingRow{Bucket: name}
+	}
+	billSeen := map[string]map[int]bool{}
+	studentSeen := map[string]map[string]bool{}
+	add := func(bucket string, b reportBill, amount int) {
+		if amount <= 0 {
+			return
+		}
+		row := rows[bucket]
+		row.Amount += amount
+		if billSeen[bucket] == nil {
+			billSeen[bucket], studentSeen[bucket] = map[int]bool{}, map[string]bool{}
+		}
+		if !billSeen[bucket][b.ID] {
+			billSeen[bucket][b.ID] = true
+			row.Bills++
+		}
+		if !studentSeen[bucket][b.StudentID] {
+			studentSeen[bucket][b.StudentID] = true
+			row.Students++
+		}
+	}
+
+	for _, b := range bills {
+		if b.Balance.Outstanding == 0 {
+			continue
+		}
+		if len(b.Installments) == 0 {
+			add(agingBucket(BillDueDate(b.Bill), now), b, b.Balance.Outstanding)
+			continue
+		}
+		// ยอดค้างของบิลผ่อนชำระกระจายลงงวดที่ยังไม่ชำระ โดยงวดท้าย ๆ รับยอดค้างก่อน
+		sort.Slice(b.Installments, func(i, j int) bool { return b.Installments[i].Seq > b.Installments[j].Seq })
+		remaining := b.Balance.Outstanding
+		for _, inst := range b.Installments {
+			if remaining == 0 {
+				break
+			}
+			amount := inst.Amount
+			if amount > remaining {
+				amount = remaining
+			}
+			add(agingBucket(inst.DueDate, now), b, amount)
+			remaining -= amount
+		}
+		add(AgingNotDue, b, remaining)
+	}
+
+	out := make([]AgingRow, 0, len(AgingBuckets))
+	for _, name := range AgingBuckets {
+		out = append(out, *rows[name])
+	}
+	return out, nil
+}
+
+// รายงานอัตราการเก็บเงินแยกตามรุ่นของนักศึกษา
+func CollectionReport(db *gorm.DB) ([]CollectionRow, error) {
+	bills, err := loadReportBills(db)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := map[int]*CollectionRow{}
+	students := map[int]map[string]bool{}
+	for _, b := range bills {
+		cohort := StudentEntryYear(b.StudentID)
+		row, ok := rows[cohort]
+		if !ok {
+			row = &CollectionRow{Cohort: cohort}
+			rows[cohort] = row
+			students[cohort] = map[string]bool{}
+		}
+		if !students[cohort][b.StudentID] {
+			students[cohort][b.StudentID] = true
+			row.Students++
+		}
+		row.Billed += b.Balance.Total
+		row.Collected += collectedAmount(b.Balance)
+	}
+
+	out := make([]CollectionRow, 0, len(rows))
+	for _, r := range rows {
+		if r.Billed > 0 {
+			r.Rate = float64(r.Collected*10000/r.Billed) / 100
+		}
+		out = append(out, *r)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Cohort > out[j].Cohort })
+	return out, nil
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"reg_system/config"
+	"reg_system/entity"
+
+	"gorm.io/gorm"
+)
+
+// สิทธิ์ที่ตรวจการระงับ
+const (
+	HoldActionRegistration = "registration"
+	HoldActionTranscript   = "transcript"
+	HoldActionGraduation   = "graduation"
+)
+
+// หน่วยงานและผู้ระงับสิทธิ์อัตโนมัติจากบิลค้างชำระ
+const (
+	HoldOfficeFinance  = "finance"
+	HoldPlacedBySystem = "system"
+)
+
+var (
+	ErrInvalidHoldType  = errors.New("invalid hold type")
+	ErrRegistrationHold = errors.New("registration is blocked by active holds")
+)
+
+// สิทธิ์ที่ถูกระงับตามประเภท (ค่าเริ่มต้นเมื่อสร้าง hold)
+// [ลงทะเบียน, ใบแสดงผลการเรียน, แจ้งจบ]
+var holdDefaultBlocks = map[string][3]bool{
+	entity.HoldFinancial:    {true, true, true},
+	entity.HoldDisciplinary: {true, true, true},
+	entity.HoldLibrary:      {false, true, true},
+	entity.HoldAdvising:     {true, false, false},
+}
+
+// กำหนดสิทธิ์ที่ถูกระงับตามประเภทของ hold
+func ApplyHoldDefaults(h *entity.Hold) error {
+	blocks, ok := holdDefaultBlocks[h.Type]
+	if !ok {
+		return ErrInvalidHoldType
+	}
+	h.BlocksRegistration, h.BlocksTranscript, h.BlocksGraduation = blocks[0], blocks[1], blocks[2]
+	return nil
+}
+
+// hold ที่ยังมีผล: ยังไม่ถูกปลด และยังไม่ถึงวันปลดตามกำหนด
+func activeHolds(db *gorm.DB, now time.Time) *gorm.DB {
+	return db.Where("released_at IS NULL AND (release_date IS NULL OR release_date > ?)", now)
+}
+
+// hold ที่ยังมีผลทั้งหมดของนักศึกษา
+func ActiveHolds(db *gorm.DB, studentID string) ([]entity.Hold, error) {
+	var holds []entity.Hold
+	err := activeHolds(db, time.Now()).Where("student_id = ?", studentID).Order("placed_at ASC").Find(&holds).Error
+	return holds, err
+}
+
+// hold ที่ระงับสิทธิ์ action ของนักศึกษา (ว่าง = ทำรายการได้)
+func BlockingHolds(db *gorm.DB, studentID, action string) ([]entity.Hold, error) {
+	q := activeHolds(db, time.Now()).Where("student_id = ?", studentID)
+	switch action {
+	case HoldActionRegistration:
+		q = q.Where("blocks_registration = ?", true)
+	case HoldActionTranscript:
+		q = q.Where("blocks_transcript = ?", true)
+	case HoldActionGraduation:
+		q = q.Where("blocks_graduation = ?", true)
+	default:
+		return nil, fmt.Errorf("unknown hold action %q", action)
+	}
+	var holds []entity.Hold
+	err := q.Order("placed_at ASC").Find(&holds).Error
+	return holds, err
+}
+
+// ปลด hold 1 รายการ
+func ReleaseHold(tx *gorm.DB, h *entity.Hold, releasedBy, note string) error {
+	now := time.Now()
+	h.ReleasedAt = &now
+	h.ReleasedBy = releasedBy
+	h.ReleaseNote = note
+	return tx.Save(h).Error
+}
+
+// ปลด hold ค้างชำระที่ผูกกับบิล (เรียกเมื่อบิลชำระครบ)
+func ReleaseBillHolds(tx *gorm.DB, billID int) error {
+	now := time.Now()
+	return tx.Model(&entity.Hold{}).
+		Where("bill_id = ? AND type = ? AND released_at IS NULL", billID, entity.HoldFinancial).
+		Updates(map[string]interface{}{
+			"released_at":  now,
+			"released_by":  HoldPlacedBySystem,
+			"release_note": "bill paid",
+		}).Error
+}
+
+// ระงับสิทธิ์นักศึกษาที่มีบิลค้างชำระเกินกำหนด (HOLD_OVERDUE_DAYS)
+// บิลผ่อนชำระดูจากวันครบกำหนดของงวด บิลปกติดูจากวันครบกำหนดของบิล
+// (บิลเก่าที่ไม่มี due_date ใช้วันที่ออกบิล) บิลละไม่เกิน 1 hold
+func PlaceOverdueHolds(tx *gorm.DB, now time.Time) ([]entity.Hold, error) {
+	cutoff := now.AddDate(0, 0, -config.HoldOverdueDays())
+
+	var billIDs []int
+	if err := tx.Model(&entity.Installment{}).
+		Where("status_id = ? AND due_date < ?", entity.BillStatusUnpaid, cutoff).
+		Distinct().Pluck("bill_id", &billIDs).Error; err != nil {
+		return nil, err
+	}
+	var plain []int
+	if err := tx.Model(&entity.Bill{}).
+		Where("status_id = ? AND COALESCE(due_date, date) < ? AND total_price > 0", entity.BillStatusUnpaid, cutoff).
+		Where("NOT EXISTS (SELECT 1 FROM installments WHERE installments.bill_id = bills.id)").
+		Pluck("id", &plain).Error; err != nil {
+		return nil, err
+	}
+	billIDs = append(billIDs, plain...)
+	if len(billIDs) == 0 {
+		return nil, nil
+	}
+
+	var bills []entity.Bill
+	if err := tx.Where("id IN ?", billIDs).Order("id ASC").Find(&bills).Error; err != nil {
+		return nil, err
+	}
+
+	var placed []entity.Hold
+	for _, bill := range bills {
+		var count int64
+		if err := activeHolds(tx.Model(&entity.Hold{}), now).
+			Where("bill_id = ? AND type = ?", bill.ID, entity.HoldFinancial).
+			Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			continue
+		}
+
+		reason := fmt.Sprintf("ค้างชำระบิลเลขที่ %d", bill.ID)
+		if bill.AcademicYear > 0 {
+			reason += fmt.Sprintf(" ภาคการศึกษา %d/%d", bill.Term, bill.AcademicYear)
+		}
+		billID := bill.ID
+		h := entity.Hold{
+			StudentID: bill.StudentID,
+			Type:      entity.HoldFinancial,
+			Reason:    reason,
+			Office:    HoldOfficeFinance,
+			BillID:    &billID,
+			PlacedBy:  HoldPlacedBySystem,
+			PlacedAt:  now,
+		}
+		if err := ApplyHoldDefaults(&h); err != nil {
+			return nil, err
+		}
+		if err := tx.Create(&h).Error; err != nil {
+			return nil, err
+		}
+		placed = append(placed, h)
+	}
+	return placed, nil
+}
@@ -0,0 +1,250 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"reg_system/config"
+	"reg_system/entity"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInstallmentTotal   = errors.New("installment amounts must add up to the bill total")
+	ErrInstallmentLocked  = errors.New("installment plan already has submitted, paid or late-fee installments, or payments recorded against it")
+	ErrInstallmentSettled = errors.New("installment has nothing left to pay")
+)
+
+// งวดที่ขอแบ่ง (ยอดเงิน + วันครบกำหนด)
+type InstallmentLine struct {
+	Amount  int
+	DueDate time.Time
+}
+
+// แบ่งยอดเท่า ๆ กันเป็น count งวด เศษบวกไว้ที่งวดแรก
+func SplitInstallments(total, count int, firstDue time.Time, intervalDays int) []InstallmentLine {
+	if count < 1 {
+		count = 1
+	}
+	per := total / count
+	lines := make([]InstallmentLine, count)
+	for i := range lines {
+		lines[i] = InstallmentLine{
+			Amount:  per,
+			DueDate: firstDue.AddDate(0, 0, i*intervalDays),
+		}
+	}
+	lines[0].Amount += total - per*count
+	return lines
+}
+
+// สร้าง (หรือแทนที่) แผนผ่อนชำระของบิล ยอดรวมทุกงวดต้องเท่ากับยอดบิล
+// แทนที่ได้เฉพาะเมื่อแผนเดิมยังไม่ถูกใช้ (ดู checkInstallmentPlanUnused)
+func CreateInstallmentPlan(tx *gorm.DB, bill entity.Bill, lines []InstallmentLine) ([]entity.Installment, error) {
+	if len(lines) == 0 {
+		return nil, errors.New("at least one installment is required")
+	}
+	sum := 0
+	for i, l := range lines {
+		if l.Amount <= 0 {
+			return nil, fmt.Errorf("installment %d amount must be greater than 0", i+1)
+		}
+		if i > 0 && !l.DueDate.After(lines[i-1].DueDate) {
+			return nil, errors.New("due dates must be in ascending order")
+		}
+		sum += l.Amount
+	}
+	if sum != bill.TotalPrice {
+		return nil, ErrInstallmentTotal
+	}
+
+	if err := checkInstallmentPlanUnused(tx, bill.ID); err != nil {
+		return nil, err
+	}
+	if err := tx.Where("bill_id = ?", bill.ID).Delete(&entity.Installment{}).Error; err != nil {
+		return nil, err
+	}
+
+	installments := make([]entity.Installment, 0, len(lines))
+	for i, l := range lines {
+		installments = append(installments, entity.Installment{
+			BillID:   bill.ID,
+			Seq:      i + 1,
+			Amount:   l.Amount,
+			DueDate:  l.DueDate,
+			StatusID: entity.BillStatusUnpaid,
+		})
+	}
+	if err := tx.Create(&installments).Error; err != nil {
+		return nil, err
+	}
+	if err := SyncBillStatus(tx, bill.ID); err != nil {
+		return nil, err
+	}
+	return installments, nil
+}
+
+// แผนผ่อนชำระเดิมลบ/แทนที่ได้เฉพาะเมื่อไม่มีงวดที่ส่งหลักฐาน ชำระแล้ว หรือถูกคิดค่าปรับ
+// และไม่มีรายการรับ/คืนเงินที่อ้างถึงงวดเดิม (ถ้าลบงวด ยอดที่ผูกกับงวดนั้นจะหายจากการกระจายยอด)
+func checkInstallmentPlanUnused(tx *gorm.DB, billID int) error {
+	var locked int64
+	if err := tx.Model(&entity.Installment{}).
+		Where("bill_id = ? AND (status_id <> ? OR late_fee > 0)", billID, entity.BillStatusUnpaid).
+		Count(&locked).Error; err != nil {
+		return err
+	}
+	if locked > 0 {
+		return ErrInstallmentLocked
+	}
+	var referenced int64
+	if err := tx.Model(&entity.Payment{}).
+		Where("installment_id IN (?)", tx.Model(&entity.Installment{}).Select("id").Where("bill_id = ?", billID)).
+		Count(&referenced).Error; err != nil {
+		return err
+	}
+	if referenced > 0 {
+		return ErrInstallmentLocked
+	}
+	return nil
+}
+
+// ยกเลิกแผนผ่อนชำระ (เฉพาะเมื่อแผนยังไม่ถูกใช้ ดู checkInstallmentPlanUnused)
+func DeleteInstallmentPlan(tx *gorm.DB, billID int) error {
+	if err := checkInstallmentPlanUnused(tx, billID); err != nil {
+		return err
+	}
+	return tx.Where("bill_id = ?", billID).Delete(&entity.Installment{}).Error
+}
+
+// ปรับงวดให้ยอดรวมตรงกับยอดบิลหลังคำนวณบิลใหม่ (เพิ่ม/ถอนรายวิชา, ทุน)
+// ส่วนต่างไปลงที่งวดสุดท้ายที่ยังไม่ชำระ ไล่ย้อนไปงวดก่อนหน้าถ้ายอดติดลบ
+// ถ้ายอดเพิ่มแต่ไม่มีงวดที่ยังไม่ชำระเหลือ จะสร้างงวดใหม่สำหรับส่วนที่เหลือ
+// ส่วนที่ลดลงเกินงวดที่ยังไม่ชำระจะกลายเป็นยอดชำระเกิน (ขอคืนเงินได้ตาม LedgerBalance)
+func rebalanceInstallments(tx *gorm.DB, billID, total int) error {
+	var installments []entity.Installment
+	if err := tx.Where("bill_id = ?", billID).Order("seq ASC").Find(&installments).Error; err != nil {
+		return err
+	}
+	if len(installments) == 0 {
+		return nil
+	}
+
+	diff := total
+	for _, inst := range installments {
+		diff -= inst.Amount
+	}
+	for i := len(installments) - 1; i >= 0 && diff != 0; i-- {
+		inst := installments[i]
+		if inst.StatusID != entity.BillStatusUnpaid {
+			continue
+		}
+		amount := inst.Amount + diff
+		if amount < 0 {
+			amount = 0
+		}
+		diff -= amount - inst.Amount
+		if err := tx.Model(&inst).Update("amount", amount).Error; err != nil {
+			return err
+		}
+	}
+	if diff > 0 {
+		last := installments[len(installments)-1]
+		due := time.Now()
+		if last.DueDate.After(due) {
+			due = last.DueDate
+		}
+		return tx.Create(&entity.Installment{
+			BillID:   billID,
+			Seq:      last.Seq + 1,
+			Amount:   diff,
+			DueDate:  due.AddDate(0, 0, config.InstallmentTopUpDueDays()),
+			StatusID: entity.BillStatusUnpaid,
+		}).Error
+	}
+	return nil
+}
+
+// รันการคิดค่าปรับทุกบิลตามรอบ every (เรียกครั้งเดียวตอนเริ่มเซิร์ฟเวอร์)
+func StartLateFeeSweep(db *gorm.DB, every time.Duration) {
+	if every <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(every)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			var count int
+			err := db.Transaction(func(tx *gorm.DB) error {
+				var err error
+				count, err = ApplyLateFees(tx, "", now)
+				return err
+			})
+			if err != nil {
+				log.Println("late fee sweep failed:", err)
+			} else if count > 0 {
+				log.Println("late fee sweep: applied to", count, "installments")
+			}
+		}
+	}()
+}
+
+// คิดค่าปรับงวดที่เลยวันครบกำหนดเกินระยะผ่อนผัน (งวดละครั้ง)
+// ค่าปรับบันทึกเป็นรายการ penalty ในบิล (manual เพื่อไม่ให้หายตอนคำนวณบิลใหม่) และบวกเข้ายอดของงวดนั้น
+// studentID ว่าง = ตรวจทุกบิล
+func ApplyLateFees(tx *gorm.DB, studentID string, now time.Time) (int, error) {
+	fee := config.LateFeeAmount()
+	if fee <= 0 {
+		return 0, nil
+	}
+	cutoff := now.AddDate(0, 0, -config.LateFeeGraceDays())
+
+	q := tx.Model(&entity.Installment{}).
+		Joins("JOIN bills ON bills.id = installments.bill_id").
+		Where("installments.status_id = ? AND installments.late_fee = 0 AND installments.due_date < ?", entity.BillStatusUnpaid, cutoff)
+	if studentID != "" {
+		q = q.Where("bills.student_id = ?", studentID)
+	}
+	var overdue []entity.Installment
+	if err := q.Find(&overdue).Error; err != nil {
+		return 0, err
+	}
+
+	bills := map[int]bool{}
+	for _, inst := range overdue {
+		item := entity.BillItem{
+			BillID:      inst.BillID,
+			Type:        entity.BillItemPenalty,
+			Description: fmt.Sprintf("ค่าปรับชำระล่าช้า งวดที่ %d (ครบกำหนด %s)", inst.Seq, inst.DueDate.Format("2006-01-02")),
+			Amount:      fee,
+			Manual:      true,
+		}
+		if err := tx.Create(&item).Error; err != nil {
+			return 0, err
+		}
+		if err := tx.Model(&inst).Updates(map[string]interface{}{
+			"amount":          inst.Amount + fee,
+			"late_fee":        fee,
+			"penalty_item_id": item.ID,
+		}).Error; err != nil {
+			return 0, err
+		}
+		bills[inst.BillID] = true
+	}
+
+	for billID := range bills {
+		total, err := billItemsTotal(tx, billID)
+		if err != nil {
+			return 0, err
+		}
+		if err := tx.Model(&entity.Bill{}).Where("id = ?", billID).Update("total_price", total).Error; err != nil {
+			return 0, err
+		}
+		if err := SyncBillStatus(tx, billID); err != nil {
+			return 0, err
+		}
+	}
+	return len(overdue), nil
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"reg_system/entity"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ปีงบประมาณ (พ.ศ.) ของวันที่ t ปีงบประมาณเริ่ม 1 ตุลาคมของปีก่อนหน้า
+func FiscalYear(t time.Time) int {
+	year := t.Year()
+	if t.Month() >= time.October {
+		year++
+	}
+	return year + 543
+}
+
+// ขอเลขที่ใบเสร็จถัดไปของปีงบประมาณ ต้องเรียกภายใน transaction เดียวกับการบันทึกใบเสร็จ
+// ถ้า transaction ถูกยกเลิก เลขที่จะถูกยกเลิกไปด้วย จึงไม่มีเลขขาดช่วง
+func nextReceiptSeq(tx *gorm.DB, fiscalYear int) (int, error) {
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&entity.ReceiptSequence{FiscalYear: fiscalYear}).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Model(&entity.ReceiptSequence{}).Where("fiscal_year = ?", fiscalYear).
+		Update("last_number", gorm.Expr("last_number + 1")).Error; err != nil {
+		return 0, err
+	}
+	var seq entity.ReceiptSequence
+	if err := tx.First(&seq, "fiscal_year = ?", fiscalYear).Error; err != nil {
+		return 0, err
+	}
+	return seq.LastNumber, nil
+}
+
+// ออกใบเสร็จรับเงินสำหรับรายการรับชำระ 1 รายการ (ถ้าออกไปแล้วจะคืนใบเดิม)
+func IssueOfficialReceipt(tx *gorm.DB, p entity.Payment) (*entity.OfficialReceipt, error) {
+	var existing entity.OfficialReceipt
+	err := tx.Where("payment_id = ?", p.ID).Limit(1).Find(&existing).Error
+	if err != nil {
+		return nil, err
+	}
+	if existing.ID != 0 {
+		return &existing, nil
+	}
+
+	var bill entity.Bill
+	if err := tx.Preload("Student").Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).First(&bill, p.BillID).Error; err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	fy := FiscalYear(now)
+	seq, err := nextReceiptSeq(tx, fy)
+	if err != nil {
+		return nil, err
+	}
+
+	r := entity.OfficialReceipt{
+		ReceiptNo:    fmt.Sprintf("RC%d-%06d", fy, seq),
+		FiscalYear:   fy,
+		Seq:          seq,
+		PaymentID:    p.ID,
+		BillID:       bill.ID,
+		StudentID:    bill.StudentID,
+		AcademicYear: bill.AcademicYear,
+		Term:         bill.Term,
+		BillTotal:    bill.TotalPrice,
+		Amount:       p.Amount,
+		Method:       p.Method,
+		Reference:    p.PaymentID,
+		ApprovedBy:   p.RecordedBy,
+		PaidAt:       p.PaidAt,
+		IssuedAt:     now,
+	}
+	if bill.Student != nil {
+		r.StudentName = strings.TrimSpace(bill.Student.FirstName + " " + bill.Student.LastName)
+	}
+	for _, it := range bill.Items {
+		r.Lines = append(r.Lines, entity.OfficialReceiptLine{
+			Type:        it.Type,
+			Description: it.Description,
+			SubjectID:   it.SubjectID,
+			Amount:      it.Amount,
+		})
+	}
+	if p.InstallmentID != nil {
+		var inst entity.Installment
+		if err := tx.First(&inst, *p.InstallmentID).Error; err != nil {
+			return nil, err
+		}
+		r.InstallmentSeq = inst.Seq
+	}
+
+	if err := tx.Create(&r).Error; err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
@@ -0,0 +1,25 @@
+package services
+
+import (
+	"crypto/rand"
+	"math/big"
+)
+
+// ไม่ใช้ตัวอักษรที่อ่านสับสนง่าย (0/O, 1/l/I)
+const initialPasswordChars = "abcdefghijkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+const initialPasswordLength = 12
+
+// รหัสผ่านเริ่มต้นแบบสุ่มของบัญชีใหม่ แจ้งผู้สร้างบัญชีครั้งเดียวในผลลัพธ์ ระบบเก็บเฉพาะ hash
+func GenerateInitialPassword() (string, error) {
+	b := make([]byte, initialPasswordLength)
+	max := big.NewInt(int64(len(initialPasswordChars)))
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = initialPasswordChars[n.Int64()]
+	}
+	return string(b), nil
+}
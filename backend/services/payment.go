@@ -0,0 +1,354 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"reg_system/entity"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidPayment     = errors.New("invalid payment")
+	ErrInsufficientCredit = errors.New("amount exceeds the bill's available credit")
+)
+
+// ยอดคงเหลือของบิลที่คำนวณจากสมุดบัญชีรับเงิน
+type BillBalance struct {
+	BillID      int `json:"bill_id"`
+	Total       int `json:"total"`       // ยอดบิล
+	Paid        int `json:"paid"`        // รับชำระ + เครดิตที่นำมาใช้
+	Refunded    int `json:"refunded"`    // คืนเงิน + เครดิตที่โอนออก
+	Net         int `json:"net"`         // Paid - Refunded
+	Outstanding int `json:"outstanding"` // ยอดค้างชำระ
+	Credit      int `json:"credit"`      // ยอดชำระเกิน (คืนได้หรือโอนไปบิลอื่นได้)
+}
+
+// ยอดรวมของรายการในสมุดบัญชีตามทิศทางเงิน
+func ledgerSign(paymentType string) int {
+	switch paymentType {
+	case entity.PaymentTypePayment, entity.PaymentTypeCredit:
+		return 1
+	case entity.PaymentTypeRefund, entity.PaymentTypeCreditTransfer:
+		return -1
+	}
+	return 0
+}
+
+// คำนวณยอดคงเหลือของบิลจากรายการรับ/คืนเงิน
+func LedgerBalance(tx *gorm.DB, billID int) (BillBalance, error) {
+	var bill entity.Bill
+	if err := tx.First(&bill, billID).Error; err != nil {
+		return BillBalance{}, err
+	}
+	var payments []entity.Payment
+	if err := tx.Where("bill_id = ?", billID).Find(&payments).Error; err != nil {
+		return BillBalance{}, err
+	}
+	return computeBalance(bill, payments), nil
+}
+
+func computeBalance(bill entity.Bill, payments []entity.Payment) BillBalance {
+	b := BillBalance{BillID: bill.ID, Total: bill.TotalPrice}
+	for _, p := range payments {
+		if ledgerSign(p.Type) > 0 {
+			b.Paid += p.Amount
+		} else {
+			b.Refunded += p.Amount
+		}
+	}
+	b.Net = b.Paid - b.Refunded
+	if b.Net < b.Total {
+		b.Outstanding = b.Total - b.Net
+	} else {
+		b.Credit = b.Net - b.Total
+	}
+	return b
+}
+
+// บันทึกรายการรับ/คืนเงิน แล้วคำนวณสถานะบิลใหม่
+// คืนเงินหรือโอนเครดิตออกได้ไม่เกินยอดชำระเกินของบิล
+func RecordPayment(tx *gorm.DB, p *entity.Payment) error {
+	if p.Amount <= 0 || ledgerSign(p.Type) == 0 {
+		return ErrInvalidPayment
+	}
+	if ledgerSign(p.Type) < 0 {
+		bal, err := LedgerBalance(tx, p.BillID)
+		if err != nil {
+			return err
+		}
+		if p.Amount > bal.Credit {
+			return ErrInsufficientCredit
+		}
+	}
+	if p.InstallmentID != nil {
+		var count int64
+		if err := tx.Model(&entity.Installment{}).
+			Where("id = ? AND bill_id = ?", *p.InstallmentID, p.BillID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return fmt.Errorf("%w: installment does not belong to bill", ErrInvalidPayment)
+		}
+	}
+
+	if p.PaymentID == "" {
+		p.PaymentID = "PAY-" + strings.ToUpper(uuid.NewString()[:8])
+	}
+	if p.PaidAt.IsZero() {
+		p.PaidAt = time.Now()
+	}
+	if err := tx.Create(p).Error; err != nil {
+		return err
+	}
+	// เงินที่รับเข้าจริงต้องออกใบเสร็จในธุรกรรมเดียวกัน เพื่อไม่ให้เลขที่ใบเสร็จขาดช่วง
+	if p.Type == entity.PaymentTypePayment {
+		if _, err := IssueOfficialReceipt(tx, *p); err != nil {
+			return err
+		}
+	}
+	return SyncBillStatus(tx, p.BillID)
+}
+
+// นำเครดิตจากบิล fromBillID ไปชำระบิล toBillID (บันทึกทั้งสองฝั่ง)
+func TransferCredit(tx *gorm.DB, fromBillID, toBillID, amount int, recordedBy string) error {
+	if fromBillID == toBillID {
+		return fmt.Errorf("%w: cannot transfer credit to the same bill", ErrInvalidPayment)
+	}
+	var from, to entity.Bill
+	if err := tx.First(&from, fromBillID).Error; err != nil {
+		return err
+	}
+	if err := tx.First(&to, toBillID).Error; err != nil {
+		return err
+	}
+	if from.StudentID != to.StudentID {
+		return fmt.Errorf("%w: bills belong to different students", ErrInvalidPayment)
+	}
+
+	now := time.Now()
+	out := entity.Payment{
+		BillID:        fromBillID,
+		RelatedBillID: &toBillID,
+		Type:          entity.PaymentTypeCreditTransfer,
+		Method:        entity.PaymentMethodCredit,
+		Amount:        amount,
+		PayerID:       from.StudentID,
+		RecordedBy:    recordedBy,
+		PaidAt:        now,
+	}
+	if err := RecordPayment(tx, &out); err != nil {
+		return err
+	}
+	in := entity.Payment{
+		BillID:        toBillID,
+		RelatedBillID: &fromBillID,
+		Type:          entity.PaymentTypeCredit,
+		Method:        entity.PaymentMethodCredit,
+		Amount:        amount,
+		Reference:     out.PaymentID,
+		PayerID:       to.StudentID,
+		RecordedBy:    recordedBy,
+		PaidAt:        now,
+	}
+	return RecordPayment(tx, &in)
+}
+
+// สถานะบิลคำนวณจากสมุดบัญชีรับเงิน:
+// ชำระครบ = ชำระแล้ว, มีหลักฐานรอตรวจสอบ = รอตรวจสอบ, นอกนั้น = ค้างชำระ
+// บิลที่ผ่อนชำระจะกระจายยอดที่รับแล้วลงแต่ละงวดด้วย
+func SyncBillStatus(tx *gorm.DB, billID int) error {
+	var bill entity.Bill
+	if err := tx.First(&bill, billID).Error; err != nil {
+		return err
+	}
+	var payments []entity.Payment
+	if err := tx.Where("bill_id = ?", billID).Find(&payments).Error; err != nil {
+		return err
+	}
+	bal := computeBalance(bill, payments)
+
+	pending, err := allocateInstallments(tx, billID, payments)
+	if err != nil {
+		return err
+	}
+
+	status := entity.BillStatusUnpaid
+	switch {
+	case bal.Outstanding == 0:
+		status = entity.BillStatusPaid
+	case pending == nil && bill.StatusID == entity.BillStatusPending:
+		// บิลชำระเต็มจำนวนที่ส่งหลักฐานแล้ว ยังรอเจ้าหน้าที่ตรวจ
+		status = entity.BillStatusPending
+	case pending != nil && *pending:
+		status = entity.BillStatusPending
+	}
+	if err := tx.Model(&entity.Bill{}).Where("id = ?", billID).Update("status_id", status).Error; err != nil {
+		return err
+	}
+	// ชำระครบแล้วปลดการระงับสิทธิ์ที่เกิดจากบิลนี้
+	if status == entity.BillStatusPaid {
+		return ReleaseBillHolds(tx, billID)
+	}
+	return nil
+}
+
+// กระจายยอดรับชำระลงงวด: รายการที่ระบุงวดลงงวดนั้นก่อน ส่วนที่เหลือไล่ลงตามลำดับงวด
+// คืนค่า nil ถ้าบิลไม่มีงวด มิฉะนั้นคืนว่ามีงวดที่รอตรวจสอบหรือไม่
+func allocateInstallments(tx *gorm.DB, billID int, payments []entity.Payment) (*bool, error) {
+	var installments []entity.Installment
+	if err := tx.Where("bill_id = ?", billID).Order("seq ASC").Find(&installments).Error; err != nil {
+		return nil, err
+	}
+	if len(installments) == 0 {
+		return nil, nil
+	}
+	coverage := installmentCoverage(installments, payments)
+
+	pending := false
+	now := time.Now()
+	for _, inst := range installments {
+		covered := coverage[inst.ID]
+		status := inst.StatusID
+		if covered >= inst.Amount {
+			status = entity.BillStatusPaid
+		} else if status == entity.BillStatusPaid {
+			// เคยชำระครบแต่ยอดถูกคืน/ปรับเพิ่มภายหลัง
+			status = entity.BillStatusUnpaid
+		}
+		if status == entity.BillStatusPending {
+			pending = true
+		}
+		if status != inst.StatusID {
+			updates := map[string]interface{}{"status_id": status, "paid_at": nil}
+			if status == entity.BillStatusPaid {
+				updates["paid_at"] = now
+			}
+			if err := tx.Model(&inst).Updates(updates).Error; err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &pending, nil
+}
+
+// ยอดที่ชำระแล้วของแต่ละงวด (installments เรียงตามลำดับงวด)
+// รายการที่ระบุงวดลงงวดนั้นก่อน (ส่วนที่เกินยอดงวดคืนเข้ากองกลาง) ยอดกองกลางไล่ลงตามลำดับงวด
+func installmentCoverage(installments []entity.Installment, payments []entity.Payment) map[int]int {
+	assigned := map[int]int{}
+	pool := 0
+	for _, p := range payments {
+		amount := ledgerSign(p.Type) * p.Amount
+		if amount > 0 && p.InstallmentID != nil {
+			assigned[*p.InstallmentID] += amount
+		} else {
+			pool += amount
+		}
+	}
+	for _, inst := range installments {
+		if over := assigned[inst.ID] - inst.Amount; over > 0 {
+			assigned[inst.ID] = inst.Amount
+			pool += over
+		}
+	}
+	for _, inst := range installments {
+		if need := inst.Amount - assigned[inst.ID]; need > 0 && pool > 0 {
+			take := need
+			if pool < take {
+				take = pool
+			}
+			assigned[inst.ID] += take
+			pool -= take
+		}
+	}
+	return assigned
+}
+
+// ยอดที่ยังต้องชำระของงวด หลังกระจายยอดรับชำระทั้งบิลแบบเดียวกับ SyncBillStatus
+func InstallmentRemaining(tx *gorm.DB, inst entity.Installment) (int, error) {
+	var installments []entity.Installment
+	if err := tx.Where("bill_id = ?", inst.BillID).Order("seq ASC").Find(&installments).Error; err != nil {
+		return 0, err
+	}
+	var payments []entity.Payment
+	if err := tx.Where("bill_id = ?", inst.BillID).Find(&payments).Error; err != nil {
+		return 0, err
+	}
+	remaining := inst.Amount - installmentCoverage(installments, payments)[inst.ID]
+	if remaining < 0 {
+		remaining = 0
+	}
+	return remaining, nil
+}
+
+// อนุมัติหลักฐานของงวด: บันทึกรับชำระเท่ายอดที่ยังขาดของงวดนั้น (InstallmentRemaining)
+// ถ้างวดไม่มียอดค้างแล้วคืน ErrInstallmentSettled
+func ApproveInstallment(tx *gorm.DB, inst entity.Installment, recordedBy string) error {
+	var bill entity.Bill
+	if err := tx.First(&bill, inst.BillID).Error; err != nil {
+		return err
+	}
+
+	// ยอดคงเหลือเดียวกับที่ใช้สร้าง QR และจับคู่รายการเดินบัญชี (หักยอดกองกลาง เช่น เครดิตที่โอนมา)
+	remaining, err := InstallmentRemaining(tx, inst)
+	if err != nil {
+		return err
+	}
+	if remaining <= 0 {
+		return ErrInstallmentSettled
+	}
+
+	instID := inst.ID
+	return RecordPayment(tx, &entity.Payment{
+		BillID:        inst.BillID,
+		InstallmentID: &instID,
+		Type:          entity.PaymentTypePayment,
+		Method:        entity.PaymentMethodTransfer,
+		Amount:        remaining,
+		Reference:     inst.FilePath,
+		PayerID:       bill.StudentID,
+		RecordedBy:    recordedBy,
+	})
+}
+
+// อนุมัติหลักฐานการชำระของบิล: บิลผ่อนชำระอนุมัติงวดที่รอตรวจสอบ
+// บิลชำระเต็มจำนวนบันทึกรับชำระเท่ายอดค้าง
+func ApproveBillReceipt(tx *gorm.DB, bill entity.Bill, recordedBy string) error {
+	var installments []entity.Installment
+	if err := tx.Where("bill_id = ?", bill.ID).Order("seq ASC").Find(&installments).Error; err != nil {
+		return err
+	}
+	if len(installments) > 0 {
+		for _, inst := range installments {
+			if inst.StatusID != entity.BillStatusPending {
+				continue
+			}
+			if err := ApproveInstallment(tx, inst, recordedBy); err != nil {
+				return err
+			}
+		}
+		return SyncBillStatus(tx, bill.ID)
+	}
+
+	bal, err := LedgerBalance(tx, bill.ID)
+	if err != nil {
+		return err
+	}
+	if bal.Outstanding == 0 {
+		return SyncBillStatus(tx, bill.ID)
+	}
+	return RecordPayment(tx, &entity.Payment{
+		BillID:     bill.ID,
+		Type:       entity.PaymentTypePayment,
+		Method:     entity.PaymentMethodTransfer,
+		Amount:     bal.Outstanding,
+		Reference:  bill.FilePath,
+		PayerID:    bill.StudentID,
+		RecordedBy: recordedBy,
+	})
+}
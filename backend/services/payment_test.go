@@ -0,0 +1,178 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"reg_system/entity"
+)
+
+func intPtr(v int) *int { return &v }
+
+func TestComputeBalance(t *testing.T) {
+	bill := entity.Bill{ID: 7, TotalPrice: 10000}
+	tests := []struct {
+		name     string
+		payments []entity.Payment
+		want     BillBalance
+	}{
+		{"unpaid", nil, BillBalance{BillID: 7, Total: 10000, Outstanding: 10000}},
+		{
+			"partially paid with credit",
+			[]entity.Payment{
+				{Type: entity.PaymentTypePayment, Amount: 4000},
+				{Type: entity.PaymentTypeCredit, Amount: 1000},
+			},
+			BillBalance{BillID: 7, Total: 10000, Paid: 5000, Net: 5000, Outstanding: 5000},
+		},
+		{
+			"overpaid then partly refunded",
+			[]entity.Payment{
+				{Type: entity.PaymentTypePayment, Amount: 12000},
+				{Type: entity.PaymentTypeRefund, Amount: 500},
+				{Type: entity.PaymentTypeCreditTransfer, Amount: 500},
+			},
+			BillBalance{BillID: 7, Total: 10000, Paid: 12000, Refunded: 1000, Net: 11000, Credit: 1000},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := computeBalance(bill, tt.payments); got != tt.want {
+				t.Fatalf("computeBalance = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInstallmentCoverage(t *testing.T) {
+	installments := []entity.Installment{
+		{ID: 1, Seq: 1, Amount: 3000},
+		{ID: 2, Seq: 2, Amount: 3000},
+		{ID: 3, Seq: 3, Amount: 4000},
+	}
+	tests := []struct {
+		name     string
+		payments []entity.Payment
+		want     map[int]int
+	}{
+		{
+			"unassigned payments fill installments in order",
+			[]entity.Payment{{Type: entity.PaymentTypePayment, Amount: 4000}},
+			map[int]int{1: 3000, 2: 1000},
+		},
+		{
+			"assigned payment goes to its installment first",
+			[]entity.Payment{
+				{Type: entity.PaymentTypePayment, Amount: 4000, InstallmentID: intPtr(3)},
+				{Type: entity.PaymentTypePayment, Amount: 1000},
+			},
+			map[int]int{1: 1000, 3: 4000},
+		},
+		{
+			"overpayment of an installment spills into the pool",
+			[]entity.Payment{{Type: entity.PaymentTypePayment, Amount: 5000, InstallmentID: intPtr(2)}},
+			map[int]int{1: 2000, 2: 3000},
+		},
+		{
+			"refunds reduce the pool",
+			[]entity.Payment{
+				{Type: entity.PaymentTypePayment, Amount: 6000},
+				{Type: entity.PaymentTypeRefund, Amount: 2000},
+			},
+			map[int]int{1: 3000, 2: 1000},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := installmentCoverage(installments, tt.payments)
+			for id, v := range got {
+				if v == 0 {
+					delete(got, id)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("installmentCoverage = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllocateInstallments(t *testing.T) {
+	db := newTestDB(t, &entity.Installment{})
+
+	if pending, err := allocateInstallments(db, 1, nil); err != nil || pending != nil {
+		t.Fatalf("bill without installments = (%v, %v), want (nil, nil)", pending, err)
+	}
+
+	due := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	installments := []entity.Installment{
+		{BillID: 1, Seq: 1, Amount: 3000, DueDate: due, StatusID: entity.BillStatusUnpaid},
+		{BillID: 1, Seq: 2, Amount: 3000, DueDate: due.AddDate(0, 1, 0), StatusID: entity.BillStatusPending},
+		{BillID: 1, Seq: 3, Amount: 4000, DueDate: due.AddDate(0, 2, 0), StatusID: entity.BillStatusPaid},
+	}
+	if err := db.Create(&installments).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	payments := []entity.Payment{{BillID: 1, Type: entity.PaymentTypePayment, Amount: 4000}}
+	pending, err := allocateInstallments(db, 1, payments)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pending == nil || !*pending {
+		t.Fatalf("pending = %v, want true", pending)
+	}
+
+	var got []entity.Installment
+	if err := db.Order("seq ASC").Find(&got).Error; err != nil {
+		t.Fatal(err)
+	}
+	wantStatus := []int{entity.BillStatusPaid, entity.BillStatusPending, entity.BillStatusUnpaid}
+	for i, inst := range got {
+		if inst.StatusID != wantStatus[i] {
+			t.Errorf("installment %d status = %d, want %d", inst.Seq, inst.StatusID, wantStatus[i])
+		}
+	}
+	if got[0].PaidAt == nil || got[2].PaidAt != nil {
+		t.Errorf("paid_at = %v, %v; want set on the paid installment only", got[0].PaidAt, got[2].PaidAt)
+	}
+}
+
+func TestApproveInstallmentRecordsRemainder(t *testing.T) {
+	db := newTestDB(t, &entity.Bill{}, &entity.Installment{}, &entity.Payment{},
+		&entity.ReceiptSequence{}, &entity.OfficialReceipt{}, &entity.Hold{})
+
+	bill := entity.Bill{StudentID: "B6616052", AcademicYear: 2568, Term: 1, TotalPrice: 6000, StatusID: entity.BillStatusUnpaid}
+	if err := db.Create(&bill).Error; err != nil {
+		t.Fatal(err)
+	}
+	due := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	installments := []entity.Installment{
+		{BillID: bill.ID, Seq: 1, Amount: 3000, DueDate: due, StatusID: entity.BillStatusPending},
+		{BillID: bill.ID, Seq: 2, Amount: 3000, DueDate: due.AddDate(0, 1, 0), StatusID: entity.BillStatusUnpaid},
+	}
+	if err := db.Create(&installments).Error; err != nil {
+		t.Fatal(err)
+	}
+	// เครดิตที่โอนมาไม่ได้ระบุงวด จึงเข้ากองกลางและครอบคลุมงวดแรกบางส่วน
+	if err := db.Create(&entity.Payment{PaymentID: "PAY-CREDIT", BillID: bill.ID, Type: entity.PaymentTypeCredit, Amount: 1000}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ApproveInstallment(db, installments[0], "admin"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var recorded entity.Payment
+	if err := db.Where("installment_id = ?", installments[0].ID).First(&recorded).Error; err != nil {
+		t.Fatal(err)
+	}
+	if recorded.Amount != 2000 {
+		t.Fatalf("recorded amount = %d, want 2000", recorded.Amount)
+	}
+
+	if err := ApproveInstallment(db, installments[0], "admin"); !errors.Is(err, ErrInstallmentSettled) {
+		t.Fatalf("second approval err = %v, want ErrInstallmentSettled", err)
+	}
+}
@@ -0,0 +1,447 @@
+package services
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"reg_system/entity"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrErasureScope     = errors.New("unknown erasure scope")
+	ErrDataRequestOpen  = errors.New("student already has a pending erasure request")
+	ErrDataRequestState = errors.New("data request is not pending")
+)
+
+// ฟิลด์ของ Students (ชื่อตาม JSON เดียวกับ profileFields) ที่ลบได้ตามขอบเขตคำขอ
+var erasureScopeFields = map[string][]string{
+	entity.ErasureScopeContact:     {"Email", "Phone", "Address", "Parent"},
+	entity.ErasureScopeSensitive:   {"Nationality", "Ethnicity", "Religion", "BirthDay"},
+	entity.ErasureScopeAttachments: nil,
+}
+
+// ข้อมูลที่ไม่ลบแม้มีคำขอ เพราะมีหน้าที่ตามกฎหมายต้องเก็บไว้
+var retainedStudentData = []entity.RetainedData{
+	{Item: "identity", Reason: "ชื่อ-สกุล เลขประจำตัวประชาชน และรหัสนักศึกษา ใช้ยืนยันตัวตนในเอกสารทางการศึกษา"},
+	{Item: "academic", Reason: "การลงทะเบียน ผลการเรียน ประวัติสถานภาพ และการสำเร็จการศึกษา ต้องเก็บถาวรเพื่อออกใบแสดงผลการศึกษา"},
+	{Item: "financial", Reason: "บิล การชำระเงิน ใบเสร็จ และหลักฐานการชำระ ต้องเก็บตามกฎหมายการบัญชีและภาษีอากร"},
+	{Item: "documents", Reason: "รูปถ่ายใช้ยืนยันตัวตนบนบัตรนักศึกษา และใบแสดงผลการเรียนระดับก่อนหน้าเป็นหลักฐานคุณสมบัติการเข้าศึกษา (สำเนาบัตรประชาชนและทะเบียนบ้านถูกลบในขอบเขตไฟล์แนบ)"},
+}
+
+// เอกสารนักศึกษาที่ลบได้ในขอบเขตไฟล์แนบ ใช้ตรวจตอนรับเข้าเท่านั้น หลังตรวจแล้วไม่จำเป็นต้องเก็บสำเนา
+var erasableStudentDocuments = []string{entity.DocumentIDCard, entity.DocumentHouseRegistration}
+
+// ไฟล์ที่แนบมากับข้อมูลส่งออก
+type ExportFile struct {
+	Category     string `json:"Category"`
+	Name         string `json:"Name"` // ตำแหน่งใน ZIP
+	OriginalName string `json:"OriginalName"`
+
+	path string
+}
+
+// ข้อมูลนักศึกษาในสำเนาข้อมูล เลขประจำตัวประชาชนเต็มหรือปิดบังตามสิทธิผู้ขอ (ดู BuildStudentExport)
+type ExportProfile struct {
+	entity.Students
+	CitizenID string `json:"CitizenID"`
+}
+
+// ข้อมูลส่วนบุคคลทั้งหมดที่ผูกกับรหัสนักศึกษา
+type StudentDataExport struct {
+	GeneratedAt time.Time `json:"GeneratedAt"`
+	StudentID   string    `json:"StudentID"`
+
+	Profile               ExportProfile                 `json:"Profile"`
+	Registrations         []entity.Registration         `json:"Registrations"`
+	Grades                []entity.Grades               `json:"Grades"`
+	Scores                []entity.Scores               `json:"Scores"`
+	Bills                 []entity.Bill                 `json:"Bills"`
+	Payments              []entity.Payment              `json:"Payments"`
+	ReceiptSubmissions    []entity.ReceiptSubmission    `json:"ReceiptSubmissions"`
+	OfficialReceipts      []entity.OfficialReceipt      `json:"OfficialReceipts"`
+	Refunds               []entity.RefundRequest        `json:"Refunds"`
+	Scholarships          []entity.StudentScholarship   `json:"Scholarships"`
+	Holds                 []entity.Hold                 `json:"Holds"`
+	Reports               []entity.Report               `json:"Reports"`
+	Graduations           []entity.Graduation           `json:"Graduations"`
+	StatusHistory         []entity.StudentStatusLog     `json:"StatusHistory"`
+	StatusRequests        []entity.StatusRequest        `json:"StatusRequests"`
+	ProfileChangeRequests []entity.ProfileChangeRequest `json:"ProfileChangeRequests"`
+	ProgramTransfers      []entity.ProgramTransfer      `json:"ProgramTransfers"`
+	Documents             []entity.StudentDocument      `json:"Documents"`
+	DataRequests          []entity.DataSubjectRequest   `json:"DataRequests"`
+
+	Files []ExportFile `json:"Files"`
+}
+
+func (e *StudentDataExport) addFile(category, stored, original string) {
+	if stored == "" {
+		return
+	}
+	base := filepath.Base(stored)
+	e.Files = append(e.Files, ExportFile{
+		Category:     category,
+		Name:         "files/" + category + "/" + base,
+		OriginalName: original,
+		path:         filepath.Join(UploadDir, base),
+	})
+}
+
+// รวบรวมข้อมูลส่วนบุคคลทั้งหมดของนักศึกษา (สิทธิขอเข้าถึงข้อมูลตาม PDPA)
+// revealCitizenID = ใส่เลขประจำตัวประชาชนเต็ม มิฉะนั้นใส่ค่าที่ปิดบังไว้
+func BuildStudentExport(db *gorm.DB, studentID string, revealCitizenID bool) (*StudentDataExport, error) {
+	exp := &StudentDataExport{GeneratedAt: time.Now(), StudentID: studentID}
+
+	var student entity.Students
+	if err := db.Preload("Gender").Preload("Degree").Preload("Faculty").Preload("Major").
+		Preload("StatusStudent").Preload("Curriculum").
+		First(&student, "student_id = ?", studentID).Error; err != nil {
+		return nil, err
+	}
+	exp.Profile = ExportProfile{Students: student, CitizenID: student.CitizenID.Masked()}
+	if revealCitizenID {
+		exp.Profile.CitizenID = string(student.CitizenID)
+	}
+
+	byStudent := func(dest interface{}, order string) error {
+		return db.Where("student_id = ?", studentID).Order(order).Find(dest).Error
+	}
+	queries := []struct {
+		dest  interface{}
+		order string
+	}{
+		{&exp.Grades, "id"},
+		{&exp.Scores, "id"},
+		{&exp.OfficialReceipts, "id"},
+		{&exp.Refunds, "id"},
+		{&exp.Scholarships, "id"},
+		{&exp.Holds, "id"},
+		{&exp.Graduations, "id"},
+		{&exp.StatusHistory, "id"},
+		{&exp.Documents, "type, version"},
+		{&exp.DataRequests, "id"},
+	}
+	for _, q := range queries {
+		if err := byStudent(q.dest, q.order); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := db.Preload("Subject").Where("student_id = ?", studentID).Order("id").
+		Find(&exp.Registrations).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Preload("Items").Preload("Installments").Where("student_id = ?", studentID).
+		Order("academic_year, term").Find(&exp.Bills).Error; err != nil {
+		return nil, err
+	}
+
+	billIDs := make([]int, 0, len(exp.Bills))
+	for _, b := range exp.Bills {
+		billIDs = append(billIDs, b.ID)
+	}
+	if len(billIDs) > 0 {
+		if err := db.Where("bill_id IN ?", billIDs).Order("id").Find(&exp.Payments).Error; err != nil {
+			return nil, err
+		}
+		if err := db.Where("bill_id IN ?", billIDs).Order("id").Find(&exp.ReceiptSubmissions).Error; err != nil {
+			return nil, err
+		}
+	}
+	for _, d := range exp.Documents {
+		exp.addFile("documents", d.FileName, d.OriginalName)
+	}
+	for _, r := range exp.ReceiptSubmissions {
+		exp.addFile("receipts", r.FilePath, r.OriginalName)
+	}
+
+	if err := db.Preload("ReportType").Preload("Attachments").Where("student_id = ?", studentID).
+		Order("created_at").Find(&exp.Reports).Error; err != nil {
+		return nil, err
+	}
+	for _, r := range exp.Reports {
+		for _, a := range r.Attachments {
+			exp.addFile("reports", a.File_Path, a.File_Name)
+		}
+	}
+
+	if err := db.Preload("Documents").Where("student_id = ?", studentID).
+		Order("requested_at").Find(&exp.StatusRequests).Error; err != nil {
+		return nil, err
+	}
+	for _, r := range exp.StatusRequests {
+		for _, d := range r.Documents {
+			exp.addFile("status-requests", d.FileName, d.OriginalName)
+		}
+	}
+
+	if err := db.Preload("Attachments").Where("student_id = ?", studentID).
+		Order("requested_at").Find(&exp.ProfileChangeRequests).Error; err != nil {
+		return nil, err
+	}
+	for i := range exp.ProfileChangeRequests {
+		cr := &exp.ProfileChangeRequests[i]
+		// ค่าที่ขอแก้ไขของฟิลด์ข้อมูลส่วนบุคคลเก็บแบบเข้ารหัส ถอดให้เจ้าของข้อมูลอ่านได้
+		for j, ch := range cr.Changes {
+			if v, err := profileChangeValue(ch); err == nil {
+				cr.Changes[j].After = v
+			}
+		}
+		for _, a := range cr.Attachments {
+			exp.addFile("change-requests", a.FileName, a.OriginalName)
+		}
+	}
+
+	if err := db.Preload("CreditMappings").Where("student_id = ?", studentID).
+		Order("requested_at").Find(&exp.ProgramTransfers).Error; err != nil {
+		return nil, err
+	}
+
+	return exp, nil
+}
+
+// เขียนข้อมูลส่งออกเป็น ZIP: data.json และไฟล์แนบทั้งหมดในโฟลเดอร์ files/
+// ไฟล์ที่หาไม่พบบนดิสก์จะถูกข้าม
+func WriteStudentExportZip(w io.Writer, exp *StudentDataExport) error {
+	zw := zip.NewWriter(w)
+
+	data, err := zw.Create("data.json")
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(data)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(exp); err != nil {
+		return err
+	}
+
+	for _, f := range exp.Files {
+		src, err := os.Open(f.path)
+		if err != nil {
+			continue
+		}
+		dst, err := zw.Create(f.Name)
+		if err == nil {
+			_, err = io.Copy(dst, src)
+		}
+		src.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// ยื่นคำขอลบข้อมูลส่วนบุคคล
+func CreateErasureRequest(tx *gorm.DB, studentID string, scopes []string, reason, requestedBy string) (*entity.DataSubjectRequest, error) {
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("%w: at least one scope is required", ErrErasureScope)
+	}
+	for _, s := range scopes {
+		if _, ok := erasureScopeFields[s]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrErasureScope, s)
+		}
+	}
+
+	var student entity.Students
+	if err := tx.First(&student, "student_id = ?", studentID).Error; err != nil {
+		return nil, err
+	}
+
+	var open int64
+	if err := tx.Model(&entity.DataSubjectRequest{}).
+		Where("student_id = ? AND type = ? AND status = ?", studentID, entity.DataRequestErasure, entity.DataRequestPending).
+		Count(&open).Error; err != nil {
+		return nil, err
+	}
+	if open > 0 {
+		return nil, ErrDataRequestOpen
+	}
+
+	r := &entity.DataSubjectRequest{
+		StudentID:   studentID,
+		Type:        entity.DataRequestErasure,
+		Scopes:      scopes,
+		Reason:      reason,
+		Status:      entity.DataRequestPending,
+		RequestedBy: requestedBy,
+		RequestedAt: time.Now(),
+	}
+	return r, tx.Create(r).Error
+}
+
+// บันทึกการส่งออกข้อมูลให้เจ้าของข้อมูล (คำขอเข้าถึงข้อมูลที่ดำเนินการทันที)
+func RecordDataAccess(tx *gorm.DB, studentID, requestedBy string) (*entity.DataSubjectRequest, error) {
+	now := time.Now()
+	r := &entity.DataSubjectRequest{
+		StudentID:   studentID,
+		Type:        entity.DataRequestAccess,
+		Status:      entity.DataRequestCompleted,
+		RequestedBy: requestedBy,
+		RequestedAt: now,
+		CompletedAt: &now,
+	}
+	return r, tx.Create(r).Error
+}
+
+// ลบข้อมูลตามขอบเขตที่ระบุ ข้อมูลการศึกษา/การเงินคงไว้ตาม retainedStudentData
+// คืนค่ารายชื่อไฟล์ที่ต้องลบออกจากดิสก์ (ให้ลบหลัง commit สำเร็จ)
+func EraseStudentData(tx *gorm.DB, studentID string, scopes []string) (*entity.DataErasureResult, []string, error) {
+	result := &entity.DataErasureResult{Retained: retainedStudentData}
+	var files []string
+
+	columns := map[string]interface{}{}
+	erasedFields := map[string]bool{}
+	for _, s := range scopes {
+		for _, field := range erasureScopeFields[s] {
+			columns[profileFields[field].Column] = ""
+			erasedFields[field] = true
+			result.Erased = append(result.Erased, field)
+		}
+	}
+	if len(columns) > 0 {
+		if err := tx.Model(&entity.Students{}).Where("student_id = ?", studentID).Updates(columns).Error; err != nil {
+			return nil, nil, err
+		}
+
+		// ค่าเดิม/ค่าใหม่ในคำขอแก้ไขข้อมูลก็เป็นข้อมูลเดียวกัน ต้องลบด้วย
+		var crs []entity.ProfileChangeRequest
+		if err := tx.Where("student_id = ?", studentID).Find(&crs).Error; err != nil {
+			return nil, nil, err
+		}
+		for _, cr := range crs {
+			changed := false
+			for i, ch := range cr.Changes {
+				if erasedFields[ch.Field] {
+					cr.Changes[i].Before, cr.Changes[i].After = "", ""
+					changed = true
+				}
+			}
+			if changed {
+				if err := tx.Model(&cr).Select("Changes").Updates(&entity.ProfileChangeRequest{Changes: cr.Changes}).Error; err != nil {
+					return nil, nil, err
+				}
+			}
+		}
+	}
+
+	if containsScope(scopes, entity.ErasureScopeAttachments) {
+		var err error
+		if files, err = eraseStudentAttachments(tx, studentID); err != nil {
+			return nil, nil, err
+		}
+		result.Erased = append(result.Erased, "Attachments")
+	}
+	result.Files = len(files)
+	return result, files, nil
+}
+
+// ล้างไฟล์แนบของรายงาน คำขอแก้ไขข้อมูล คำร้องสถานภาพ และสำเนาบัตรประชาชน/ทะเบียนบ้าน
+// (หลักฐานการชำระเงินเป็นเอกสารทางการเงิน ไม่ลบ)
+func eraseStudentAttachments(tx *gorm.DB, studentID string) ([]string, error) {
+	var files []string
+
+	var atts []entity.Attachment
+	if err := tx.Joins("JOIN reports ON reports.report_id = attachments.report_id").
+		Where("reports.student_id = ? AND attachments.file_path <> ''", studentID).Find(&atts).Error; err != nil {
+		return nil, err
+	}
+	for _, a := range atts {
+		files = append(files, a.File_Path)
+		if err := tx.Model(&entity.Attachment{}).Where("attachment_id = ?", a.Attachment_id).
+			Updates(map[string]interface{}{"file_path": "", "file_name": ""}).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	var cas []entity.ProfileChangeAttachment
+	if err := tx.Joins("JOIN profile_change_requests ON profile_change_requests.id = profile_change_attachments.change_request_id").
+		Where("profile_change_requests.student_id = ? AND profile_change_attachments.file_name <> ''", studentID).Find(&cas).Error; err != nil {
+		return nil, err
+	}
+	for _, a := range cas {
+		files = append(files, a.FileName)
+		if err := tx.Model(&a).Updates(map[string]interface{}{"file_name": "", "original_name": ""}).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	var docs []entity.StatusRequestDocument
+	if err := tx.Joins("JOIN status_requests ON status_requests.id = status_request_documents.status_request_id").
+		Where("status_requests.student_id = ? AND status_request_documents.file_name <> ''", studentID).Find(&docs).Error; err != nil {
+		return nil, err
+	}
+	for _, d := range docs {
+		files = append(files, d.FileName)
+		if err := tx.Model(&d).Updates(map[string]interface{}{"file_name": "", "original_name": ""}).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	// คงเรคคอร์ดและสถานะการตรวจไว้เป็นประวัติว่าเคยยื่นและตรวจแล้ว ลบเฉพาะไฟล์
+	var studentDocs []entity.StudentDocument
+	if err := tx.Where("student_id = ? AND type IN ? AND file_name <> ''", studentID, erasableStudentDocuments).
+		Find(&studentDocs).Error; err != nil {
+		return nil, err
+	}
+	for _, d := range studentDocs {
+		files = append(files, d.FileName)
+		if err := tx.Model(&d).Updates(map[string]interface{}{"file_name": "", "original_name": ""}).Error; err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// ลบไฟล์ออกจากโฟลเดอร์ uploads (ไฟล์ที่ไม่มีอยู่แล้วถือว่าสำเร็จ)
+func RemoveUploads(files []string) {
+	for _, f := range files {
+		if err := os.Remove(filepath.Join(UploadDir, filepath.Base(f))); err != nil && !os.IsNotExist(err) {
+			log.Println("cannot remove upload", f, err)
+		}
+	}
+}
+
+func containsScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// อนุมัติ (ดำเนินการลบ) หรือปฏิเสธคำขอลบข้อมูล
+func ReviewErasureRequest(tx *gorm.DB, r *entity.DataSubjectRequest, approve bool, reason, reviewer string) ([]string, error) {
+	if r.Status != entity.DataRequestPending {
+		return nil, ErrDataRequestState
+	}
+	now := time.Now()
+	r.ReviewedBy = reviewer
+	r.ReviewedAt = &now
+
+	var files []string
+	if approve {
+		result, removed, err := EraseStudentData(tx, r.StudentID, r.Scopes)
+		if err != nil {
+			return nil, err
+		}
+		files = removed
+		r.Status = entity.DataRequestCompleted
+		r.Result = result
+		r.CompletedAt = &now
+	} else {
+		r.Status = entity.DataRequestRejected
+		r.RejectReason = reason
+	}
+	return files, tx.Save(r).Error
+}
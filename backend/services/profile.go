@@ -0,0 +1,277 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"reg_system/config"
+	"reg_system/entity"
+
+	"gorm.io/gorm"
+)
+
+// นโยบายการแก้ไขข้อมูลนักศึกษาด้วยตนเอง
+const (
+	ProfileFieldDirect    = "direct"    // ข้อมูลติดต่อ แก้ไขได้ทันที
+	ProfileFieldApproval  = "approval"  // ข้อมูลประจำตัว/การศึกษา ต้องยื่นคำขอให้เจ้าหน้าที่อนุมัติ
+	ProfileFieldForbidden = "forbidden" // นักศึกษาแก้ไขไม่ได้
+)
+
+type profileField struct {
+	Column string
+	Policy string
+	Int    bool
+	// ข้อมูลส่วนบุคคล: ค่าใหม่ในคำขอเก็บแบบเข้ารหัส ค่าเดิมเก็บแบบปิดบัง
+	Sensitive bool
+	// ตารางที่ต้องมีค่าอ้างอิงอยู่จริง (foreign key)
+	RefTable, RefColumn string
+}
+
+// ฟิลด์ของ Students (ชื่อตาม JSON) ที่ไม่อยู่ในรายการนี้จะไม่ถูกนำไปใช้
+var profileFields = map[string]profileField{
+	"Email":   {Column: "email", Policy: ProfileFieldDirect},
+	"Phone":   {Column: "phone", Policy: ProfileFieldDirect},
+	"Address": {Column: "address", Policy: ProfileFieldDirect},
+	"Parent":  {Column: "parent", Policy: ProfileFieldDirect},
+
+	"FirstName":    {Column: "first_name", Policy: ProfileFieldApproval},
+	"LastName":     {Column: "last_name", Policy: ProfileFieldApproval},
+	"CitizenID":    {Column: "citizen_id", Policy: ProfileFieldApproval, Sensitive: true},
+	"BirthDay":     {Column: "birth_day", Policy: ProfileFieldApproval},
+	"Nationality":  {Column: "nationality", Policy: ProfileFieldApproval},
+	"Ethnicity":    {Column: "ethnicity", Policy: ProfileFieldApproval},
+	"Religion":     {Column: "religion", Policy: ProfileFieldApproval},
+	"GenderID":     {Column: "gender_id", Policy: ProfileFieldApproval, Int: true, RefTable: "genders", RefColumn: "id"},
+	"FacultyID":    {Column: "faculty_id", Policy: ProfileFieldApproval, RefTable: "faculties", RefColumn: "faculty_id"},
+	"MajorID":      {Column: "major_id", Policy: ProfileFieldApproval, RefTable: "majors", RefColumn: "major_id"},
+	"DegreeID":     {Column: "degree_id", Policy: ProfileFieldApproval, Int: true, RefTable: "degrees", RefColumn: "degree_id"},
+	"CurriculumID": {Column: "curriculum_id", Policy: ProfileFieldApproval, RefTable: "curriculums", RefColumn: "curriculum_id"},
+
+	"StudentID":       {Column: "student_id", Policy: ProfileFieldForbidden},
+	"StatusStudentID": {Column: "status_student_id", Policy: ProfileFieldForbidden},
+	"AdvisorID":       {Column: "advisor_id", Policy: ProfileFieldForbidden},
+	"GPAX":            {Column: "gpax", Policy: ProfileFieldForbidden},
+	"GraduteDate":     {Column: "gradute_date", Policy: ProfileFieldForbidden},
+}
+
+var (
+	ErrProfileFieldForbidden = errors.New("these fields cannot be changed by the student")
+	ErrProfileChangeReviewed = errors.New("change request has already been reviewed")
+	ErrProfileInvalidValue   = errors.New("invalid value")
+)
+
+// ผลการแยกข้อมูลที่นักศึกษาส่งมาแก้ไขตามนโยบาย
+type ProfileUpdate struct {
+	Direct    map[string]interface{}      // column -> ค่าใหม่ ที่บันทึกได้ทันที
+	Applied   []string                    // ชื่อฟิลด์ที่บันทึกได้ทันที
+	Changes   []entity.ProfileFieldChange // ฟิลด์ที่ต้องขออนุมัติ
+	Forbidden []string                    // ฟิลด์ต้องห้ามที่ส่งค่าใหม่มา
+}
+
+func profileValueString(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case []byte:
+		return string(x)
+	case time.Time:
+		return x.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(x)
+	}
+}
+
+func currentStudentValues(tx *gorm.DB, studentID string) (map[string]interface{}, error) {
+	current := map[string]interface{}{}
+	if err := tx.Model(&entity.Students{}).Where("student_id = ?", studentID).Take(&current).Error; err != nil {
+		return nil, err
+	}
+	// อ่านแบบ map ไม่ผ่าน serializer จึงถอดรหัสเอง
+	for _, field := range profileFields {
+		if field.Sensitive {
+			plain, err := config.DecryptPII(profileValueString(current[field.Column]))
+			if err != nil {
+				return nil, err
+			}
+			current[field.Column] = plain
+		}
+	}
+	return current, nil
+}
+
+// ค่าใหม่ของฟิลด์ในคำขอ (ถอดรหัสฟิลด์ข้อมูลส่วนบุคคล)
+func profileChangeValue(ch entity.ProfileFieldChange) (string, error) {
+	if profileFields[ch.Field].Sensitive {
+		return config.DecryptPII(ch.After)
+	}
+	return ch.After, nil
+}
+
+// แยกฟิลด์ที่ส่งมา (JSON ของ Students) ตามนโยบาย ฟิลด์ที่ค่าไม่เปลี่ยนจะถูกข้าม
+func ClassifyProfileUpdate(tx *gorm.DB, studentID string, input map[string]interface{}) (*ProfileUpdate, error) {
+	current, err := currentStudentValues(tx, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	upd := &ProfileUpdate{Direct: map[string]interface{}{}}
+	names := make([]string, 0, len(input))
+	for name := range input {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		field, ok := profileFields[name]
+		if !ok || input[name] == nil {
+			continue
+		}
+		after := profileValueString(input[name])
+		before := profileValueString(current[field.Column])
+		if after == before {
+			continue
+		}
+		if field.Sensitive {
+			// ฟอร์มส่งค่าที่แสดงแบบปิดบังกลับมา ถือว่าไม่เปลี่ยน
+			if !CitizenIDChanged(entity.CitizenID(before), after) {
+				continue
+			}
+			before = entity.CitizenID(before).Masked()
+		}
+		switch field.Policy {
+		case ProfileFieldDirect:
+			upd.Direct[field.Column] = after
+			upd.Applied = append(upd.Applied, name)
+		case ProfileFieldApproval:
+			upd.Changes = append(upd.Changes, entity.ProfileFieldChange{Field: name, Before: before, After: after})
+		default:
+			upd.Forbidden = append(upd.Forbidden, name)
+		}
+	}
+	return upd, nil
+}
+
+// ตรวจค่าที่ขอแก้ไข: ตัวเลข, รูปแบบเลขบัตรประชาชน และค่าอ้างอิงที่ต้องมีอยู่จริง
+func validateProfileChange(tx *gorm.DB, ch entity.ProfileFieldChange) error {
+	field := profileFields[ch.Field]
+	after, err := profileChangeValue(ch)
+	if err != nil {
+		return err
+	}
+	ch.After = after
+	if field.Int {
+		if _, err := strconv.Atoi(ch.After); err != nil {
+			return fmt.Errorf("%w: %s must be a number", ErrProfileInvalidValue, ch.Field)
+		}
+	}
+	if ch.Field == "CitizenID" && !ValidCitizenID(ch.After) {
+		return fmt.Errorf("%w: CitizenID must be 13 digits with a valid check digit", ErrProfileInvalidValue)
+	}
+	if field.RefTable != "" {
+		var count int64
+		if err := tx.Table(field.RefTable).Where(field.RefColumn+" = ?", ch.After).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return fmt.Errorf("%w: %s %q does not exist", ErrProfileInvalidValue, ch.Field, ch.After)
+		}
+	}
+	return nil
+}
+
+// ยื่นคำขอแก้ไข ถ้ามีคำขอที่รออนุมัติอยู่แล้วจะรวมฟิลด์เข้าคำขอเดิม (เอกสารแนบเดิมยังอยู่)
+func SubmitProfileChanges(tx *gorm.DB, studentID string, changes []entity.ProfileFieldChange, note string) (*entity.ProfileChangeRequest, error) {
+	for _, ch := range changes {
+		if err := validateProfileChange(tx, ch); err != nil {
+			return nil, err
+		}
+	}
+
+	var cr entity.ProfileChangeRequest
+	err := tx.Where("student_id = ? AND status = ?", studentID, entity.ProfileChangePending).First(&cr).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		cr = entity.ProfileChangeRequest{
+			StudentID: studentID,
+			Status:    entity.ProfileChangePending,
+		}
+	}
+
+	for _, ch := range changes {
+		if profileFields[ch.Field].Sensitive {
+			encrypted, err := config.EncryptPII(ch.After)
+			if err != nil {
+				return nil, err
+			}
+			ch.After = encrypted
+		}
+		replaced := false
+		for i := range cr.Changes {
+			if cr.Changes[i].Field == ch.Field {
+				cr.Changes[i] = ch
+				replaced = true
+			}
+		}
+		if !replaced {
+			cr.Changes = append(cr.Changes, ch)
+		}
+	}
+	if note != "" {
+		cr.Note = note
+	}
+	cr.RequestedAt = time.Now()
+	if err := tx.Save(&cr).Error; err != nil {
+		return nil, err
+	}
+	return &cr, nil
+}
+
+// เจ้าหน้าที่พิจารณาคำขอ อนุมัติแล้วบันทึกค่าใหม่ลงข้อมูลนักศึกษา
+func ReviewProfileChange(tx *gorm.DB, cr *entity.ProfileChangeRequest, approve bool, reason, reviewer string) error {
+	if cr.Status != entity.ProfileChangePending {
+		return ErrProfileChangeReviewed
+	}
+	now := time.Now()
+	cr.ReviewedBy = reviewer
+	cr.ReviewedAt = &now
+
+	if !approve {
+		cr.Status = entity.ProfileChangeRejected
+		cr.RejectReason = reason
+		return tx.Save(cr).Error
+	}
+
+	updates := map[string]interface{}{}
+	for _, ch := range cr.Changes {
+		if err := validateProfileChange(tx, ch); err != nil {
+			return err
+		}
+		field := profileFields[ch.Field]
+		after, err := profileChangeValue(ch)
+		if err != nil {
+			return err
+		}
+		if field.Int {
+			n, _ := strconv.Atoi(after)
+			updates[field.Column] = n
+		} else {
+			updates[field.Column] = after
+		}
+	}
+	if len(updates) > 0 {
+		if err := tx.Model(&entity.Students{}).Where("student_id = ?", cr.StudentID).Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+	cr.Status = entity.ProfileChangeApproved
+	return tx.Save(cr).Error
+}
@@ -0,0 +1,325 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"reg_system/entity"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrTransferSameProgram = errors.New("student is already in the target curriculum")
+	ErrTransferOpen        = errors.New("student already has an open program transfer request")
+	ErrTransferGPAX        = errors.New("GPAX does not meet the target curriculum's transfer requirement")
+	ErrTransferState       = errors.New("program transfer request is not in a state that allows this action")
+)
+
+// เกรดที่นับว่าผ่านและได้หน่วยกิต
+func passingGrade(grade string) bool {
+	_, ok := gradePointMap[grade]
+	return ok && grade != "F"
+}
+
+// ยื่นคำขอย้ายไปหลักสูตร toCurriculumID ตรวจเกณฑ์เกรดเฉลี่ยของหลักสูตรปลายทางทันที
+func CreateProgramTransfer(tx *gorm.DB, studentID, toCurriculumID, reason string) (*entity.ProgramTransfer, error) {
+	var student entity.Students
+	if err := tx.First(&student, "student_id = ?", studentID).Error; err != nil {
+		return nil, err
+	}
+	var target entity.Curriculum
+	if err := tx.First(&target, "curriculum_id = ?", toCurriculumID).Error; err != nil {
+		return nil, err
+	}
+	if student.CurriculumID == target.CurriculumID {
+		return nil, ErrTransferSameProgram
+	}
+
+	var open int64
+	if err := tx.Model(&entity.ProgramTransfer{}).
+		Where("student_id = ? AND status IN ?", studentID,
+			[]string{entity.ProgramTransferPending, entity.ProgramTransferAccepted, entity.ProgramTransferScheduled}).
+		Count(&open).Error; err != nil {
+		return nil, err
+	}
+	if open > 0 {
+		return nil, ErrTransferOpen
+	}
+
+	gpax, err := StudentGPAX(tx, studentID)
+	if err != nil {
+		return nil, err
+	}
+	if target.TransferMinGPAX > 0 && gpax < target.TransferMinGPAX {
+		return nil, fmt.Errorf("%w (GPAX %.2f, required %.2f)", ErrTransferGPAX, gpax, target.TransferMinGPAX)
+	}
+
+	t := entity.ProgramTransfer{
+		StudentID:        studentID,
+		FromCurriculumID: student.CurriculumID,
+		FromMajorID:      student.MajorID,
+		FromFacultyID:    student.FacultyID,
+		ToCurriculumID:   target.CurriculumID,
+		ToMajorID:        target.MajorID,
+		ToFacultyID:      target.FacultyID,
+		Reason:           reason,
+		GPAX:             gpax,
+		RequiredGPAX:     target.TransferMinGPAX,
+		Status:           entity.ProgramTransferPending,
+		RequestedAt:      time.Now(),
+	}
+	if err := tx.Create(&t).Error; err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// คณะปลายทางพิจารณารับนักศึกษา
+func FacultyReviewTransfer(tx *gorm.DB, t *entity.ProgramTransfer, accept bool, comment, reviewer string) error {
+	if t.Status != entity.ProgramTransferPending {
+		return ErrTransferState
+	}
+	now := time.Now()
+	t.FacultyReviewedBy = reviewer
+	t.FacultyReviewedAt = &now
+	t.FacultyComment = comment
+	t.Status = entity.ProgramTransferAccepted
+	if !accept {
+		t.Status = entity.ProgramTransferRejected
+		t.RejectReason = comment
+	}
+	return tx.Save(t).Error
+}
+
+// ไม่อนุมัติคำขอ (ทำได้ทั้งก่อนและหลังคณะปลายทางรับ และยกเลิกการย้ายที่รอภาคการศึกษาที่มีผลได้)
+func RejectProgramTransfer(tx *gorm.DB, t *entity.ProgramTransfer, reason, reviewer string) error {
+	switch t.Status {
+	case entity.ProgramTransferPending, entity.ProgramTransferAccepted:
+	case entity.ProgramTransferScheduled:
+		if err := tx.Where("program_transfer_id = ?", t.ID).Delete(&entity.TransferCreditMapping{}).Error; err != nil {
+			return err
+		}
+	default:
+		return ErrTransferState
+	}
+	now := time.Now()
+	t.ReviewedBy = reviewer
+	t.ReviewedAt = &now
+	t.RejectReason = reason
+	t.Status = entity.ProgramTransferRejected
+	return tx.Save(t).Error
+}
+
+// อนุมัติการย้าย: สร้างการเทียบโอนรายวิชาที่ผ่านแล้ว และเปลี่ยนสาขา/หลักสูตรของนักศึกษา
+// วิชาที่อยู่ในหลักสูตรปลายทางเทียบโอนอัตโนมัติ overrides ใช้กำหนดการเทียบรายวิชาเอง (อ้างอิงด้วย SubjectID)
+// ถ้าภาคการศึกษาที่มีผลยังไม่เริ่ม คำขอจะรอ (scheduled) และย้ายเมื่อถึงวันเปิดภาคใน ApplyDueProgramTransfers
+func ApproveProgramTransfer(tx *gorm.DB, t *entity.ProgramTransfer, semesterID int, overrides []entity.TransferCreditMapping, reviewer string) error {
+	if t.Status != entity.ProgramTransferAccepted {
+		return ErrTransferState
+	}
+	var semester entity.Semester
+	if err := tx.First(&semester, "id = ?", semesterID).Error; err != nil {
+		return err
+	}
+
+	var grades []entity.Grades
+	if err := tx.Preload("Subject").Where("student_id = ?", t.StudentID).Find(&grades).Error; err != nil {
+		return err
+	}
+	var inTarget []string
+	if err := tx.Model(&entity.SubjectCurriculum{}).Where("curriculum_id = ?", t.ToCurriculumID).
+		Pluck("subject_id", &inTarget).Error; err != nil {
+		return err
+	}
+	targetSubjects := map[string]bool{}
+	for _, id := range inTarget {
+		targetSubjects[id] = true
+	}
+	byOverride := map[string]entity.TransferCreditMapping{}
+	for _, o := range overrides {
+		byOverride[o.SubjectID] = o
+	}
+
+	for _, g := range grades {
+		if !passingGrade(g.Grade) {
+			continue
+		}
+		m := entity.TransferCreditMapping{
+			ProgramTransferID: t.ID,
+			StudentID:         t.StudentID,
+			SubjectID:         g.SubjectID,
+			Grade:             g.Grade,
+		}
+		if g.Subject != nil {
+			m.Credit = g.Subject.Credit
+		}
+		if targetSubjects[g.SubjectID] {
+			m.TargetSubjectID = g.SubjectID
+			m.Counted = true
+		}
+		if o, ok := byOverride[g.SubjectID]; ok {
+			m.TargetSubjectID = o.TargetSubjectID
+			m.Counted = o.Counted
+		}
+		if err := tx.Create(&m).Error; err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	t.ReviewedBy = reviewer
+	t.ReviewedAt = &now
+	t.EffectiveSemesterID = &semesterID
+	if !semesterStarted(semester, now) {
+		t.Status = entity.ProgramTransferScheduled
+		return tx.Save(t).Error
+	}
+	return applyProgramTransfer(tx, t)
+}
+
+// เปลี่ยนสาขา/หลักสูตร/คณะของนักศึกษาตามคำขอที่อนุมัติแล้ว
+func applyProgramTransfer(tx *gorm.DB, t *entity.ProgramTransfer) error {
+	res := tx.Model(&entity.Students{}).Where("student_id = ?", t.StudentID).Updates(map[string]interface{}{
+		"curriculum_id": t.ToCurriculumID,
+		"major_id":      t.ToMajorID,
+		"faculty_id":    t.ToFacultyID,
+	})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("student %s: %w", t.StudentID, gorm.ErrRecordNotFound)
+	}
+	t.Status = entity.ProgramTransferApproved
+	return tx.Save(t).Error
+}
+
+// ย้ายสาขา/หลักสูตรของคำขอที่รอ (scheduled) ซึ่งภาคการศึกษาที่มีผลเริ่มแล้ว คืนค่าจำนวนที่ย้าย
+// คำขอที่ย้ายไม่ได้ (เช่น ไม่พบข้อมูลนักศึกษาแล้ว) จะถูกปิดเป็นไม่อนุมัติพร้อมเหตุผล
+func ApplyDueProgramTransfers(tx *gorm.DB, now time.Time) (int, error) {
+	var due []entity.ProgramTransfer
+	if err := tx.Joins("JOIN semesters ON semesters.id = program_transfers.effective_semester_id").
+		Where("program_transfers.status = ? AND (semesters.start_date IS NULL OR semesters.start_date <= ?)",
+			entity.ProgramTransferScheduled, now).
+		Order("program_transfers.id ASC").Find(&due).Error; err != nil {
+		return 0, err
+	}
+	applied := 0
+	for i := range due {
+		// แต่ละคำขอทำใน savepoint ของตัวเอง คำขอที่ย้ายไม่ได้ถูกปฏิเสธพร้อมเหตุผลโดยไม่กระทบคำขออื่น
+		err := tx.Transaction(func(tx *gorm.DB) error {
+			return applyProgramTransfer(tx, &due[i])
+		})
+		if err == nil {
+			applied++
+			continue
+		}
+		log.Printf("scheduled program transfer %d could not be applied: %v", due[i].ID, err)
+		var t entity.ProgramTransfer
+		if err := tx.First(&t, due[i].ID).Error; err != nil {
+			return applied, err
+		}
+		if err := RejectProgramTransfer(tx, &t, "ไม่สามารถย้ายสาขาเมื่อถึงภาคการศึกษาที่มีผล: "+err.Error(), "system"); err != nil {
+			return applied, err
+		}
+	}
+	return applied, nil
+}
+
+// รายวิชาในผลการตรวจสอบการจบ
+type AuditLine struct {
+	SubjectID       string `json:"subject_id"`
+	Credit          int    `json:"credit"`
+	Grade           string `json:"grade"`
+	Source          string `json:"source"` // curriculum | transfer | elective
+	TargetSubjectID string `json:"target_subject_id,omitempty"`
+}
+
+// ผลการตรวจสอบหน่วยกิตเทียบกับหลักสูตรปัจจุบัน
+type DegreeAuditResult struct {
+	StudentID          string      `json:"student_id"`
+	CurriculumID       string      `json:"curriculum_id"`
+	RequiredCredits    int         `json:"required_credits"`
+	EarnedCredits      int         `json:"earned_credits"`
+	TransferredCredits int         `json:"transferred_credits"`
+	RemainingCredits   int         `json:"remaining_credits"`
+	Subjects           []AuditLine `json:"subjects"`
+	Excluded           []AuditLine `json:"excluded"` // วิชาที่ไม่นับหลังย้ายหลักสูตร
+}
+
+// ตรวจสอบหน่วยกิตที่นับได้ในหลักสูตรปัจจุบัน
+// วิชาที่เรียนก่อนย้ายหลักสูตรนับตามการเทียบโอนของการย้ายครั้งล่าสุด
+func DegreeAudit(tx *gorm.DB, studentID string) (*DegreeAuditResult, error) {
+	var student entity.Students
+	if err := tx.Preload("Curriculum").First(&student, "student_id = ?", studentID).Error; err != nil {
+		return nil, err
+	}
+	res := &DegreeAuditResult{
+		StudentID:    studentID,
+		CurriculumID: student.CurriculumID,
+		Subjects:     []AuditLine{},
+		Excluded:     []AuditLine{},
+	}
+	if student.Curriculum != nil {
+		res.RequiredCredits = student.Curriculum.TotalCredit
+	}
+
+	mappings := map[string]entity.TransferCreditMapping{}
+	var last entity.ProgramTransfer
+	err := tx.Preload("CreditMappings").
+		Where("student_id = ? AND status = ? AND to_curriculum_id = ?", studentID, entity.ProgramTransferApproved, student.CurriculumID).
+		Order("reviewed_at DESC").First(&last).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	for _, m := range last.CreditMappings {
+		mappings[m.SubjectID] = m
+	}
+
+	var inCurriculum []string
+	if err := tx.Model(&entity.SubjectCurriculum{}).Where("curriculum_id = ?", student.CurriculumID).
+		Pluck("subject_id", &inCurriculum).Error; err != nil {
+		return nil, err
+	}
+	curriculumSubjects := map[string]bool{}
+	for _, id := range inCurriculum {
+		curriculumSubjects[id] = true
+	}
+
+	var grades []entity.Grades
+	if err := tx.Preload("Subject").Where("student_id = ?", studentID).Find(&grades).Error; err != nil {
+		return nil, err
+	}
+	for _, g := range grades {
+		if !passingGrade(g.Grade) {
+			continue
+		}
+		line := AuditLine{SubjectID: g.SubjectID, Grade: g.Grade}
+		if g.Subject != nil {
+			line.Credit = g.Subject.Credit
+		}
+		if m, ok := mappings[g.SubjectID]; ok {
+			line.Source = "transfer"
+			line.TargetSubjectID = m.TargetSubjectID
+			if !m.Counted {
+				res.Excluded = append(res.Excluded, line)
+				continue
+			}
+			res.TransferredCredits += line.Credit
+		} else if curriculumSubjects[g.SubjectID] {
+			line.Source = "curriculum"
+		} else {
+			line.Source = "elective"
+		}
+		res.EarnedCredits += line.Credit
+		res.Subjects = append(res.Subjects, line)
+	}
+
+	if res.RequiredCredits > res.EarnedCredits {
+		res.RemainingCredits = res.RequiredCredits - res.EarnedCredits
+	}
+	return res, nil
+}
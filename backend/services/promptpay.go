@@ -0,0 +1,95 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var ErrInvalidPromptPayID = errors.New("invalid PromptPay ID")
+
+var nonDigit = regexp.MustCompile(`\D`)
+
+// รหัสอ้างอิงของบิล/งวด ที่ใส่ใน QR (ใช้จับคู่รายการโอนกับบิล)
+func BillReference(billID, seq int) string {
+	if seq > 0 {
+		return fmt.Sprintf("BILL%06dI%02d", billID, seq)
+	}
+	return fmt.Sprintf("BILL%06d", billID)
+}
+
+// ข้อมูล 1 ช่องตามรูปแบบ EMVCo: ID (2 หลัก) + ความยาว (2 หลัก) + ค่า
+func emvField(id, value string) string {
+	return fmt.Sprintf("%s%02d%s", id, len(value), value)
+}
+
+// CRC16-CCITT (poly 0x1021, ค่าเริ่มต้น 0xFFFF) ตามที่ EMVCo กำหนด
+func CRC16CCITT(data []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// ข้อมูลบัญชีผู้รับแบบโอนเงินพร้อมเพย์ (Tag 29)
+func promptPayAccount(id string) (string, error) {
+	id = nonDigit.ReplaceAllString(id, "")
+	switch len(id) {
+	case 10: // เบอร์โทรศัพท์ 0812345678 -> 0066812345678
+		return emvField("01", "0066"+id[1:]), nil
+	case 13: // เลขประจำตัวประชาชน/ผู้เสียภาษี
+		return emvField("02", id), nil
+	case 15: // e-Wallet
+		return emvField("03", id), nil
+	}
+	return "", ErrInvalidPromptPayID
+}
+
+// สร้าง payload QR พร้อมเพย์ (Thai QR Payment) แบบระบุยอดเงิน
+// billerID ว่าง = โอนเข้าพร้อมเพย์ promptPayID (Tag 29) แนบรหัสอ้างอิงใน Tag 62
+// billerID มีค่า = QR ชำระบิล (Tag 30) ref1 = รหัสนักศึกษา, ref2 = รหัสอ้างอิงบิล
+func PromptPayPayload(promptPayID, billerID string, amount int, ref1, ref2 string) (string, error) {
+	var b strings.Builder
+	b.WriteString(emvField("00", "01"))
+	b.WriteString(emvField("01", "12")) // 12 = QR ใช้ครั้งเดียวต่อยอดเงิน
+
+	if billerID != "" {
+		billerID = nonDigit.ReplaceAllString(billerID, "")
+		if len(billerID) != 15 {
+			return "", errors.New("biller ID must be 15 digits")
+		}
+		merchant := emvField("00", "A000000677010112") +
+			emvField("01", billerID) +
+			emvField("02", strings.ToUpper(ref1)) +
+			emvField("03", strings.ToUpper(ref2))
+		b.WriteString(emvField("30", merchant))
+	} else {
+		account, err := promptPayAccount(promptPayID)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(emvField("29", emvField("00", "A000000677010111")+account))
+	}
+
+	b.WriteString(emvField("53", "764")) // THB
+	if amount > 0 {
+		b.WriteString(emvField("54", fmt.Sprintf("%d.00", amount)))
+	}
+	b.WriteString(emvField("58", "TH"))
+	if billerID == "" && ref2 != "" {
+		b.WriteString(emvField("62", emvField("01", ref2))) // Bill Number
+	}
+
+	b.WriteString("6304")
+	payload := b.String()
+	return payload + fmt.Sprintf("%04X", CRC16CCITT([]byte(payload))), nil
+}
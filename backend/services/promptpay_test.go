@@ -0,0 +1,59 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCRC16CCITT(t *testing.T) {
+	// ค่าตรวจสอบมาตรฐานของ CRC-16/CCITT-FALSE
+	if got := CRC16CCITT([]byte("123456789")); got != 0x29B1 {
+		t.Fatalf("CRC16CCITT(123456789) = %04X, want 29B1", got)
+	}
+}
+
+func TestPromptPayPayload(t *testing.T) {
+	tests := []struct {
+		name                string
+		promptPayID, biller string
+		amount              int
+		ref1, ref2          string
+		want                string
+	}{
+		{
+			name:        "mobile number with bill reference",
+			promptPayID: "081-234-5678",
+			amount:      500,
+			ref2:        "BILL000001",
+			want:        "00020101021229370016A0000006770101110113006681234567853037645406500.005802TH62140110BILL0000016304B72F",
+		},
+		{
+			name:   "bill payment",
+			biller: "099400016550100",
+			amount: 1250,
+			ref1:   "b6616052",
+			ref2:   "BILL000001I02",
+			want:   "00020101021230680016A00000067701011201150994000165501000208B66160520313BILL000001I02530376454071250.005802TH6304DBFC",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PromptPayPayload(tt.promptPayID, tt.biller, tt.amount, tt.ref1, tt.ref2)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("payload\n got %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPromptPayPayloadInvalidID(t *testing.T) {
+	if _, err := PromptPayPayload("12345", "", 100, "", ""); !errors.Is(err, ErrInvalidPromptPayID) {
+		t.Fatalf("err = %v, want ErrInvalidPromptPayID", err)
+	}
+	if _, err := PromptPayPayload("", "1234", 100, "", ""); err == nil {
+		t.Fatal("expected error for short biller ID")
+	}
+}
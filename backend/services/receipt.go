@@ -0,0 +1,148 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"reg_system/entity"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrReceiptReviewed      = errors.New("receipt has already been reviewed")
+	ErrReceiptReasonMissing = errors.New("reason is required when rejecting a receipt")
+)
+
+// บันทึกหลักฐานการชำระเวอร์ชันใหม่ของบิล (หรือของงวด ถ้า inst ไม่เป็น nil)
+// หลักฐานที่ยังรอตรวจของบิล/งวดเดียวกันจะถูกแทนที่ (superseded)
+func SubmitReceipt(tx *gorm.DB, bill entity.Bill, inst *entity.Installment, fileName, originalName, submittedBy string) (*entity.ReceiptSubmission, error) {
+	scope := tx.Model(&entity.ReceiptSubmission{}).Where("bill_id = ?", bill.ID)
+	if inst != nil {
+		scope = scope.Where("installment_id = ?", inst.ID)
+	} else {
+		scope = scope.Where("installment_id IS NULL")
+	}
+
+	var version int
+	if err := scope.Session(&gorm.Session{}).Select("COALESCE(MAX(version), 0)").Scan(&version).Error; err != nil {
+		return nil, err
+	}
+	if err := scope.Session(&gorm.Session{}).Where("status = ?", entity.ReceiptSubmitted).
+		Update("status", entity.ReceiptSuperseded).Error; err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	sub := entity.ReceiptSubmission{
+		BillID:       bill.ID,
+		Version:      version + 1,
+		FilePath:     fileName,
+		OriginalName: originalName,
+		SubmittedBy:  submittedBy,
+		SubmittedAt:  now,
+		Status:       entity.ReceiptSubmitted,
+	}
+	if inst != nil {
+		instID := inst.ID
+		sub.InstallmentID = &instID
+	}
+	if err := tx.Create(&sub).Error; err != nil {
+		return nil, err
+	}
+
+	// FilePath ของบิล/งวดชี้ไปที่ไฟล์ล่าสุดเพื่อให้หน้าจอเดิมแสดงได้ ประวัติทั้งหมดอยู่ใน ReceiptSubmission
+	if inst != nil {
+		if err := tx.Model(&entity.Installment{}).Where("id = ?", inst.ID).Updates(map[string]interface{}{
+			"file_path":   fileName,
+			"status_id":   entity.BillStatusPending,
+			"uploaded_at": now,
+		}).Error; err != nil {
+			return nil, err
+		}
+	} else {
+		if err := tx.Model(&entity.Bill{}).Where("id = ?", bill.ID).Updates(map[string]interface{}{
+			"file_path":   fileName,
+			"status_id":   entity.BillStatusPending,
+			"uploaded_at": now,
+		}).Error; err != nil {
+			return nil, err
+		}
+	}
+	if err := SyncBillStatus(tx, bill.ID); err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// ตรวจหลักฐาน: อนุมัติ = บันทึกรับชำระในสมุดบัญชี, ไม่อนุมัติ = ต้องระบุเหตุผล และนักศึกษาส่งใหม่ได้
+func ReviewReceipt(tx *gorm.DB, sub *entity.ReceiptSubmission, approve bool, reason, reviewer string) error {
+	if sub.Status != entity.ReceiptSubmitted {
+		return ErrReceiptReviewed
+	}
+	if !approve && reason == "" {
+		return ErrReceiptReasonMissing
+	}
+
+	now := time.Now()
+	sub.ReviewedBy = reviewer
+	sub.ReviewedAt = &now
+	sub.Reason = reason
+	sub.Status = entity.ReceiptRejected
+	if approve {
+		sub.Status = entity.ReceiptApproved
+	}
+	if err := tx.Save(sub).Error; err != nil {
+		return err
+	}
+
+	var bill entity.Bill
+	if err := tx.First(&bill, sub.BillID).Error; err != nil {
+		return err
+	}
+
+	if sub.InstallmentID != nil {
+		var inst entity.Installment
+		if err := tx.First(&inst, *sub.InstallmentID).Error; err != nil {
+			return err
+		}
+		if approve {
+			return ApproveInstallment(tx, inst, reviewer)
+		}
+		if err := tx.Model(&inst).Update("status_id", entity.BillStatusUnpaid).Error; err != nil {
+			return err
+		}
+		return SyncBillStatus(tx, bill.ID)
+	}
+
+	if approve {
+		return ApproveBillReceipt(tx, bill, reviewer)
+	}
+	if err := tx.Model(&entity.Bill{}).Where("id = ?", bill.ID).Update("status_id", entity.BillStatusUnpaid).Error; err != nil {
+		return err
+	}
+	return SyncBillStatus(tx, bill.ID)
+}
+
+// ปิดหลักฐานที่รอตรวจของบิล/งวด เมื่อรับชำระจากช่องทางอื่นแล้ว (เช่น จับคู่รายการเดินบัญชี)
+// เพื่อไม่ให้อนุมัติหลักฐานเดิมซ้ำแล้วบันทึกรับเงินสองครั้ง
+func approveOpenReceipts(tx *gorm.DB, billID int, instID *int, reviewer string) error {
+	q := tx.Model(&entity.ReceiptSubmission{}).Where("bill_id = ? AND status = ?", billID, entity.ReceiptSubmitted)
+	if instID != nil {
+		q = q.Where("installment_id = ?", *instID)
+	} else {
+		q = q.Where("installment_id IS NULL")
+	}
+	return q.Updates(map[string]interface{}{
+		"status":      entity.ReceiptApproved,
+		"reviewed_by": reviewer,
+		"reviewed_at": time.Now(),
+	}).Error
+}
+
+// หลักฐานที่รอตรวจทั้งหมดของบิล
+func PendingReceipts(tx *gorm.DB, billID int) ([]entity.ReceiptSubmission, error) {
+	var subs []entity.ReceiptSubmission
+	err := tx.Where("bill_id = ? AND status = ?", billID, entity.ReceiptSubmitted).Order("id ASC").Find(&subs).Error
+	return subs, err
+}
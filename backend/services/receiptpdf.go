@@ -0,0 +1,154 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+
+	"reg_system/config"
+	"reg_system/entity"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// ข้อความบนใบเสร็จ [ไทย, อังกฤษ] ใช้ภาษาไทยเมื่อกำหนดฟอนต์ไทยไว้เท่านั้น
+var receiptLabels = map[string][2]string{
+	"title":       {"ใบเสร็จรับเงิน / ใบกำกับภาษี", "OFFICIAL RECEIPT / TAX INVOICE"},
+	"no":          {"เลขที่", "Receipt No."},
+	"date":        {"วันที่", "Date"},
+	"tax_id":      {"เลขประจำตัวผู้เสียภาษี", "Tax ID"},
+	"student":     {"นักศึกษา", "Student"},
+	"term":        {"ภาคการศึกษา", "Term"},
+	"installment": {"งวดที่", "Installment"},
+	"item":        {"รายการ", "Description"},
+	"amount":      {"จำนวนเงิน (บาท)", "Amount (THB)"},
+	"bill_total":  {"ยอดรวมตามบิล", "Bill total"},
+	"received":    {"รับชำระครั้งนี้", "Amount received"},
+	"method":      {"ช่องทางการชำระ", "Payment method"},
+	"reference":   {"เลขที่รายการ", "Reference"},
+	"approved_by": {"ผู้อนุมัติ", "Approved by"},
+}
+
+var receiptMethodLabels = map[string][2]string{
+	entity.PaymentMethodCash:      {"เงินสด", "Cash"},
+	entity.PaymentMethodTransfer:  {"โอนเงิน", "Bank transfer"},
+	entity.PaymentMethodPromptPay: {"พร้อมเพย์", "PromptPay"},
+	entity.PaymentMethodCard:      {"บัตร", "Card"},
+	entity.PaymentMethodCredit:    {"เครดิต", "Credit"},
+}
+
+var receiptItemLabels = map[string]string{
+	entity.BillItemTuition:  "Tuition",
+	entity.BillItemTermFee:  "Term fee",
+	entity.BillItemLabFee:   "Lab fee",
+	entity.BillItemDiscount: "Discount",
+	entity.BillItemPenalty:  "Penalty",
+}
+
+func isASCII(s string) bool {
+	for _, r := range s {
+		if r > unicode.MaxASCII {
+			return false
+		}
+	}
+	return true
+}
+
+func formatBaht(n int) string {
+	sign := ""
+	if n < 0 {
+		sign, n = "-", -n
+	}
+	s := fmt.Sprint(n)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return sign + s + ".00"
+}
+
+// สร้างไฟล์ PDF ของใบเสร็จและเขียนลง w
+func RenderOfficialReceipt(w io.Writer, r entity.OfficialReceipt) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	font, thai := "Helvetica", false
+	if path := config.ReceiptFontPath(); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		pdf.AddUTF8FontFromBytes("receipt", "", data)
+		if pdf.Err() {
+			return pdf.Error()
+		}
+		font, thai = "receipt", true
+	}
+	label := func(pair [2]string) string {
+		if thai {
+			return pair[0]
+		}
+		return pair[1]
+	}
+	// ฟอนต์มาตรฐานแสดงภาษาไทยไม่ได้ ข้อความที่ไม่ใช่ ASCII จะใช้ fallback แทน
+	text := func(s, fallback string) string {
+		if thai || isASCII(s) {
+			return s
+		}
+		return fallback
+	}
+
+	pdf.AddPage()
+	pdf.SetFont(font, "", 16)
+	pdf.CellFormat(0, 9, text(config.ReceiptIssuerName(), "Registration Office"), "", 1, "C", false, 0, "")
+	if taxID := config.ReceiptTaxID(); taxID != "" {
+		pdf.SetFont(font, "", 11)
+		pdf.CellFormat(0, 6, label(receiptLabels["tax_id"])+" "+taxID, "", 1, "C", false, 0, "")
+	}
+	pdf.SetFont(font, "", 14)
+	pdf.CellFormat(0, 9, label(receiptLabels["title"]), "", 1, "C", false, 0, "")
+	pdf.Ln(3)
+
+	pdf.SetFont(font, "", 11)
+	row := func(key, value string) {
+		pdf.CellFormat(45, 7, label(receiptLabels[key]), "", 0, "L", false, 0, "")
+		pdf.CellFormat(0, 7, value, "", 1, "L", false, 0, "")
+	}
+	row("no", r.ReceiptNo)
+	row("date", r.IssuedAt.Format("02/01/2006 15:04"))
+	student := r.StudentID
+	if name := text(r.StudentName, ""); name != "" {
+		student += "  " + name
+	}
+	row("student", student)
+	row("term", fmt.Sprintf("%d/%d", r.Term, r.AcademicYear))
+	if r.InstallmentSeq > 0 {
+		row("installment", fmt.Sprint(r.InstallmentSeq))
+	}
+	pdf.Ln(3)
+
+	pdf.CellFormat(130, 8, label(receiptLabels["item"]), "1", 0, "C", false, 0, "")
+	pdf.CellFormat(0, 8, label(receiptLabels["amount"]), "1", 1, "C", false, 0, "")
+	for _, line := range r.Lines {
+		fallback := receiptItemLabels[line.Type]
+		if line.SubjectID != "" {
+			fallback += " " + line.SubjectID
+		}
+		pdf.CellFormat(130, 7, text(line.Description, fallback), "LR", 0, "L", false, 0, "")
+		pdf.CellFormat(0, 7, formatBaht(line.Amount), "LR", 1, "R", false, 0, "")
+	}
+	pdf.CellFormat(130, 8, label(receiptLabels["bill_total"]), "1", 0, "R", false, 0, "")
+	pdf.CellFormat(0, 8, formatBaht(r.BillTotal), "1", 1, "R", false, 0, "")
+	pdf.CellFormat(130, 8, label(receiptLabels["received"]), "1", 0, "R", false, 0, "")
+	pdf.CellFormat(0, 8, formatBaht(r.Amount), "1", 1, "R", false, 0, "")
+	pdf.Ln(4)
+
+	method := r.Method
+	if m, ok := receiptMethodLabels[r.Method]; ok {
+		method = label(m)
+	}
+	row("method", method)
+	row("reference", r.Reference)
+	row("approved_by", strings.TrimSpace(text(r.ApprovedBy, "-")))
+
+	return pdf.Output(w)
+}
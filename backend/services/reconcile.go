@@ -0,0 +1,425 @@
+package services
+
+import (
+	"crypto/sha1"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"reg_system/config"
+	"reg_system/entity"
+
+	"gorm.io/gorm"
+)
+
+// การจับคู่คอลัมน์ของไฟล์ statement (ชื่อหัวคอลัมน์ในไฟล์)
+// ค่าว่างจะค้นจากชื่อหัวคอลัมน์ที่ใช้บ่อย
+type StatementMapping struct {
+	Date        string `json:"date"`
+	Time        string `json:"time"`
+	Amount      string `json:"amount"`
+	Reference   string `json:"reference"`
+	Description string `json:"description"`
+	Sequence    string `json:"sequence"`    // เลขที่รายการของธนาคาร (ถ้ามี)
+	DateFormat  string `json:"date_format"` // รูปแบบวันที่ของ Go เช่น 02/01/2006
+	Delimiter   string `json:"delimiter"`
+	SkipRows    int    `json:"skip_rows"` // จำนวนบรรทัดก่อนหัวตาราง
+}
+
+// รายการที่อ่านจาก statement
+type StatementLine struct {
+	Line         int
+	TransactedAt time.Time
+	Amount       int
+	Reference    string
+	Description  string
+	Sequence     string // เลขที่รายการของธนาคาร
+	Occurrence   int    // ลำดับของรายการที่วันเวลา ยอด อ้างอิง และรายละเอียดเหมือนกันในไฟล์เดียวกัน (เริ่มที่ 1)
+}
+
+type StatementError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+var headerAliases = map[string][]string{
+	"date":        {"date", "transaction date", "txn date", "วันที่", "วันที่ทำรายการ"},
+	"time":        {"time", "เวลา"},
+	"amount":      {"amount", "credit", "deposit", "จำนวนเงิน", "ฝาก", "เงินเข้า"},
+	"reference":   {"reference", "ref", "ref1", "ref2", "reference no", "เลขที่อ้างอิง", "อ้างอิง"},
+	"description": {"description", "details", "remark", "รายละเอียด", "หมายเหตุ"},
+	"sequence":    {"sequence", "sequence no", "transaction id", "txn id", "trace no", "เลขที่รายการ"},
+}
+
+var statementDateFormats = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	time.RFC3339,
+	"02/01/2006 15:04:05",
+	"02/01/2006 15:04",
+	"02/01/2006",
+	"2/1/2006",
+	"02-01-2006",
+}
+
+var billRefPattern = regexp.MustCompile(`BILL(\d{6})(?:I(\d{2}))?`)
+
+func findColumn(header []string, name string, configured string) int {
+	candidates := headerAliases[name]
+	if configured != "" {
+		candidates = []string{configured}
+	}
+	for _, want := range candidates {
+		for i, h := range header {
+			if strings.EqualFold(strings.TrimSpace(h), want) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// วันที่ใน statement อาจเป็น พ.ศ. ให้แปลงเป็น ค.ศ.
+func parseStatementDate(value, layout string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	layouts := statementDateFormats
+	if layout != "" {
+		layouts = []string{layout}
+	}
+	for _, l := range layouts {
+		if t, err := time.ParseInLocation(l, value, time.Local); err == nil {
+			if t.Year() > 2400 {
+				t = t.AddDate(-543, 0, 0)
+			}
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
+
+func parseStatementAmount(value string) (int, error) {
+	value = strings.NewReplacer(",", "", " ", "", "฿", "").Replace(strings.TrimSpace(value))
+	if value == "" {
+		return 0, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", value)
+	}
+	if f != math.Trunc(f) {
+		return 0, fmt.Errorf("amount %q has satang, bills are charged in whole baht", value)
+	}
+	return int(f), nil
+}
+
+// อ่านไฟล์ statement (CSV) ตามการจับคู่คอลัมน์ รายการเงินออก/ยอด 0 จะถูกข้าม
+func ParseStatement(r io.Reader, m StatementMapping) ([]StatementLine, []StatementError, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	if m.Delimiter != "" {
+		reader.Comma = []rune(m.Delimiter)[0]
+	}
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(rows) <= m.SkipRows {
+		return nil, nil, errors.New("statement has no header row")
+	}
+	header := rows[m.SkipRows]
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	dateCol := findColumn(header, "date", m.Date)
+	amountCol := findColumn(header, "amount", m.Amount)
+	if dateCol < 0 || amountCol < 0 {
+		return nil, nil, errors.New("date and amount columns are required")
+	}
+	timeCol := findColumn(header, "time", m.Time)
+	refCol := findColumn(header, "reference", m.Reference)
+	descCol := findColumn(header, "description", m.Description)
+	seqCol := findColumn(header, "sequence", m.Sequence)
+
+	cell := func(row []string, i int) string {
+		if i < 0 || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	lines := []StatementLine{}
+	errs := []StatementError{}
+	seen := map[string]int{}
+	for i, row := range rows[m.SkipRows+1:] {
+		lineNo := m.SkipRows + i + 2
+		if strings.TrimSpace(strings.Join(row, "")) == "" {
+			continue
+		}
+
+		dateValue := cell(row, dateCol)
+		if t := cell(row, timeCol); t != "" {
+			dateValue += " " + t
+		}
+		at, err := parseStatementDate(dateValue, m.DateFormat)
+		if err != nil {
+			errs = append(errs, StatementError{Line: lineNo, Error: err.Error()})
+			continue
+		}
+		amount, err := parseStatementAmount(cell(row, amountCol))
+		if err != nil {
+			errs = append(errs, StatementError{Line: lineNo, Error: err.Error()})
+			continue
+		}
+		if amount <= 0 {
+			continue
+		}
+
+		l := StatementLine{
+			Line:         lineNo,
+			TransactedAt: at,
+			Amount:       amount,
+			Reference:    cell(row, refCol),
+			Description:  cell(row, descCol),
+			Sequence:     cell(row, seqCol),
+		}
+		key := statementLineKey(l)
+		seen[key]++
+		l.Occurrence = seen[key]
+		lines = append(lines, l)
+	}
+	return lines, errs, nil
+}
+
+func statementLineKey(l StatementLine) string {
+	return fmt.Sprintf("%s|%d|%s|%s", l.TransactedAt.Format(time.RFC3339), l.Amount, l.Reference, l.Description)
+}
+
+// ใช้เลขที่รายการของธนาคารถ้ามี ไม่มีใช้ลำดับของรายการที่เหมือนกันในไฟล์
+// รายการโอนยอดเท่ากันในนาทีเดียวกันที่ไม่มีอ้างอิงจึงไม่ถูกนับเป็นรายการซ้ำ
+// (รายการแรกใช้ค่าเดิมเพื่อให้ตรงกับรายการที่นำเข้าไว้ก่อนแล้ว)
+func statementFingerprint(l StatementLine) string {
+	key := statementLineKey(l)
+	switch {
+	case l.Sequence != "":
+		key = fmt.Sprintf("seq|%s|%s|%d", l.Sequence, l.TransactedAt.Format(time.RFC3339), l.Amount)
+	case l.Occurrence > 1:
+		key += fmt.Sprintf("|#%d", l.Occurrence)
+	}
+	sum := sha1.Sum([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// นำเข้า statement: บันทึกรายการ จับคู่อัตโนมัติ และบันทึกรับชำระของรายการที่จับคู่ได้
+func ImportStatement(tx *gorm.DB, fileName, importedBy string, lines []StatementLine) (*entity.BankStatementImport, error) {
+	imp := entity.BankStatementImport{FileName: fileName, ImportedBy: importedBy}
+	if err := tx.Create(&imp).Error; err != nil {
+		return nil, err
+	}
+
+	for _, l := range lines {
+		imp.Rows++
+		fp := statementFingerprint(l)
+		var dup int64
+		if err := tx.Model(&entity.BankTransaction{}).Where("fingerprint = ?", fp).Count(&dup).Error; err != nil {
+			return nil, err
+		}
+		if dup > 0 {
+			imp.Duplicates++
+			continue
+		}
+
+		btx := entity.BankTransaction{
+			ImportID:     imp.ID,
+			Line:         l.Line,
+			TransactedAt: l.TransactedAt,
+			Amount:       l.Amount,
+			Reference:    l.Reference,
+			Description:  l.Description,
+			Sequence:     l.Sequence,
+			Fingerprint:  fp,
+			Status:       entity.BankTxUnmatched,
+		}
+		if err := tx.Create(&btx).Error; err != nil {
+			return nil, err
+		}
+		if err := autoMatch(tx, &btx, importedBy); err != nil {
+			return nil, err
+		}
+
+		switch btx.Status {
+		case entity.BankTxMatched:
+			imp.Matched++
+		case entity.BankTxAmbiguous:
+			imp.Ambiguous++
+		default:
+			imp.Unmatched++
+		}
+	}
+
+	if err := tx.Save(&imp).Error; err != nil {
+		return nil, err
+	}
+	return &imp, nil
+}
+
+// บิล/งวดที่คาดว่ารายการนี้ชำระ
+type matchCandidate struct {
+	bill entity.Bill
+	inst *entity.Installment
+}
+
+// ยอดที่ยังต้องชำระ งวดใช้ยอดที่ยังขาดหลังกระจายยอดรับชำระ (InstallmentRemaining)
+func (m matchCandidate) expected(tx *gorm.DB) (int, error) {
+	if m.inst != nil {
+		return InstallmentRemaining(tx, *m.inst)
+	}
+	bal, err := LedgerBalance(tx, m.bill.ID)
+	return bal.Outstanding, err
+}
+
+// จับคู่ตามลำดับ: รหัสอ้างอิงบิล (BILLxxxxxx[Iyy]) ก่อน
+// ถ้าไม่มีรหัส ใช้ยอดเงินตรงกับบิล/งวดที่ส่งหลักฐานไว้ภายในช่วงวันที่ที่กำหนด
+func autoMatch(tx *gorm.DB, btx *entity.BankTransaction, recordedBy string) error {
+	if ref := billRefPattern.FindStringSubmatch(strings.ToUpper(btx.Reference + " " + btx.Description)); ref != nil {
+		billID, _ := strconv.Atoi(ref[1])
+		var cand matchCandidate
+		if err := tx.First(&cand.bill, billID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				btx.MatchNote = "reference " + ref[0] + " does not match any bill"
+				return tx.Save(btx).Error
+			}
+			return err
+		}
+		if ref[2] != "" {
+			seq, _ := strconv.Atoi(ref[2])
+			var inst entity.Installment
+			if err := tx.Where("bill_id = ? AND seq = ?", billID, seq).First(&inst).Error; err == nil {
+				cand.inst = &inst
+			}
+		}
+
+		expected, err := cand.expected(tx)
+		if err != nil {
+			return err
+		}
+		if expected != btx.Amount {
+			btx.Status = entity.BankTxAmbiguous
+			btx.Candidates = strconv.Itoa(billID)
+			btx.MatchNote = fmt.Sprintf("reference %s matches but amount %d differs from expected %d", ref[0], btx.Amount, expected)
+			return tx.Save(btx).Error
+		}
+		return ApplyBankMatch(tx, btx, cand.bill, cand.inst, entity.PaymentMethodPromptPay, recordedBy, "matched by reference "+ref[0])
+	}
+
+	window := time.Duration(config.ReconcileWindowDays()) * 24 * time.Hour
+	from, to := btx.TransactedAt.Add(-window), btx.TransactedAt.Add(window)
+
+	candidates := []matchCandidate{}
+
+	var bills []entity.Bill
+	if err := tx.Where("status_id = ? AND uploaded_at BETWEEN ? AND ?", entity.BillStatusPending, from, to).Find(&bills).Error; err != nil {
+		return err
+	}
+	for _, b := range bills {
+		var n int64
+		if err := tx.Model(&entity.Installment{}).Where("bill_id = ?", b.ID).Count(&n).Error; err != nil {
+			return err
+		}
+		if n > 0 {
+			continue
+		}
+		bal, err := LedgerBalance(tx, b.ID)
+		if err != nil {
+			return err
+		}
+		if bal.Outstanding == btx.Amount {
+			candidates = append(candidates, matchCandidate{bill: b})
+		}
+	}
+
+	var insts []entity.Installment
+	if err := tx.Where("status_id = ? AND uploaded_at BETWEEN ? AND ?", entity.BillStatusPending, from, to).
+		Find(&insts).Error; err != nil {
+		return err
+	}
+	for i := range insts {
+		remaining, err := InstallmentRemaining(tx, insts[i])
+		if err != nil {
+			return err
+		}
+		if remaining != btx.Amount {
+			continue
+		}
+		var b entity.Bill
+		if err := tx.First(&b, insts[i].BillID).Error; err != nil {
+			return err
+		}
+		candidates = append(candidates, matchCandidate{bill: b, inst: &insts[i]})
+	}
+
+	switch len(candidates) {
+	case 0:
+		btx.MatchNote = "no bill with a pending receipt for this amount and date"
+		return tx.Save(btx).Error
+	case 1:
+		return ApplyBankMatch(tx, btx, candidates[0].bill, candidates[0].inst, entity.PaymentMethodTransfer, recordedBy, "matched by amount and date")
+	}
+
+	ids := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		ids = append(ids, strconv.Itoa(c.bill.ID))
+	}
+	btx.Status = entity.BankTxAmbiguous
+	btx.Candidates = strings.Join(ids, ",")
+	btx.MatchNote = fmt.Sprintf("%d bills match this amount and date", len(candidates))
+	return tx.Save(btx).Error
+}
+
+// บันทึกรับชำระจากรายการเดินบัญชีเข้าบิล (ใช้ทั้งการจับคู่อัตโนมัติและการยืนยันโดยเจ้าหน้าที่)
+// หลักฐานที่รอตรวจของบิล/งวดนั้นถือว่าอนุมัติแล้ว โดยผู้นำเข้าเป็นผู้ตรวจ
+func ApplyBankMatch(tx *gorm.DB, btx *entity.BankTransaction, bill entity.Bill, inst *entity.Installment, method, recordedBy, note string) error {
+	ref := btx.Reference
+	if ref == "" {
+		ref = fmt.Sprintf("BANK-%d", btx.ID)
+	}
+	payment := entity.Payment{
+		BillID:     bill.ID,
+		Type:       entity.PaymentTypePayment,
+		Method:     method,
+		Amount:     btx.Amount,
+		Reference:  ref,
+		Note:       "statement import: " + note,
+		PayerID:    bill.StudentID,
+		RecordedBy: recordedBy,
+		PaidAt:     btx.TransactedAt,
+	}
+	if inst != nil {
+		instID := inst.ID
+		payment.InstallmentID = &instID
+		btx.InstallmentID = &instID
+	}
+	if err := RecordPayment(tx, &payment); err != nil {
+		return err
+	}
+	if err := approveOpenReceipts(tx, bill.ID, payment.InstallmentID, recordedBy); err != nil {
+		return err
+	}
+
+	billID := bill.ID
+	btx.BillID = &billID
+	btx.PaymentID = &payment.ID
+	btx.Status = entity.BankTxMatched
+	btx.MatchNote = note
+	return tx.Save(btx).Error
+}
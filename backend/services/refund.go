@@ -0,0 +1,203 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"reg_system/entity"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRefundReviewed = errors.New("refund request has already been reviewed")
+	ErrRefundAmount   = errors.New("refund amount must be positive and within the bill's available credit")
+)
+
+// สัปดาห์ที่เท่าไรของภาคเรียน (สัปดาห์แรก = 1) ก่อนเปิดภาคคืนค่า 0
+func TeachingWeek(start, at time.Time) int {
+	if at.Before(start) {
+		return 0
+	}
+	return int(at.Sub(start).Hours()/24)/7 + 1
+}
+
+// ร้อยละที่คืนได้เมื่อถอนรายวิชาในวันที่ at ตามนโยบาย
+// ถ้าภาคเรียนยังไม่กำหนดวันเปิดภาค หรือยังไม่เปิดภาค คืนเต็มจำนวน
+func DropRefundPercent(tx *gorm.DB, semester entity.Semester, at time.Time) (int, error) {
+	if semester.StartDate == nil {
+		return 100, nil
+	}
+	week := TeachingWeek(*semester.StartDate, at)
+	if week == 0 {
+		return 100, nil
+	}
+
+	var policies []entity.RefundPolicy
+	if err := tx.Order("from_week ASC").Find(&policies).Error; err != nil {
+		return 0, err
+	}
+	for _, p := range policies {
+		if week >= p.FromWeek && (p.ToWeek == 0 || week <= p.ToWeek) {
+			return p.Percent, nil
+		}
+	}
+	// ไม่มีนโยบายครอบคลุมสัปดาห์นี้ ถือว่าไม่คืนเงิน
+	return 0, nil
+}
+
+// คิดค่าธรรมเนียมส่วนที่ไม่คืนเมื่อถอนรายวิชาหลังช่วงเพิ่ม-ถอน
+// ต้องเรียกก่อนลบรายการลงทะเบียน: ค่าธรรมเนียมเป็นรายการ Manual จึงคงอยู่หลังคำนวณบิลใหม่
+func ApplyDropPolicy(tx *gorm.DB, reg entity.Registration, at time.Time) error {
+	var subject entity.Subject
+	if err := tx.Preload("Semester").First(&subject, "subject_id = ?", reg.SubjectID).Error; err != nil {
+		return nil
+	}
+	if subject.Semester == nil {
+		return nil
+	}
+
+	var bill entity.Bill
+	err := tx.Where("student_id = ? AND academic_year = ? AND term = ?",
+		reg.StudentID, subject.Semester.AcademicYear, subject.Semester.Term).
+		Order("id DESC").First(&bill).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	percent, err := DropRefundPercent(tx, *subject.Semester, at)
+	if err != nil || percent >= 100 {
+		return err
+	}
+
+	var charged int
+	if err := tx.Model(&entity.BillItem{}).
+		Where("bill_id = ? AND registration_id = ? AND type IN ?", bill.ID, reg.ID,
+			[]string{entity.BillItemTuition, entity.BillItemLabFee}).
+		Select("COALESCE(SUM(amount), 0)").Scan(&charged).Error; err != nil {
+		return err
+	}
+	retained := charged * (100 - percent) / 100
+	if retained <= 0 {
+		return nil
+	}
+
+	return tx.Create(&entity.BillItem{
+		BillID:      bill.ID,
+		Type:        entity.BillItemPenalty,
+		Description: fmt.Sprintf("ค่าธรรมเนียมถอนรายวิชา %s (คืนเงิน %d%%)", reg.SubjectID, percent),
+		Amount:      retained,
+		SubjectID:   reg.SubjectID,
+		Manual:      true,
+	}).Error
+}
+
+// สร้างคำขอคืนเงิน ยอดที่ขอต้องไม่เกินยอดชำระเกินของบิล (0 = ขอคืนทั้งหมด)
+func CreateRefundRequest(tx *gorm.DB, req *entity.RefundRequest) error {
+	bal, err := LedgerBalance(tx, req.BillID)
+	if err != nil {
+		return err
+	}
+	if req.Amount == 0 {
+		req.Amount = bal.Credit
+	}
+	if req.Amount <= 0 || req.Amount > bal.Credit {
+		return ErrRefundAmount
+	}
+	if req.PaymentID != nil {
+		var count int64
+		if err := tx.Model(&entity.Payment{}).
+			Where("id = ? AND bill_id = ? AND type = ?", *req.PaymentID, req.BillID, entity.PaymentTypePayment).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return fmt.Errorf("%w: payment does not belong to bill", ErrInvalidPayment)
+		}
+	}
+	req.Status = entity.RefundPending
+	req.RequestedAt = time.Now()
+	return tx.Create(req).Error
+}
+
+// ฝ่ายการเงินพิจารณาคำขอคืนเงิน อนุมัติแล้วบันทึกรายการคืนเงินในสมุดบัญชีของบิล
+// amount = 0 ใช้ยอดที่ขอ
+func ReviewRefund(tx *gorm.DB, req *entity.RefundRequest, approve bool, amount int, method, reason, reviewer string) error {
+	if req.Status != entity.RefundPending {
+		return ErrRefundReviewed
+	}
+	now := time.Now()
+	req.ReviewedBy = reviewer
+	req.ReviewedAt = &now
+
+	if !approve {
+		req.Status = entity.RefundRejected
+		req.RejectReason = reason
+		return tx.Save(req).Error
+	}
+
+	if amount == 0 {
+		amount = req.Amount
+	}
+	if method == "" {
+		method = entity.PaymentMethodTransfer
+	}
+	p := entity.Payment{
+		BillID:     req.BillID,
+		Type:       entity.PaymentTypeRefund,
+		Method:     method,
+		Amount:     amount,
+		Reference:  fmt.Sprintf("REFUND-%d", req.ID),
+		Note:       req.Note,
+		PayerID:    req.StudentID,
+		RecordedBy: reviewer,
+	}
+	if err := RecordPayment(tx, &p); err != nil {
+		if errors.Is(err, ErrInsufficientCredit) || errors.Is(err, ErrInvalidPayment) {
+			return ErrRefundAmount
+		}
+		return err
+	}
+
+	req.Status = entity.RefundApproved
+	req.ApprovedAmount = amount
+	req.Method = method
+	req.RefundPaymentID = &p.ID
+	return tx.Save(req).Error
+}
+
+// ยอดรวมบัญชีของนักศึกษาจากทุกบิล
+type StudentAccount struct {
+	StudentID   string        `json:"student_id"`
+	Total       int           `json:"total"`
+	Net         int           `json:"net"`
+	Outstanding int           `json:"outstanding"`
+	Credit      int           `json:"credit"`
+	Bills       []BillBalance `json:"bills"`
+}
+
+func StudentAccountBalance(tx *gorm.DB, studentID string) (StudentAccount, error) {
+	acc := StudentAccount{StudentID: studentID, Bills: []BillBalance{}}
+
+	var bills []entity.Bill
+	if err := tx.Where("student_id = ?", studentID).Order("id ASC").Find(&bills).Error; err != nil {
+		return acc, err
+	}
+	for _, b := range bills {
+		var payments []entity.Payment
+		if err := tx.Where("bill_id = ?", b.ID).Find(&payments).Error; err != nil {
+			return acc, err
+		}
+		bal := computeBalance(b, payments)
+		acc.Total += bal.Total
+		acc.Net += bal.Net
+		acc.Outstanding += bal.Outstanding
+		acc.Credit += bal.Credit
+		acc.Bills = append(acc.Bills, bal)
+	}
+	return acc, nil
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"reg_system/entity"
+)
+
+func TestDropRefundPercent(t *testing.T) {
+	db := newTestDB(t, &entity.RefundPolicy{})
+	policies := []entity.RefundPolicy{
+		{FromWeek: 3, ToWeek: 4, Percent: 50},
+		{FromWeek: 1, ToWeek: 2, Percent: 100},
+		{FromWeek: 8, ToWeek: 0, Percent: 10},
+	}
+	if err := db.Create(&policies).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)
+	semester := entity.Semester{StartDate: &start}
+	day := func(n int) time.Time { return start.AddDate(0, 0, n) }
+
+	tests := []struct {
+		name     string
+		semester entity.Semester
+		at       time.Time
+		want     int
+	}{
+		{"no start date", entity.Semester{}, day(60), 100},
+		{"before start", semester, day(-1), 100},
+		{"week 1", semester, day(0), 100},
+		{"week 2 last day", semester, day(13), 100},
+		{"week 3", semester, day(14), 50},
+		{"week 4", semester, day(27), 50},
+		{"week 5 uncovered", semester, day(28), 0},
+		{"week 8 open ended", semester, day(49), 10},
+		{"week 20 open ended", semester, day(140), 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DropRefundPercent(db, tt.semester, tt.at)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("DropRefundPercent = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
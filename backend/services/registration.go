@@ -0,0 +1,27 @@
+package services
+
+import (
+	"reg_system/entity"
+
+	"gorm.io/gorm"
+)
+
+// ใช้กับ db.Scopes(...) เพื่อกรองเฉพาะรายการลงทะเบียนที่อาจารย์ที่ปรึกษาอนุมัติแล้ว
+// ใช้ทั้งตอนคำนวณบิลและตอนดึงรายชื่อนักศึกษาในรายวิชา
+func ApprovedRegistrations(db *gorm.DB) *gorm.DB {
+	return db.Where("registrations.status = ?", entity.RegistrationApproved)
+}
+
+// สถานะเริ่มต้นของการลงทะเบียนใหม่ ตามเงื่อนไขหลักสูตรของนักศึกษา
+func InitialRegistrationStatus(db *gorm.DB, studentID string) (string, error) {
+	var student entity.Students
+	if err := db.Preload("Curriculum").
+		First(&student, "student_id = ?", studentID).Error; err != nil {
+		return "", err
+	}
+
+	if student.Curriculum != nil && student.Curriculum.RequireAdvisorApproval {
+		return entity.RegistrationPending, nil
+	}
+	return entity.RegistrationApproved, nil
+}
@@ -0,0 +1,363 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"reg_system/entity"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrReportAction  = errors.New("post-approval action failed")
+	ErrReportActions = errors.New("invalid report actions")
+)
+
+// รายการในบิลที่ยกเว้นได้ด้วยคำร้อง
+var waivableBillItems = map[string]bool{
+	entity.BillItemTuition: true,
+	entity.BillItemTermFee: true,
+	entity.BillItemLabFee:  true,
+	entity.BillItemPenalty: true,
+}
+
+// ตรวจว่า key อ้างอิงช่องใน FormSchema ที่เป็นชนิด typ
+func checkActionField(rt *entity.ReportType, key, typ string, required bool) error {
+	if key == "" {
+		if required {
+			return fmt.Errorf("needs a %s field", typ)
+		}
+		return nil
+	}
+	for _, f := range rt.FormSchema {
+		if f.Key == key {
+			if f.Type != typ {
+				return fmt.Errorf("field %s must be of type %s", key, typ)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("field %s is not in the form schema", key)
+}
+
+func checkReportAction(rt *entity.ReportType, a entity.ReportAction) error {
+	switch a.Type {
+	case entity.ReportActionChangeStatus:
+		if _, ok := statusTransitions[a.Status]; !ok {
+			return fmt.Errorf("unknown student status %q", a.Status)
+		}
+		if a.TermsField != "" && a.Status != entity.StatusOnLeave {
+			return errors.New("TermsField is only allowed for leave of absence")
+		}
+		if err := checkActionField(rt, a.TermsField, entity.ReportFieldNumber, false); err != nil {
+			return err
+		}
+		// ลาพัก กลับเข้าศึกษา ลาออก คืนสภาพ ต้องผ่านคำร้องเปลี่ยนสถานภาพซึ่งต้องระบุภาคการศึกษาที่มีผล
+		return checkActionField(rt, a.SemesterField, entity.ReportFieldSemester, statusRequiresRequest(a.Status))
+	case entity.ReportActionAddRegistration, entity.ReportActionDropRegistration:
+		return checkActionField(rt, a.SubjectField, entity.ReportFieldSubject, true)
+	case entity.ReportActionWaiveBillItem:
+		if !waivableBillItems[a.ItemType] {
+			return fmt.Errorf("ItemType must be tuition, term_fee, lab_fee or penalty")
+		}
+		if err := checkActionField(rt, a.SemesterField, entity.ReportFieldSemester, true); err != nil {
+			return err
+		}
+		return checkActionField(rt, a.SubjectField, entity.ReportFieldSubject, false)
+	case entity.ReportActionExtendIncomplete:
+		if err := checkActionField(rt, a.SubjectField, entity.ReportFieldSubject, true); err != nil {
+			return err
+		}
+		if a.DateField == "" && a.Days <= 0 {
+			return errors.New("needs DateField or Days")
+		}
+		return checkActionField(rt, a.DateField, entity.ReportFieldDate, false)
+	}
+	return fmt.Errorf("unknown action type %q", a.Type)
+}
+
+// ตรวจและบันทึกการดำเนินการหลังอนุมัติของประเภทคำร้อง (ต้องกำหนด FormSchema ก่อน)
+func SetReportActions(tx *gorm.DB, rt *entity.ReportType, actions []entity.ReportAction) error {
+	for i, a := range actions {
+		if err := checkReportAction(rt, a); err != nil {
+			return fmt.Errorf("%w: action %d: %v", ErrReportActions, i+1, err)
+		}
+	}
+	rt.Actions = actions
+	return tx.Model(rt).Select("Actions").Updates(&entity.ReportType{Actions: actions}).Error
+}
+
+// ทำการดำเนินการทั้งหมดของประเภทคำร้องตามลำดับ และบันทึกผลลงคำร้อง
+// ถ้ารายการใดล้มเหลวจะคืน error เพื่อให้ transaction ของการอนุมัติย้อนกลับทั้งหมด
+func RunReportActions(tx *gorm.DB, rep *entity.Report, rt *entity.ReportType, actor string) error {
+	for i, a := range rt.Actions {
+		detail, ref, err := runReportAction(tx, rep, a, actor)
+		if err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrReportAction, a.Type, err)
+		}
+		if err := tx.Create(&entity.ReportActionLog{
+			Report_id: rep.Report_id,
+			Seq:       i + 1,
+			Type:      a.Type,
+			Detail:    detail,
+			Ref:       ref,
+			Actor:     actor,
+			CreatedAt: time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func reportFormValue(rep *entity.Report, key string) (string, error) {
+	v := rep.FormData[key]
+	if v == "" {
+		return "", fmt.Errorf("form field %s is empty", key)
+	}
+	return v, nil
+}
+
+func runReportAction(tx *gorm.DB, rep *entity.Report, a entity.ReportAction, actor string) (detail, ref string, err error) {
+	reason := "ตามคำร้อง " + rep.Report_id
+	now := time.Now()
+
+	switch a.Type {
+	case entity.ReportActionChangeStatus:
+		return changeStatusForReport(tx, rep, a, actor, reason)
+
+	case entity.ReportActionAddRegistration:
+		subjectID, err := reportFormValue(rep, a.SubjectField)
+		if err != nil {
+			return "", "", err
+		}
+		var subject entity.Subject
+		if err := tx.First(&subject, "subject_id = ?", subjectID).Error; err != nil {
+			return "", "", err
+		}
+		var student entity.Students
+		if err := tx.First(&student, "student_id = ?", rep.StudentID).Error; err != nil {
+			return "", "", err
+		}
+		if !StatusAllowsRegistration(student.StatusStudentID) {
+			return "", "", ErrRegistrationStatus
+		}
+		holds, err := BlockingHolds(tx, rep.StudentID, HoldActionRegistration)
+		if err != nil {
+			return "", "", err
+		}
+		if len(holds) > 0 {
+			types := make([]string, 0, len(holds))
+			for _, h := range holds {
+				types = append(types, h.Type)
+			}
+			return "", "", fmt.Errorf("%w: %s", ErrRegistrationHold, strings.Join(types, ", "))
+		}
+		var cnt int64
+		if err := tx.Model(&entity.Registration{}).
+			Where("student_id = ? AND subject_id = ?", rep.StudentID, subjectID).Count(&cnt).Error; err != nil {
+			return "", "", err
+		}
+		if cnt > 0 {
+			return "", "", fmt.Errorf("subject %s is already registered", subjectID)
+		}
+		reg := entity.Registration{
+			Date:           now,
+			SubjectID:      subjectID,
+			SemesterID:     subject.SemesterID,
+			StudentID:      rep.StudentID,
+			Status:         entity.RegistrationApproved,
+			AdvisorComment: reason,
+			ReviewedBy:     actor,
+			ReviewedAt:     &now,
+		}
+		if err := tx.Create(&reg).Error; err != nil {
+			return "", "", err
+		}
+		if err := RefreshBillForRegistration(tx, reg); err != nil {
+			return "", "", err
+		}
+		return fmt.Sprintf("ลงทะเบียนรายวิชา %s", subjectID), reg.RegistrationID, nil
+
+	case entity.ReportActionDropRegistration:
+		subjectID, err := reportFormValue(rep, a.SubjectField)
+		if err != nil {
+			return "", "", err
+		}
+		var reg entity.Registration
+		if err := tx.Where("student_id = ? AND subject_id = ?", rep.StudentID, subjectID).First(&reg).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return "", "", fmt.Errorf("subject %s is not registered", subjectID)
+			}
+			return "", "", err
+		}
+		if err := ApplyDropPolicy(tx, reg, now); err != nil {
+			return "", "", err
+		}
+		if err := tx.Delete(&reg).Error; err != nil {
+			return "", "", err
+		}
+		if err := RefreshBillForRegistration(tx, reg); err != nil {
+			return "", "", err
+		}
+		return fmt.Sprintf("ถอนรายวิชา %s", subjectID), reg.RegistrationID, nil
+
+	case entity.ReportActionWaiveBillItem:
+		return waiveBillItemForReport(tx, rep, a, reason, actor)
+
+	case entity.ReportActionExtendIncomplete:
+		subjectID, err := reportFormValue(rep, a.SubjectField)
+		if err != nil {
+			return "", "", err
+		}
+		var grade entity.Grades
+		if err := tx.Where("student_id = ? AND subject_id = ?", rep.StudentID, subjectID).First(&grade).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return "", "", fmt.Errorf("no grade for subject %s", subjectID)
+			}
+			return "", "", err
+		}
+		if grade.Grade != entity.GradeIncomplete {
+			return "", "", fmt.Errorf("grade of subject %s is not %s", subjectID, entity.GradeIncomplete)
+		}
+		var deadline time.Time
+		if a.DateField != "" {
+			v, err := reportFormValue(rep, a.DateField)
+			if err != nil {
+				return "", "", err
+			}
+			if deadline, err = time.Parse("2006-01-02", v); err != nil {
+				return "", "", err
+			}
+		} else {
+			base := now
+			if grade.IncompleteDeadline != nil && grade.IncompleteDeadline.After(now) {
+				base = *grade.IncompleteDeadline
+			}
+			deadline = base.AddDate(0, 0, a.Days)
+		}
+		if err := tx.Model(&grade).Update("incomplete_deadline", deadline).Error; err != nil {
+			return "", "", err
+		}
+		return fmt.Sprintf("ขยายกำหนดแก้เกรด I รายวิชา %s ถึง %s", subjectID, deadline.Format("2006-01-02")),
+			strconv.Itoa(grade.ID), nil
+	}
+	return "", "", fmt.Errorf("unknown action type %q", a.Type)
+}
+
+// เปลี่ยนสถานภาพตามคำร้อง ลาพัก กลับเข้าศึกษา ลาออก และคืนสภาพ สร้างคำร้องเปลี่ยนสถานภาพที่อนุมัติแล้ว
+// จึงผ่านการตรวจสถานภาพต้นทาง จำนวนภาคที่ลาพักได้ (MAX_LEAVE_TERMS) และนับรวมใน UsedLeaveTerms
+// สถานภาพอื่นเปลี่ยนโดยตรงตามเส้นทางที่อนุญาต
+func changeStatusForReport(tx *gorm.DB, rep *entity.Report, a entity.ReportAction, actor, reason string) (string, string, error) {
+	var semesterID *int
+	if a.SemesterField != "" {
+		v, err := reportFormValue(rep, a.SemesterField)
+		if err != nil {
+			return "", "", err
+		}
+		sem, _ := strconv.Atoi(v)
+		semesterID = &sem
+	}
+	detail := fmt.Sprintf("เปลี่ยนสถานภาพนักศึกษาเป็น %s", a.Status)
+
+	var student entity.Students
+	if err := tx.First(&student, "student_id = ?", rep.StudentID).Error; err != nil {
+		return "", "", err
+	}
+	typ, ok := StatusRequestTypeFor(student.StatusStudentID, a.Status)
+	if !ok {
+		if statusRequiresRequest(a.Status) {
+			return "", "", fmt.Errorf("%w: %s -> %s", ErrStatusTransition, student.StatusStudentID, a.Status)
+		}
+		change := StatusChange{EffectiveSemesterID: semesterID, Reason: reason, ApprovedBy: actor}
+		if err := ChangeStudentStatus(tx, rep.StudentID, a.Status, change); err != nil {
+			return "", "", err
+		}
+		return detail, rep.StudentID, nil
+	}
+	if semesterID == nil {
+		return "", "", errors.New("effective semester is required")
+	}
+
+	req := entity.StatusRequest{
+		StudentID:           rep.StudentID,
+		Type:                typ,
+		Reason:              reason,
+		EffectiveSemesterID: *semesterID,
+		RequestedBy:         rep.StudentID,
+	}
+	if a.TermsField != "" {
+		if v := rep.FormData[a.TermsField]; v != "" {
+			terms, err := strconv.ParseFloat(v, 64)
+			if err != nil || terms != float64(int(terms)) {
+				return "", "", fmt.Errorf("form field %s must be a whole number of terms", a.TermsField)
+			}
+			req.Terms = int(terms)
+		}
+	}
+	if err := CreateStatusRequest(tx, &req); err != nil {
+		return "", "", err
+	}
+	if err := ReviewStatusRequest(tx, &req, true, "", actor); err != nil {
+		return "", "", err
+	}
+	if req.Status == entity.StatusRequestScheduled {
+		detail = fmt.Sprintf("อนุมัติเปลี่ยนสถานภาพนักศึกษาเป็น %s เมื่อเริ่มภาคการศึกษา %d", a.Status, req.EffectiveSemesterID)
+	}
+	return detail, strconv.Itoa(req.ID), nil
+}
+
+// ยกเว้นรายการในบิลโดยบันทึก BillWaiver ที่ผูกกับประเภทรายการ (และรายวิชา)
+// ส่วนลดคำนวณใหม่ทุกครั้งที่คำนวณบิล จึงตามรายวิชาที่เพิ่ม/ถอนภายหลัง
+func waiveBillItemForReport(tx *gorm.DB, rep *entity.Report, a entity.ReportAction, reason, actor string) (string, string, error) {
+	v, err := reportFormValue(rep, a.SemesterField)
+	if err != nil {
+		return "", "", err
+	}
+	var semester entity.Semester
+	if err := tx.First(&semester, "id = ?", v).Error; err != nil {
+		return "", "", err
+	}
+	var bill entity.Bill
+	if err := tx.Where("student_id = ? AND academic_year = ? AND term = ?", rep.StudentID, semester.AcademicYear, semester.Term).
+		Order("id DESC").First(&bill).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", "", fmt.Errorf("no bill for %d/%d", semester.Term, semester.AcademicYear)
+		}
+		return "", "", err
+	}
+
+	waiver := entity.BillWaiver{
+		BillID:    bill.ID,
+		ItemType:  a.ItemType,
+		Report_id: rep.Report_id,
+		Reason:    reason,
+		CreatedBy: actor,
+		CreatedAt: time.Now(),
+	}
+	if a.SubjectField != "" {
+		if waiver.SubjectID, err = reportFormValue(rep, a.SubjectField); err != nil {
+			return "", "", err
+		}
+	}
+	if err := tx.Create(&waiver).Error; err != nil {
+		return "", "", err
+	}
+	if _, err := RefreshBill(tx, rep.StudentID, semester.AcademicYear, semester.Term); err != nil {
+		return "", "", err
+	}
+
+	var amount int
+	if err := tx.Model(&entity.BillItem{}).Where("bill_waiver_id = ?", waiver.ID).
+		Select("COALESCE(SUM(amount), 0)").Scan(&amount).Error; err != nil {
+		return "", "", err
+	}
+	if amount == 0 {
+		return "", "", fmt.Errorf("bill %d has no %s item to waive", bill.ID, a.ItemType)
+	}
+	return fmt.Sprintf("%s (%d บาท)", billWaiverDescription(waiver), -amount), strconv.Itoa(waiver.ID), nil
+}
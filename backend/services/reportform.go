@@ -0,0 +1,226 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"reg_system/config"
+	"reg_system/entity"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrReportForm       = errors.New("invalid report form")
+	ErrReportFormSchema = errors.New("invalid report form schema")
+)
+
+var reportFieldKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+var reportFieldTypes = map[string]bool{
+	entity.ReportFieldText:     true,
+	entity.ReportFieldDate:     true,
+	entity.ReportFieldSubject:  true,
+	entity.ReportFieldSemester: true,
+	entity.ReportFieldNumber:   true,
+	entity.ReportFieldFile:     true,
+}
+
+// ตรวจและบันทึก FormSchema ของประเภทคำร้อง
+// การดำเนินการหลังอนุมัติ (Actions) ที่มีอยู่ต้องยังอ้างอิงช่องในฟอร์มใหม่ได้ครบ
+func SetReportFormSchema(tx *gorm.DB, rt *entity.ReportType, fields []entity.ReportFormField) error {
+	seen := map[string]bool{}
+	for i := range fields {
+		f := &fields[i]
+		f.Key = strings.TrimSpace(f.Key)
+		f.Label = strings.TrimSpace(f.Label)
+		switch {
+		case !reportFieldKeyPattern.MatchString(f.Key):
+			return fmt.Errorf("%w: field %d key must be lowercase letters, digits or _", ErrReportFormSchema, i+1)
+		case seen[f.Key]:
+			return fmt.Errorf("%w: duplicate key %s", ErrReportFormSchema, f.Key)
+		case !reportFieldTypes[f.Type]:
+			return fmt.Errorf("%w: %s has unknown type %q (text, date, subject, semester, number, file)", ErrReportFormSchema, f.Key, f.Type)
+		case f.Type != entity.ReportFieldNumber && (f.Min != nil || f.Max != nil):
+			return fmt.Errorf("%w: %s: Min/Max are only allowed on number fields", ErrReportFormSchema, f.Key)
+		case f.Min != nil && f.Max != nil && *f.Min > *f.Max:
+			return fmt.Errorf("%w: %s: Min is greater than Max", ErrReportFormSchema, f.Key)
+		}
+		if f.Label == "" {
+			f.Label = f.Key
+		}
+		seen[f.Key] = true
+	}
+
+	next := *rt
+	next.FormSchema = fields
+	for i, a := range rt.Actions {
+		if err := checkReportAction(&next, a); err != nil {
+			return fmt.Errorf("%w: action %d (%s) no longer matches the form: %v", ErrReportFormSchema, i+1, a.Type, err)
+		}
+	}
+
+	rt.FormSchema = fields
+	return tx.Model(rt).Select("FormSchema").Updates(&entity.ReportType{FormSchema: fields}).Error
+}
+
+// แปลง form_data (JSON object) จากฟอร์ม ค่าต้องเป็นข้อความหรือตัวเลข
+func ParseReportFormData(raw string) (map[string]string, error) {
+	values := map[string]string{}
+	if strings.TrimSpace(raw) == "" {
+		return values, nil
+	}
+	dec := json.NewDecoder(strings.NewReader(raw))
+	dec.UseNumber()
+	var obj map[string]any
+	if err := dec.Decode(&obj); err != nil {
+		return nil, fmt.Errorf("%w: form_data must be a JSON object", ErrReportForm)
+	}
+	for k, v := range obj {
+		switch v := v.(type) {
+		case nil:
+		case string:
+			values[k] = v
+		case json.Number:
+			values[k] = v.String()
+		default:
+			return nil, fmt.Errorf("%w: %s must be a string or number", ErrReportForm, k)
+		}
+	}
+	return values, nil
+}
+
+// ตรวจคำตอบตาม FormSchema และคืนค่าที่จัดรูปแล้ว (วันที่ YYYY-MM-DD, ตัวเลขไม่มีศูนย์เกิน)
+// files คือ key ของช่องชนิด file ที่มีไฟล์แนบมา ค่าของช่องเหล่านี้ผู้เรียกเติมเป็นรหัส Attachment เอง
+func ValidateReportForm(tx *gorm.DB, rt *entity.ReportType, values map[string]string, files map[string]bool) (map[string]string, error) {
+	fields := map[string]entity.ReportFormField{}
+	for _, f := range rt.FormSchema {
+		fields[f.Key] = f
+	}
+
+	var problems []string
+	for k := range values {
+		if f, ok := fields[k]; !ok || f.Type == entity.ReportFieldFile {
+			problems = append(problems, k+": unknown field")
+		}
+	}
+	for k := range files {
+		if f, ok := fields[k]; !ok || f.Type != entity.ReportFieldFile {
+			problems = append(problems, k+": not a file field")
+		}
+	}
+	sort.Strings(problems)
+
+	out := map[string]string{}
+	for _, f := range rt.FormSchema {
+		if f.Type == entity.ReportFieldFile {
+			if f.Required && !files[f.Key] {
+				problems = append(problems, f.Key+": file is required")
+			}
+			continue
+		}
+		v := strings.TrimSpace(values[f.Key])
+		if v == "" {
+			if f.Required {
+				problems = append(problems, f.Key+": is required")
+			}
+			continue
+		}
+		norm, err := normalizeReportField(tx, f, v)
+		if err != nil {
+			problems = append(problems, f.Key+": "+err.Error())
+			continue
+		}
+		out[f.Key] = norm
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrReportForm, strings.Join(problems, "; "))
+	}
+	return out, nil
+}
+
+func normalizeReportField(tx *gorm.DB, f entity.ReportFormField, v string) (string, error) {
+	switch f.Type {
+	case entity.ReportFieldDate:
+		d, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return "", errors.New("must be a date in YYYY-MM-DD format")
+		}
+		return d.Format("2006-01-02"), nil
+	case entity.ReportFieldNumber:
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return "", errors.New("must be a number")
+		}
+		if f.Min != nil && n < *f.Min {
+			return "", fmt.Errorf("must be at least %v", *f.Min)
+		}
+		if f.Max != nil && n > *f.Max {
+			return "", fmt.Errorf("must be at most %v", *f.Max)
+		}
+		return strconv.FormatFloat(n, 'f', -1, 64), nil
+	case entity.ReportFieldSubject:
+		var cnt int64
+		if err := tx.Model(&entity.Subject{}).Where("subject_id = ?", v).Count(&cnt).Error; err != nil {
+			return "", err
+		}
+		if cnt == 0 {
+			return "", fmt.Errorf("subject %s not found", v)
+		}
+	case entity.ReportFieldSemester:
+		if _, err := strconv.Atoi(v); err != nil {
+			return "", errors.New("must be a semester id")
+		}
+		var cnt int64
+		if err := tx.Model(&entity.Semester{}).Where("id = ?", v).Count(&cnt).Error; err != nil {
+			return "", err
+		}
+		if cnt == 0 {
+			return "", fmt.Errorf("semester %s not found", v)
+		}
+	}
+	return v, nil
+}
+
+// ชนิดไฟล์ (ตรวจจากเนื้อไฟล์) ที่รับได้ของช่องชนิด file
+var reportFormFileMIMETypes = []string{"image/jpeg", "image/png", "application/pdf"}
+
+// ตรวจไฟล์ของช่องชนิด file ก่อนบันทึก ขนาดไม่เกิน DOCUMENT_MAX_SIZE_KB และเป็นรูปหรือ PDF
+func CheckReportFormFile(key string, file *multipart.FileHeader) error {
+	if limit := config.DocumentMaxSize(); file.Size > limit {
+		return fmt.Errorf("%w: %s: maximum is %d KB", ErrDocumentTooLarge, key, limit/1024)
+	}
+	mime, err := SniffUpload(file)
+	if err != nil {
+		return err
+	}
+	for _, m := range reportFormFileMIMETypes {
+		if m == mime {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s: %s (allowed: %s)", ErrDocumentMIME, key, mime, strings.Join(reportFormFileMIMETypes, ", "))
+}
+
+// บันทึกคำตอบลงตาราง ReportFieldValue สำหรับใช้กรองคำร้อง
+func SaveReportFieldValues(tx *gorm.DB, reportID string, data map[string]string) error {
+	if err := tx.Where("report_id = ?", reportID).Delete(&entity.ReportFieldValue{}).Error; err != nil {
+		return err
+	}
+	rows := make([]entity.ReportFieldValue, 0, len(data))
+	for k, v := range data {
+		rows = append(rows, entity.ReportFieldValue{Report_id: reportID, Key: k, Value: v})
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return tx.Create(&rows).Error
+}
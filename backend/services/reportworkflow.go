@@ -0,0 +1,340 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"reg_system/config"
+	"reg_system/entity"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrReportTransition  = errors.New("status change is not allowed for this report")
+	ErrReportActor       = errors.New("only the reviewer of the current step can change this report")
+	ErrReportAttachments = errors.New("required attachments are missing")
+	ErrReportReviewer    = errors.New("cannot find a reviewer for this step")
+	ErrReportWorkflow    = errors.New("invalid report workflow")
+)
+
+// การเปลี่ยนสถานะเมื่อประเภทคำร้องไม่ได้กำหนด Transitions ไว้
+var defaultReportTransitions = []entity.ReportTransition{
+	{From: entity.ReportStatusPending, To: entity.ReportStatusApproved, By: entity.ReportActorReviewer},
+	{From: entity.ReportStatusPending, To: entity.ReportStatusRejected, By: entity.ReportActorReviewer},
+	{From: entity.ReportStatusPending, To: entity.ReportStatusReturned, By: entity.ReportActorReviewer},
+	{From: entity.ReportStatusReturned, To: entity.ReportStatusPending, By: entity.ReportActorStudent},
+	{From: entity.ReportStatusPending, To: entity.ReportStatusCancelled, By: entity.ReportActorStudent},
+	{From: entity.ReportStatusReturned, To: entity.ReportStatusCancelled, By: entity.ReportActorStudent},
+}
+
+// ชื่อขั้นเริ่มต้นตามผู้พิจารณา
+var reportStepNames = map[string]string{
+	entity.ReportStepAdvisor:        "อาจารย์ที่ปรึกษา",
+	entity.ReportStepDepartmentHead: "หัวหน้าสาขาวิชา",
+	entity.ReportStepRegistrar:      "งานทะเบียน",
+}
+
+// การเปลี่ยนสถานะที่ใช้กับประเภทคำร้องนี้
+func ReportTransitions(rt *entity.ReportType) []entity.ReportTransition {
+	if len(rt.Transitions) > 0 {
+		return rt.Transitions
+	}
+	return defaultReportTransitions
+}
+
+func findReportTransition(rt *entity.ReportType, from, to string) (entity.ReportTransition, bool) {
+	for _, t := range ReportTransitions(rt) {
+		if t.From == from && t.To == to {
+			return t, true
+		}
+	}
+	return entity.ReportTransition{}, false
+}
+
+// โหลดประเภทคำร้องพร้อมขั้นการพิจารณาเรียงตามลำดับ
+func LoadReportType(tx *gorm.DB, id string) (*entity.ReportType, error) {
+	var rt entity.ReportType
+	err := tx.Preload("Steps", func(db *gorm.DB) *gorm.DB { return db.Order("seq") }).
+		Where("report_type_id = ?", id).First(&rt).Error
+	if err != nil {
+		return nil, err
+	}
+	return &rt, nil
+}
+
+func reportStep(rt *entity.ReportType, seq int) *entity.ReportWorkflowStep {
+	for i := range rt.Steps {
+		if rt.Steps[i].Seq == seq {
+			return &rt.Steps[i]
+		}
+	}
+	return nil
+}
+
+func nextReportStep(rt *entity.ReportType, seq int) *entity.ReportWorkflowStep {
+	for i := range rt.Steps {
+		if rt.Steps[i].Seq > seq {
+			return &rt.Steps[i]
+		}
+	}
+	return nil
+}
+
+// รหัส Reviewer ของบัญชีผู้ใช้ (สร้างให้ถ้ายังไม่มี)
+func ReviewerForUsername(tx *gorm.DB, username string) (string, error) {
+	var user entity.Users
+	if err := tx.Where("username = ?", username).First(&user).Error; err != nil {
+		return "", err
+	}
+	if err := EnsureReviewer(tx, user.ID); err != nil {
+		return "", err
+	}
+	var rv entity.Reviewer
+	if err := tx.Where("user_id = ?", user.ID).First(&rv).Error; err != nil {
+		return "", err
+	}
+	return rv.Reviewer_id, nil
+}
+
+// หาผู้พิจารณาของขั้นสำหรับนักศึกษาคนนี้
+func ResolveStepReviewer(tx *gorm.DB, step *entity.ReportWorkflowStep, studentID string) (string, error) {
+	if step.Reviewer_id != "" {
+		return step.Reviewer_id, nil
+	}
+
+	var username string
+	switch step.Role {
+	case entity.ReportStepAdvisor, entity.ReportStepDepartmentHead:
+		var student entity.Students
+		if err := tx.Preload("Major").First(&student, "student_id = ?", studentID).Error; err != nil {
+			return "", err
+		}
+		if step.Role == entity.ReportStepAdvisor {
+			username = student.AdvisorID
+		} else if student.Major != nil {
+			username = student.Major.HeadTeacherID
+		}
+	case entity.ReportStepRegistrar:
+		username = config.ReportRegistrarUsername()
+	}
+	if username == "" {
+		return "", fmt.Errorf("%w: %s", ErrReportReviewer, step.Name)
+	}
+
+	rid, err := ReviewerForUsername(tx, username)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", fmt.Errorf("%w: %s (%s)", ErrReportReviewer, step.Name, username)
+	}
+	return rid, err
+}
+
+// ตั้งค่าเริ่มต้นของคำร้องใหม่: สถานะรอดำเนินการ และมอบหมายผู้พิจารณาขั้นแรก
+// ประเภทที่ไม่มีขั้นการพิจารณาจะใช้ Reviewer_id ที่นักศึกษาเลือกตามเดิม
+// ยกเว้นประเภทที่มีการดำเนินการหลังอนุมัติ (Actions) ซึ่งต้องกำหนดขั้นการพิจารณา ไม่ให้นักศึกษาเลือกผู้อนุมัติเอง
+func StartReportWorkflow(tx *gorm.DB, rep *entity.Report, rt *entity.ReportType) error {
+	rep.Status = entity.ReportStatusPending
+	if len(rt.Steps) == 0 {
+		if len(rt.Actions) > 0 {
+			return fmt.Errorf("%w: report types with post-approval actions need workflow steps", ErrReportWorkflow)
+		}
+		if rep.Reviewer_id == "" {
+			return fmt.Errorf("%w: reviewer_id is required", ErrReportReviewer)
+		}
+		return nil
+	}
+	first := &rt.Steps[0]
+	rid, err := ResolveStepReviewer(tx, first, rep.StudentID)
+	if err != nil {
+		return err
+	}
+	rep.CurrentStep = first.Seq
+	rep.Reviewer_id = rid
+	return nil
+}
+
+// บันทึกประวัติการยื่นคำร้อง (เรียกหลังสร้างคำร้องแล้ว)
+func LogReportSubmitted(tx *gorm.DB, rep *entity.Report, rt *entity.ReportType, actor string) error {
+	log := entity.ReportStatusLog{
+		Report_id:   rep.Report_id,
+		Step:        rep.CurrentStep,
+		Reviewer_id: rep.Reviewer_id,
+		ToStatus:    rep.Status,
+		Actor:       actor,
+		CreatedAt:   time.Now(),
+	}
+	if step := reportStep(rt, rep.CurrentStep); step != nil {
+		log.StepName = step.Name
+	}
+	return tx.Create(&log).Error
+}
+
+// ชนิดเอกสารแนบที่ประเภทคำร้องกำหนดแต่คำร้องนี้ยังไม่มี
+func MissingReportAttachments(tx *gorm.DB, rep *entity.Report, rt *entity.ReportType) ([]string, error) {
+	if len(rt.RequiredAttachments) == 0 {
+		return nil, nil
+	}
+	var kinds []string
+	if err := tx.Model(&entity.Attachment{}).Where("report_id = ?", rep.Report_id).
+		Distinct().Pluck("kind", &kinds).Error; err != nil {
+		return nil, err
+	}
+	have := map[string]bool{}
+	for _, k := range kinds {
+		have[k] = true
+	}
+	var missing []string
+	for _, k := range rt.RequiredAttachments {
+		if !have[k] {
+			missing = append(missing, k)
+		}
+	}
+	return missing, nil
+}
+
+// ผู้เปลี่ยนสถานะคำร้อง
+type ReportActor struct {
+	Username string
+	Role     string
+}
+
+func authorizeReportActor(tx *gorm.DB, rep *entity.Report, by string, actor ReportActor) error {
+	if actor.Role == "admin" {
+		return nil
+	}
+	if by == entity.ReportActorStudent {
+		if actor.Role == "student" && actor.Username == rep.StudentID {
+			return nil
+		}
+		return ErrReportActor
+	}
+	if actor.Role == "student" {
+		return ErrReportActor
+	}
+	var user entity.Users
+	err := tx.Joins("JOIN reviewers ON reviewers.user_id = users.id").
+		Where("reviewers.reviewer_id = ?", rep.Reviewer_id).First(&user).Error
+	if err != nil || !strings.EqualFold(user.Username, actor.Username) {
+		return ErrReportActor
+	}
+	return nil
+}
+
+// เปลี่ยนสถานะคำร้องตาม workflow ของประเภทคำร้อง
+// การอนุมัติขั้นที่ยังไม่ใช่ขั้นสุดท้ายจะส่งต่อให้ผู้พิจารณาขั้นถัดไป (สถานะยังเป็นรอดำเนินการ)
+func ChangeReportStatus(tx *gorm.DB, rep *entity.Report, to, comment string, actor ReportActor) error {
+	rt, err := LoadReportType(tx, rep.ReportType_id)
+	if err != nil {
+		return err
+	}
+	tr, ok := findReportTransition(rt, rep.Status, to)
+	if !ok {
+		return fmt.Errorf("%w: %s -> %s", ErrReportTransition, rep.Status, to)
+	}
+	if err := authorizeReportActor(tx, rep, tr.By, actor); err != nil {
+		return err
+	}
+	if to == entity.ReportStatusApproved {
+		missing, err := MissingReportAttachments(tx, rep, rt)
+		if err != nil {
+			return err
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("%w: %s", ErrReportAttachments, strings.Join(missing, ", "))
+		}
+	}
+
+	log := entity.ReportStatusLog{
+		Report_id:   rep.Report_id,
+		Step:        rep.CurrentStep,
+		Reviewer_id: rep.Reviewer_id,
+		FromStatus:  rep.Status,
+		ToStatus:    to,
+		Comment:     comment,
+		Actor:       actor.Username,
+		CreatedAt:   time.Now(),
+	}
+	if step := reportStep(rt, rep.CurrentStep); step != nil {
+		log.StepName = step.Name
+	}
+
+	rep.Status = to
+	// คำร้องที่ยื่นก่อนมี workflow (CurrentStep = 0) อนุมัติครั้งเดียวจบ
+	if to == entity.ReportStatusApproved && rep.CurrentStep > 0 {
+		if next := nextReportStep(rt, rep.CurrentStep); next != nil {
+			rid, err := ResolveStepReviewer(tx, next, rep.StudentID)
+			if err != nil {
+				return err
+			}
+			rep.Status = entity.ReportStatusPending
+			rep.CurrentStep = next.Seq
+			rep.Reviewer_id = rid
+		}
+	}
+
+	if err := tx.Model(&entity.Report{}).Where("report_id = ?", rep.Report_id).Updates(map[string]interface{}{
+		"status":       rep.Status,
+		"current_step": rep.CurrentStep,
+		"reviewer_id":  rep.Reviewer_id,
+		"updated_at":   time.Now(),
+	}).Error; err != nil {
+		return err
+	}
+	if err := tx.Create(&log).Error; err != nil {
+		return err
+	}
+	// อนุมัติขั้นสุดท้ายแล้ว ทำการดำเนินการของประเภทคำร้องใน transaction เดียวกัน
+	if rep.Status == entity.ReportStatusApproved {
+		return RunReportActions(tx, rep, rt, actor.Username)
+	}
+	return nil
+}
+
+// แทนที่ขั้นการพิจารณา การเปลี่ยนสถานะ และเอกสารที่ต้องแนบของประเภทคำร้อง
+// ลำดับขั้นเรียงตามที่ส่งมา (Seq เริ่มที่ 1)
+func SetReportWorkflow(tx *gorm.DB, rt *entity.ReportType, steps []entity.ReportWorkflowStep, transitions []entity.ReportTransition, required []string) error {
+	for i := range steps {
+		s := &steps[i]
+		if _, ok := reportStepNames[s.Role]; !ok && s.Reviewer_id == "" {
+			return fmt.Errorf("%w: step %d needs a role (advisor, department_head, registrar) or Reviewer_id", ErrReportWorkflow, i+1)
+		}
+		if s.Reviewer_id != "" {
+			var cnt int64
+			if err := tx.Model(&entity.Reviewer{}).Where("reviewer_id = ?", s.Reviewer_id).Count(&cnt).Error; err != nil {
+				return err
+			}
+			if cnt == 0 {
+				return fmt.Errorf("%w: reviewer %s not found", ErrReportWorkflow, s.Reviewer_id)
+			}
+		}
+		s.ID = 0
+		s.ReportType_id = rt.ReportType_id
+		s.Seq = i + 1
+		if s.Name == "" {
+			s.Name = reportStepNames[s.Role]
+		}
+	}
+	for _, t := range transitions {
+		if t.From == "" || t.To == "" || (t.By != entity.ReportActorReviewer && t.By != entity.ReportActorStudent) {
+			return fmt.Errorf("%w: transition needs From, To and By (reviewer or student)", ErrReportWorkflow)
+		}
+	}
+
+	if err := tx.Where("report_type_id = ?", rt.ReportType_id).Delete(&entity.ReportWorkflowStep{}).Error; err != nil {
+		return err
+	}
+	if len(steps) > 0 {
+		if err := tx.Create(&steps).Error; err != nil {
+			return err
+		}
+	}
+	rt.Steps = steps
+	rt.Transitions = transitions
+	rt.RequiredAttachments = required
+	return tx.Model(rt).Select("Transitions", "RequiredAttachments").Updates(&entity.ReportType{
+		Transitions:         transitions,
+		RequiredAttachments: required,
+	}).Error
+}
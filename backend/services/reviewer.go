@@ -0,0 +1,41 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"reg_system/entity"
+
+	"gorm.io/gorm"
+)
+
+var reviewerIDPattern = regexp.MustCompile(`(?i)^RV(\d+)$`)
+
+// สร้าง Reviewer ให้บัญชีผู้ใช้ (อาจารย์) ถ้ายังไม่มี รหัสรันต่อจากเดิม เช่น RV001, RV002, ...
+func EnsureReviewer(tx *gorm.DB, userID uint) error {
+	var existed entity.Reviewer
+	err := tx.Where("user_id = ?", userID).First(&existed).Error
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	var ids []string
+	if err := tx.Model(&entity.Reviewer{}).Select("reviewer_id").Find(&ids).Error; err != nil {
+		return err
+	}
+	maxN := 0
+	for _, s := range ids {
+		if m := reviewerIDPattern.FindStringSubmatch(strings.TrimSpace(s)); len(m) == 2 {
+			if n, err := strconv.Atoi(m[1]); err == nil && n > maxN {
+				maxN = n
+			}
+		}
+	}
+	return tx.Create(&entity.Reviewer{Reviewer_id: fmt.Sprintf("RV%03d", maxN+1), UserID: userID}).Error
+}
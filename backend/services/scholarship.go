@@ -0,0 +1,176 @@
+package services
+
+import (
+	"fmt"
+
+	"reg_system/entity"
+
+	"gorm.io/gorm"
+)
+
+// ผลการตรวจต่อทุนของนักศึกษา 1 คน
+type RenewalResult struct {
+	StudentScholarshipID int     `json:"student_scholarship_id"`
+	StudentID            string  `json:"student_id"`
+	ScholarshipID        int     `json:"scholarship_id"`
+	GPAX                 float64 `json:"gpax"`
+	From                 string  `json:"from"`
+	To                   string  `json:"to"`
+}
+
+// ใช้เปรียบเทียบลำดับเทอม เช่น 2568/1 -> 25681
+func termIndex(year, term int) int {
+	return year*10 + term
+}
+
+// เกรดเฉลี่ยสะสมของนักศึกษา
+func StudentGPAX(db *gorm.DB, studentID string) (float64, error) {
+	var grades []entity.Grades
+	if err := db.Preload("Subject").Where("student_id = ?", studentID).Find(&grades).Error; err != nil {
+		return 0, err
+	}
+	return CalculateGPA(grades), nil
+}
+
+// ทุนใช้ได้ในเทอมนี้หรือไม่ (ไม่รวมเกณฑ์เกรด ซึ่งตรวจตอนมอบทุนและตอนต่อทุนแต่ละเทอม)
+func ScholarshipAppliesTo(s entity.Scholarship, student entity.Students, year, term int) bool {
+	idx := termIndex(year, term)
+	if s.StartYear != 0 && idx < termIndex(s.StartYear, s.StartTerm) {
+		return false
+	}
+	if s.EndYear != 0 && idx > termIndex(s.EndYear, s.EndTerm) {
+		return false
+	}
+	if s.FacultyID != nil && *s.FacultyID != student.FacultyID {
+		return false
+	}
+	if s.StatusStudentID != nil && *s.StatusStudentID != student.StatusStudentID {
+		return false
+	}
+	return true
+}
+
+// ตรวจคุณสมบัติตอนมอบทุน คืนค่าเหตุผลถ้าไม่ผ่าน
+func ScholarshipEligibility(db *gorm.DB, s entity.Scholarship, student entity.Students) (string, error) {
+	if s.FacultyID != nil && *s.FacultyID != student.FacultyID {
+		return "student is not in the scholarship's faculty", nil
+	}
+	if s.StatusStudentID != nil && *s.StatusStudentID != student.StatusStudentID {
+		return "student status does not match the scholarship", nil
+	}
+	if s.MinGPAX > 0 {
+		gpax, err := StudentGPAX(db, student.StudentID)
+		if err != nil {
+			return "", err
+		}
+		if gpax < s.MinGPAX {
+			return fmt.Sprintf("GPAX %.2f is below the required %.2f", gpax, s.MinGPAX), nil
+		}
+	}
+	return "", nil
+}
+
+// สร้างรายการส่วนลด (ยอดติดลบ) จากทุนที่นักศึกษาได้รับ
+// เปอร์เซ็นต์คิดจากยอดค่าใช้จ่ายก่อนหักส่วนลด และส่วนลดรวมไม่เกินยอดค่าใช้จ่าย
+func ScholarshipDiscounts(tx *gorm.DB, student entity.Students, year, term int, lines []FeeLine) ([]FeeLine, error) {
+	var awards []entity.StudentScholarship
+	if err := tx.Preload("Scholarship").
+		Where("student_id = ? AND status = ?", student.StudentID, entity.StudentScholarshipActive).
+		Order("id ASC").
+		Find(&awards).Error; err != nil {
+		return nil, err
+	}
+
+	base := SumFees(lines)
+	remaining := base
+	discounts := []FeeLine{}
+	for _, a := range awards {
+		s := a.Scholarship
+		if s == nil || remaining <= 0 || !ScholarshipAppliesTo(*s, student, year, term) {
+			continue
+		}
+
+		amount := s.Value
+		if s.Type == entity.ScholarshipPercent {
+			amount = base * s.Value / 100
+		}
+		if s.Cap > 0 && amount > s.Cap {
+			amount = s.Cap
+		}
+		if amount > remaining {
+			amount = remaining
+		}
+		if amount <= 0 {
+			continue
+		}
+		remaining -= amount
+
+		discounts = append(discounts, FeeLine{
+			Type:          entity.BillItemDiscount,
+			Description:   "ทุนการศึกษา/ส่วนลด: " + s.Name,
+			Amount:        -amount,
+			ScholarshipID: s.ID,
+		})
+	}
+	return discounts, nil
+}
+
+// ตรวจต่อทุนประจำเทอม: ระงับทุนที่เกรดเฉลี่ยสะสมต่ำกว่าเกณฑ์ และคืนสิทธิ์ให้ทุนที่ถูกระงับเมื่อเกรดกลับมาผ่าน
+// บิลของเทอมนั้นจะถูกคำนวณใหม่ให้นักศึกษาที่สถานะทุนเปลี่ยน
+func RenewScholarships(tx *gorm.DB, year, term int) ([]RenewalResult, error) {
+	var awards []entity.StudentScholarship
+	if err := tx.Preload("Scholarship").
+		Where("status IN ?", []string{entity.StudentScholarshipActive, entity.StudentScholarshipSuspended}).
+		Find(&awards).Error; err != nil {
+		return nil, err
+	}
+
+	results := []RenewalResult{}
+	changed := map[string]bool{}
+	for _, a := range awards {
+		if a.Scholarship == nil {
+			continue
+		}
+		gpax, err := StudentGPAX(tx, a.StudentID)
+		if err != nil {
+			return nil, err
+		}
+
+		prev := a.Status
+		status := entity.StudentScholarshipActive
+		note := ""
+		if a.Scholarship.MinGPAX > 0 && gpax < a.Scholarship.MinGPAX {
+			status = entity.StudentScholarshipSuspended
+			note = fmt.Sprintf("ระงับทุนเทอม %d/%d: GPAX %.2f ต่ำกว่าเกณฑ์ %.2f", term, year, gpax, a.Scholarship.MinGPAX)
+		}
+
+		if err := tx.Model(&a).Updates(map[string]interface{}{
+			"status":            status,
+			"note":              note,
+			"last_checked_year": year,
+			"last_checked_term": term,
+			"last_checked_gpax": gpax,
+		}).Error; err != nil {
+			return nil, err
+		}
+
+		if status != prev {
+			changed[a.StudentID] = true
+		}
+		results = append(results, RenewalResult{
+			StudentScholarshipID: a.ID,
+			StudentID:            a.StudentID,
+			ScholarshipID:        a.ScholarshipID,
+			GPAX:                 gpax,
+			From:                 prev,
+			To:                   status,
+		})
+	}
+
+	for sid := range changed {
+		if _, err := RefreshBill(tx, sid, year, term); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,306 @@
+package services
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"reg_system/config"
+	"reg_system/entity"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/skip2/go-qrcode"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCardInactive = errors.New("student card is only issued to active students")
+	ErrCardToken    = errors.New("invalid student card token")
+	ErrCardExpired  = errors.New("student card has expired")
+)
+
+// ขึ้นต้น token เพื่อแยกเวอร์ชันรูปแบบ: SC1.<payload>.<signature> (base64url)
+const studentCardTokenPrefix = "SC1"
+
+// ข้อมูลใน QR ที่ลงนามแล้ว (ชื่อ key สั้นเพื่อให้ QR เล็ก)
+type StudentCardClaims struct {
+	StudentID string `json:"sid"`
+	Name      string `json:"name"`
+	FacultyID string `json:"fac"`
+	MajorID   string `json:"maj"`
+	Status    string `json:"st"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+	KeyID     string `json:"kid"`
+}
+
+// บัตรนักศึกษาที่ออกแล้ว พร้อมข้อมูลสำหรับแสดงผล
+type StudentCard struct {
+	Claims      StudentCardClaims `json:"Claims"`
+	Token       string            `json:"Token"`
+	QRContent   string            `json:"QRContent"`
+	FacultyName string            `json:"FacultyName"`
+	MajorName   string            `json:"MajorName"`
+	ExpiresAt   time.Time         `json:"ExpiresAt"`
+
+	Photo *entity.StudentDocument `json:"-"`
+}
+
+// ลงนาม claims ด้วยกุญแจของมหาวิทยาลัย
+func SignStudentCardToken(claims StudentCardClaims) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	key, err := config.StudentCardKey()
+	if err != nil {
+		return "", err
+	}
+	signingInput := studentCardTokenPrefix + "." + base64.RawURLEncoding.EncodeToString(payload)
+	sig := ed25519.Sign(key, []byte(signingInput))
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// ตรวจลายเซ็นและวันหมดอายุของ token ตรวจได้ด้วยกุญแจสาธารณะเพียงอย่างเดียว
+// ถ้าบัตรหมดอายุจะคืน claims มาด้วยพร้อม ErrCardExpired
+func VerifyStudentCardToken(token string, now time.Time) (*StudentCardClaims, error) {
+	pub, err := config.StudentCardPublicKey()
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != studentCardTokenPrefix {
+		return nil, ErrCardToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrCardToken
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrCardToken
+	}
+	if !ed25519.Verify(pub, []byte(parts[0]+"."+parts[1]), sig) {
+		return nil, ErrCardToken
+	}
+
+	var claims StudentCardClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrCardToken
+	}
+	if now.Unix() >= claims.ExpiresAt {
+		return &claims, ErrCardExpired
+	}
+	return &claims, nil
+}
+
+// ออกบัตรให้นักศึกษาที่กำลังศึกษาอยู่ (สถานภาพ 10) พร้อม token สำหรับ QR
+func IssueStudentCard(db *gorm.DB, studentID string) (*StudentCard, error) {
+	pub, err := config.StudentCardPublicKey()
+	if err != nil {
+		return nil, err
+	}
+	var student entity.Students
+	if err := db.Preload("Faculty").Preload("Major").
+		First(&student, "student_id = ?", studentID).Error; err != nil {
+		return nil, err
+	}
+	if student.StatusStudentID != entity.StatusStudying {
+		return nil, ErrCardInactive
+	}
+
+	now := time.Now()
+	expires := now.AddDate(0, 0, config.StudentCardValidDays())
+	card := &StudentCard{
+		Claims: StudentCardClaims{
+			StudentID: student.StudentID,
+			Name:      strings.TrimSpace(student.FirstName + " " + student.LastName),
+			FacultyID: student.FacultyID,
+			MajorID:   student.MajorID,
+			Status:    student.StatusStudentID,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: expires.Unix(),
+			KeyID:     config.StudentCardKeyID(pub),
+		},
+		ExpiresAt: expires,
+	}
+	if student.Faculty != nil {
+		card.FacultyName = student.Faculty.FacultyName
+	}
+	if student.Major != nil {
+		card.MajorName = student.Major.MajorName
+	}
+
+	token, err := SignStudentCardToken(card.Claims)
+	if err != nil {
+		return nil, err
+	}
+	card.Token = token
+	card.QRContent = config.StudentCardVerifyURL() + token
+
+	photo, err := CurrentStudentPhoto(db, studentID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	card.Photo = photo
+	return card, nil
+}
+
+// ขนาดบัตรมาตรฐาน ID-1 (85.6 x 53.98 มม.) ที่ 300 dpi
+const (
+	cardWidth    = 1012
+	cardHeight   = 638
+	cardWidthMM  = 85.6
+	cardHeightMM = 53.98
+)
+
+var (
+	cardHeaderColor = color.RGBA{0x1f, 0x3a, 0x93, 0xff}
+	cardTextColor   = color.RGBA{0x22, 0x22, 0x22, 0xff}
+)
+
+// ฟอนต์ของบัตร: ใช้ RECEIPT_FONT_PATH (รองรับภาษาไทย) ถ้ากำหนดไว้ ไม่เช่นนั้นใช้ Go Regular
+// คืนค่า thai = true เมื่อแสดงข้อความภาษาไทยได้
+func cardFont() (*opentype.Font, bool, error) {
+	if path := config.ReceiptFontPath(); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, false, err
+		}
+		f, err := opentype.Parse(data)
+		return f, true, err
+	}
+	f, err := opentype.Parse(goregular.TTF)
+	return f, false, err
+}
+
+// วางรูปให้พอดีกรอบโดยคงสัดส่วน (ตัดส่วนเกินตรงกลาง)
+func drawCover(dst *image.RGBA, box image.Rectangle, src image.Image) {
+	sb := src.Bounds()
+	sw, sh := sb.Dx(), sb.Dy()
+	bw, bh := box.Dx(), box.Dy()
+	if sw*bh > sh*bw {
+		cw := sh * bw / bh
+		sb.Min.X += (sw - cw) / 2
+		sb.Max.X = sb.Min.X + cw
+	} else {
+		ch := sw * bh / bw
+		sb.Min.Y += (sh - ch) / 2
+		sb.Max.Y = sb.Min.Y + ch
+	}
+	draw.CatmullRom.Scale(dst, box, src, sb, draw.Src, nil)
+}
+
+// วาดบัตรนักศึกษาเป็นรูป PNG: รูปถ่าย ชื่อ รหัส คณะ สาขา วันหมดอายุ และ QR ที่ลงนามแล้ว
+func RenderStudentCardPNG(card *StudentCard) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, cardWidth, cardHeight))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 0, cardWidth, 110), image.NewUniform(cardHeaderColor), image.Point{}, draw.Src)
+
+	f, thai, err := cardFont()
+	if err != nil {
+		return nil, err
+	}
+	face := func(size float64) (font.Face, error) {
+		return opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+	}
+	// ฟอนต์ Go Regular แสดงภาษาไทยไม่ได้ ข้อความที่ไม่ใช่ ASCII จะใช้ fallback แทน
+	text := func(s, fallback string) string {
+		if thai || isASCII(s) {
+			return s
+		}
+		return fallback
+	}
+	write := func(size float64, col color.Color, x, y int, s string) error {
+		fc, err := face(size)
+		if err != nil {
+			return err
+		}
+		defer fc.Close()
+		d := font.Drawer{Dst: img, Src: image.NewUniform(col), Face: fc, Dot: fixed.P(x, y)}
+		d.DrawString(s)
+		return nil
+	}
+
+	lines := []struct {
+		size float64
+		col  color.Color
+		x, y int
+		s    string
+	}{
+		{38, color.White, 40, 52, text(config.ReceiptIssuerName(), "Registration Office")},
+		{26, color.White, 40, 92, "STUDENT ID CARD"},
+		{38, cardTextColor, 310, 185, text(card.Claims.Name, card.Claims.StudentID)},
+		{30, cardTextColor, 310, 240, "ID  " + card.Claims.StudentID},
+		{26, cardTextColor, 310, 290, text(card.FacultyName, card.Claims.FacultyID)},
+		{26, cardTextColor, 310, 335, text(card.MajorName, card.Claims.MajorID)},
+		{26, cardTextColor, 310, 420, "Expires  " + card.ExpiresAt.Format("02/01/2006")},
+		{18, color.Gray{0x88}, 40, 610, "key " + card.Claims.KeyID},
+	}
+	for _, l := range lines {
+		if err := write(l.size, l.col, l.x, l.y, l.s); err != nil {
+			return nil, err
+		}
+	}
+
+	photoBox := image.Rect(40, 140, 280, 440)
+	draw.Draw(img, photoBox, image.NewUniform(color.Gray{0xdd}), image.Point{}, draw.Src)
+	if card.Photo != nil {
+		if photo, err := LoadDocumentImage(card.Photo); err == nil {
+			drawCover(img, photoBox, photo)
+		}
+	}
+
+	qr, err := qrcode.New(card.QRContent, qrcode.Medium)
+	if err != nil {
+		return nil, err
+	}
+	qr.DisableBorder = true
+	qrImg := qr.Image(260)
+	qrBox := image.Rect(cardWidth-300, cardHeight-300, cardWidth-40, cardHeight-40)
+	draw.Draw(img, qrBox, qrImg, qrImg.Bounds().Min, draw.Src)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// บัตรนักศึกษาเป็น PDF ขนาดบัตรจริง ใช้รูปเดียวกับ PNG เพื่อให้หน้าตาตรงกัน
+func RenderStudentCardPDF(w io.Writer, card *StudentCard) error {
+	img, err := RenderStudentCardPNG(card)
+	if err != nil {
+		return err
+	}
+
+	pdf := fpdf.NewCustom(&fpdf.InitType{
+		OrientationStr: "L",
+		UnitStr:        "mm",
+		Size:           fpdf.SizeType{Wd: cardWidthMM, Ht: cardHeightMM},
+	})
+	pdf.SetMargins(0, 0, 0)
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.SetTitle(fmt.Sprintf("Student card %s", card.Claims.StudentID), true)
+	pdf.AddPage()
+
+	opts := fpdf.ImageOptions{ImageType: "PNG"}
+	pdf.RegisterImageOptionsReader("card", opts, bytes.NewReader(img))
+	pdf.ImageOptions("card", 0, 0, cardWidthMM, cardHeightMM, false, opts, 0, "")
+	return pdf.Output(w)
+}
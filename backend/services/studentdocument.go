@@ -0,0 +1,231 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"reg_system/config"
+	"reg_system/entity"
+
+	"golang.org/x/image/draw"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrDocumentType     = errors.New("unknown document type")
+	ErrDocumentTooLarge = errors.New("file is too large")
+	ErrDocumentMIME     = errors.New("file type is not allowed for this document")
+	ErrDocumentState    = errors.New("document has already been reviewed")
+	ErrDocumentNotPhoto = errors.New("document is not a photo")
+	ErrImageDimensions  = errors.New("image dimensions are too large")
+)
+
+// ชนิดไฟล์ (ตรวจจากเนื้อไฟล์) ที่รับได้ของเอกสารแต่ละประเภท
+var documentMIMETypes = map[string][]string{
+	entity.DocumentPhoto:              {"image/jpeg", "image/png"},
+	entity.DocumentIDCard:             {"image/jpeg", "image/png", "application/pdf"},
+	entity.DocumentHouseRegistration:  {"image/jpeg", "image/png", "application/pdf"},
+	entity.DocumentPreviousTranscript: {"image/jpeg", "image/png", "application/pdf"},
+}
+
+// ขนาดรูปย่อ (ด้านที่ยาวที่สุด) เริ่มต้น/สูงสุด
+const (
+	DefaultThumbnailSize = 160
+	MaxThumbnailSize     = 512
+)
+
+// ตรวจชนิดไฟล์จาก 512 ไบต์แรก (ไม่เชื่อนามสกุลหรือ Content-Type ที่ผู้ใช้ส่งมา)
+func SniffUpload(file *multipart.FileHeader) (string, error) {
+	src, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	head := make([]byte, 512)
+	n, err := src.Read(head)
+	if err != nil && n == 0 {
+		return "", err
+	}
+	mime := http.DetectContentType(head[:n])
+	if i := strings.Index(mime, ";"); i >= 0 {
+		mime = mime[:i]
+	}
+	return mime, nil
+}
+
+// ตรวจประเภทเอกสาร ขนาด และชนิดไฟล์ก่อนบันทึก คืนค่าชนิดไฟล์ที่ตรวจพบ
+func CheckDocumentUpload(docType string, file *multipart.FileHeader) (string, error) {
+	allowed, ok := documentMIMETypes[docType]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrDocumentType, docType)
+	}
+
+	limit := config.DocumentMaxSize()
+	if docType == entity.DocumentPhoto {
+		limit = config.PhotoMaxSize()
+	}
+	if file.Size > limit {
+		return "", fmt.Errorf("%w: maximum is %d KB", ErrDocumentTooLarge, limit/1024)
+	}
+
+	mime, err := SniffUpload(file)
+	if err != nil {
+		return "", err
+	}
+	for _, m := range allowed {
+		if m != mime {
+			continue
+		}
+		if docType == entity.DocumentPhoto {
+			src, err := file.Open()
+			if err != nil {
+				return "", err
+			}
+			defer src.Close()
+			if err := checkImageDimensions(src); err != nil {
+				return "", err
+			}
+		}
+		return mime, nil
+	}
+	return "", fmt.Errorf("%w: %s (allowed: %s)", ErrDocumentMIME, mime, strings.Join(allowed, ", "))
+}
+
+// บันทึกเอกสารเวอร์ชันใหม่ เวอร์ชันก่อนหน้าที่ยังรอตรวจจะถูกแทนที่ (superseded)
+func SubmitStudentDocument(tx *gorm.DB, doc *entity.StudentDocument) error {
+	scope := tx.Model(&entity.StudentDocument{}).Where("student_id = ? AND type = ?", doc.StudentID, doc.Type)
+
+	var version int
+	if err := scope.Session(&gorm.Session{}).Select("COALESCE(MAX(version), 0)").Scan(&version).Error; err != nil {
+		return err
+	}
+	if err := scope.Session(&gorm.Session{}).Where("status = ?", entity.DocumentPending).
+		Update("status", entity.DocumentSuperseded).Error; err != nil {
+		return err
+	}
+
+	doc.Version = version + 1
+	doc.Status = entity.DocumentPending
+	doc.UploadedAt = time.Now()
+	return tx.Create(doc).Error
+}
+
+// เจ้าหน้าที่ตรวจเอกสาร: ยืนยัน หรือไม่ผ่าน (ต้องระบุเหตุผล นักศึกษาอัปโหลดใหม่ได้)
+func VerifyStudentDocument(tx *gorm.DB, doc *entity.StudentDocument, approve bool, reason, reviewer string) error {
+	if doc.Status != entity.DocumentPending {
+		return ErrDocumentState
+	}
+	now := time.Now()
+	doc.VerifiedBy = reviewer
+	doc.VerifiedAt = &now
+	if approve {
+		doc.Status = entity.DocumentVerified
+		doc.RejectReason = ""
+	} else {
+		doc.Status = entity.DocumentRejected
+		doc.RejectReason = reason
+	}
+	return tx.Save(doc).Error
+}
+
+// รูปถ่ายล่าสุดของนักศึกษาที่ยังใช้ได้ (ยืนยันแล้วมาก่อน ถ้าไม่มีใช้เวอร์ชันที่รอตรวจ)
+func CurrentStudentPhoto(db *gorm.DB, studentID string) (*entity.StudentDocument, error) {
+	var doc entity.StudentDocument
+	err := db.Where("student_id = ? AND type = ? AND status IN ?", studentID, entity.DocumentPhoto,
+		[]string{entity.DocumentVerified, entity.DocumentPending}).
+		Order("CASE status WHEN 'verified' THEN 0 ELSE 1 END, version DESC").
+		First(&doc).Error
+	if err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// ที่อยู่ไฟล์ของเอกสารในโฟลเดอร์ uploads
+func DocumentFilePath(doc *entity.StudentDocument) string {
+	return filepath.Join(UploadDir, filepath.Base(doc.FileName))
+}
+
+// โหลดรูปถ่ายจาก uploads
+func LoadDocumentImage(doc *entity.StudentDocument) (image.Image, error) {
+	if doc.Type != entity.DocumentPhoto {
+		return nil, ErrDocumentNotPhoto
+	}
+	f, err := os.Open(DocumentFilePath(doc))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if err := checkImageDimensions(f); err != nil {
+		return nil, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(f)
+	return img, err
+}
+
+// อ่านขนาดรูปจากหัวไฟล์ (image.DecodeConfig) และปฏิเสธรูปที่ด้านใดเกิน PHOTO_MAX_DIMENSION
+func checkImageDimensions(r io.Reader) error {
+	cfg, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return fmt.Errorf("%w: cannot read image header: %v", ErrDocumentMIME, err)
+	}
+	max := config.PhotoMaxDimension()
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width > max || cfg.Height > max {
+		return fmt.Errorf("%w: %dx%d (maximum %d pixels per side)", ErrImageDimensions, cfg.Width, cfg.Height, max)
+	}
+	return nil
+}
+
+// ย่อรูปให้ด้านที่ยาวที่สุดเท่ากับ size (ไม่ขยายรูปเล็ก) คืนค่าเป็น JPEG
+func PhotoThumbnail(doc *entity.StudentDocument, size int) ([]byte, error) {
+	if size <= 0 {
+		size = DefaultThumbnailSize
+	}
+	if size > MaxThumbnailSize {
+		size = MaxThumbnailSize
+	}
+	src, err := LoadDocumentImage(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w > size || h > size {
+		if w >= h {
+			w, h = size, h*size/w
+		} else {
+			w, h = w*size/h, size
+		}
+	}
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Src, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
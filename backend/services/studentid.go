@@ -0,0 +1,266 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"reg_system/config"
+	"reg_system/entity"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrStudentIDPattern   = errors.New("student ID does not match the configured pattern")
+	ErrStudentIDExhausted = errors.New("running numbers for this student ID prefix are exhausted")
+)
+
+// ส่วนประกอบของรูปแบบรหัสนักศึกษา (ดู config.StudentIDPattern)
+type studentIDToken struct {
+	Kind    string // literal, DEGREE, YY, FACULTY, SEQ
+	Literal string
+	Width   int
+}
+
+var studentIDTokenPattern = regexp.MustCompile(`\{(DEGREE|YY|FACULTY|SEQ:(\d+))\}`)
+
+func parseStudentIDPattern(pattern string) ([]studentIDToken, error) {
+	tokens := []studentIDToken{}
+	last, seqs := 0, 0
+	for _, m := range studentIDTokenPattern.FindAllStringSubmatchIndex(pattern, -1) {
+		if m[0] > last {
+			tokens = append(tokens, studentIDToken{Kind: "literal", Literal: pattern[last:m[0]]})
+		}
+		name := pattern[m[2]:m[3]]
+		if m[4] >= 0 {
+			width, _ := strconv.Atoi(pattern[m[4]:m[5]])
+			if width < 1 || width > 9 {
+				return nil, fmt.Errorf("invalid running number width in %q", pattern)
+			}
+			tokens = append(tokens, studentIDToken{Kind: "SEQ", Width: width})
+			seqs++
+		} else {
+			tokens = append(tokens, studentIDToken{Kind: name})
+		}
+		last = m[1]
+	}
+	if last < len(pattern) {
+		tokens = append(tokens, studentIDToken{Kind: "literal", Literal: pattern[last:]})
+	}
+	if seqs != 1 {
+		return nil, fmt.Errorf("student ID pattern %q must contain exactly one {SEQ:n}", pattern)
+	}
+	return tokens, nil
+}
+
+// regexp ที่ตรงกับรหัสตามรูปแบบ พร้อมกลุ่ม degree, yy, faculty, seq
+func studentIDRegexp(tokens []studentIDToken) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, t := range tokens {
+		switch t.Kind {
+		case "literal":
+			b.WriteString(regexp.QuoteMeta(t.Literal))
+		case "DEGREE":
+			b.WriteString(`(?P<degree>[A-Z])`)
+		case "YY":
+			b.WriteString(`(?P<yy>\d{2})`)
+		case "FACULTY":
+			b.WriteString(`(?P<faculty>[0-9A-Z]{2})`)
+		case "SEQ":
+			fmt.Fprintf(&b, `(?P<seq>\d{%d})`, t.Width)
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+// แยกส่วนประกอบของรหัสนักศึกษาตามรูปแบบที่ตั้งไว้ (ok = false ถ้าไม่ตรงรูปแบบ)
+func parseStudentID(id string) (parts map[string]string, ok bool) {
+	tokens, err := parseStudentIDPattern(config.StudentIDPattern())
+	if err != nil {
+		return nil, false
+	}
+	re := studentIDRegexp(tokens)
+	m := re.FindStringSubmatch(id)
+	if m == nil {
+		return nil, false
+	}
+	parts = map[string]string{}
+	for i, name := range re.SubexpNames() {
+		if name != "" {
+			parts[name] = m[i]
+		}
+	}
+	return parts, true
+}
+
+// ปีการศึกษา (พ.ศ.) ปัจจุบัน ใช้เป็นปีที่เข้าศึกษาเมื่อไม่ได้ระบุ
+func CurrentEntryYear() int {
+	return time.Now().Year() + 543
+}
+
+// ตัวอักษรนำหน้าของระดับการศึกษาและรหัสคณะที่ใช้ในรหัสนักศึกษา
+func studentIDCodes(tx *gorm.DB, degreeID int, facultyID string) (prefix, code string, err error) {
+	var degree entity.Degree
+	if err := tx.First(&degree, "degree_id = ?", degreeID).Error; err != nil {
+		return "", "", err
+	}
+	var faculty entity.Faculty
+	if err := tx.First(&faculty, "faculty_id = ?", facultyID).Error; err != nil {
+		return "", "", err
+	}
+	return degree.Prefix, faculty.Code, nil
+}
+
+// ชุดรหัสนักศึกษาตามรูปแบบ โดยแทนเลขรันด้วย # เช่น B69# (อ่านข้อมูลอ้างอิงอย่างเดียว)
+func StudentIDPrefix(db *gorm.DB, degreeID int, facultyID string, entryYear int) (string, error) {
+	tokens, err := parseStudentIDPattern(config.StudentIDPattern())
+	if err != nil {
+		return "", err
+	}
+	prefix, code, err := studentIDCodes(db, degreeID, facultyID)
+	if err != nil {
+		return "", err
+	}
+
+	var key strings.Builder
+	for _, t := range tokens {
+		switch t.Kind {
+		case "literal":
+			key.WriteString(t.Literal)
+		case "DEGREE":
+			if len(prefix) != 1 {
+				return "", fmt.Errorf("degree %d has no single-letter student ID prefix", degreeID)
+			}
+			key.WriteString(prefix)
+		case "YY":
+			fmt.Fprintf(&key, "%02d", entryYear%100)
+		case "FACULTY":
+			if len(code) != 2 {
+				return "", fmt.Errorf("faculty %s has no 2-character student ID code", facultyID)
+			}
+			key.WriteString(code)
+		case "SEQ":
+			key.WriteString("#")
+		}
+	}
+	return key.String(), nil
+}
+
+// ออกรหัสนักศึกษาถัดไปของชุด ต้องเรียกภายใน transaction เดียวกับการสร้างนักศึกษา
+// เลขรันเพิ่มด้วย UPDATE ครั้งเดียว คำขอพร้อมกันจึงไม่ได้เลขซ้ำ และข้ามรหัสที่มีผู้ใช้อยู่แล้ว (เช่น ที่พิมพ์เอง)
+// ควรเป็นคำสั่งแรกของ transaction เพื่อให้ได้สิทธิ์เขียนก่อนอ่าน คำขอพร้อมกันจะรอกันแทนที่จะชนกัน
+func AllocateStudentID(tx *gorm.DB, prefix string) (string, error) {
+	tokens, err := parseStudentIDPattern(config.StudentIDPattern())
+	if err != nil {
+		return "", err
+	}
+	width, limit := 0, 1
+	for _, t := range tokens {
+		if t.Kind == "SEQ" {
+			width = t.Width
+		}
+	}
+	for i := 0; i < width; i++ {
+		limit *= 10
+	}
+
+	for {
+		n, err := nextStudentIDSeq(tx, prefix)
+		if err != nil {
+			return "", err
+		}
+		if n >= limit {
+			return "", fmt.Errorf("%w: %s", ErrStudentIDExhausted, prefix)
+		}
+		id := strings.Replace(prefix, "#", fmt.Sprintf("%0*d", width, n), 1)
+
+		var taken int64
+		if err := tx.Unscoped().Model(&entity.Students{}).Where("student_id = ?", id).Count(&taken).Error; err != nil {
+			return "", err
+		}
+		if taken == 0 {
+			if err := tx.Unscoped().Model(&entity.Users{}).Where("username = ?", id).Count(&taken).Error; err != nil {
+				return "", err
+			}
+		}
+		if taken == 0 {
+			return id, nil
+		}
+	}
+}
+
+// ออกรหัสนักศึกษาใหม่ตามรูปแบบภายใน transaction (ใช้ตอนนำเข้าจำนวนมาก)
+func GenerateStudentID(tx *gorm.DB, degreeID int, facultyID string, entryYear int) (string, error) {
+	prefix, err := StudentIDPrefix(tx, degreeID, facultyID, entryYear)
+	if err != nil {
+		return "", err
+	}
+	return AllocateStudentID(tx, prefix)
+}
+
+func nextStudentIDSeq(tx *gorm.DB, prefix string) (int, error) {
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&entity.StudentIDSequence{Prefix: prefix}).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Model(&entity.StudentIDSequence{}).Where("prefix = ?", prefix).
+		Update("last_number", gorm.Expr("last_number + 1")).Error; err != nil {
+		return 0, err
+	}
+	var seq entity.StudentIDSequence
+	if err := tx.First(&seq, "prefix = ?", prefix).Error; err != nil {
+		return 0, err
+	}
+	return seq.LastNumber, nil
+}
+
+// ตรวจรหัสนักศึกษาที่กรอกเองว่าตรงรูปแบบ และตัวอักษรนำหน้า/รหัสคณะตรงกับระดับการศึกษาและคณะของนักศึกษา
+func ValidateStudentID(tx *gorm.DB, id string, degreeID int, facultyID string) error {
+	prefix, code, err := studentIDCodes(tx, degreeID, facultyID)
+	if err != nil {
+		return err
+	}
+	return matchStudentID(id, prefix, code)
+}
+
+func matchStudentID(id, prefix, code string) error {
+	parts, ok := parseStudentID(id)
+	if !ok {
+		return fmt.Errorf("%w (%s)", ErrStudentIDPattern, config.StudentIDPattern())
+	}
+	if d, ok := parts["degree"]; ok && prefix != "" && d != prefix {
+		return fmt.Errorf("%w: prefix %s does not match degree prefix %s", ErrStudentIDPattern, d, prefix)
+	}
+	if f, ok := parts["faculty"]; ok && code != "" && f != code {
+		return fmt.Errorf("%w: faculty code %s does not match faculty code %s", ErrStudentIDPattern, f, code)
+	}
+	return nil
+}
+
+// นักศึกษาที่รหัสไม่ตรงกับรูปแบบปัจจุบัน
+type StudentIDIssue struct {
+	StudentID string `json:"student_id"`
+	Error     string `json:"error"`
+}
+
+// ตรวจรหัสนักศึกษาที่มีอยู่ทั้งหมดกับรูปแบบปัจจุบัน
+func AuditStudentIDs(tx *gorm.DB) ([]StudentIDIssue, error) {
+	var students []entity.Students
+	if err := tx.Select("student_id", "degree_id", "faculty_id").Find(&students).Error; err != nil {
+		return nil, err
+	}
+	issues := []StudentIDIssue{}
+	for _, s := range students {
+		if err := ValidateStudentID(tx, s.StudentID, s.DegreeID, s.FacultyID); err != nil {
+			issues = append(issues, StudentIDIssue{StudentID: s.StudentID, Error: err.Error()})
+		}
+	}
+	return issues, nil
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseStudentIDPattern(t *testing.T) {
+	tokens, err := parseStudentIDPattern("{DEGREE}{YY}-{FACULTY}{SEQ:4}")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []studentIDToken{
+		{Kind: "DEGREE"},
+		{Kind: "YY"},
+		{Kind: "literal", Literal: "-"},
+		{Kind: "FACULTY"},
+		{Kind: "SEQ", Width: 4},
+	}
+	if !reflect.DeepEqual(tokens, want) {
+		t.Fatalf("tokens = %+v, want %+v", tokens, want)
+	}
+
+	for _, pattern := range []string{
+		"{DEGREE}{YY}",     // ไม่มีเลขลำดับ
+		"{SEQ:3}{SEQ:3}",   // เลขลำดับสองชุด
+		"{DEGREE}{SEQ:0}",  // ความกว้างต่ำเกินไป
+		"{DEGREE}{SEQ:10}", // ความกว้างเกิน 9
+	} {
+		if _, err := parseStudentIDPattern(pattern); err == nil {
+			t.Errorf("parseStudentIDPattern(%q): expected error", pattern)
+		}
+	}
+}
+
+func TestMatchStudentID(t *testing.T) {
+	t.Setenv("STUDENT_ID_PATTERN", "{DEGREE}{YY}{FACULTY}{SEQ:4}")
+
+	tests := []struct {
+		id, prefix, code string
+		ok               bool
+	}{
+		{"B66EN0001", "B", "EN", true},
+		{"B66EN0001", "", "", true},     // ไม่ได้กำหนดรหัสนำหน้า/รหัสคณะ
+		{"M66EN0001", "B", "EN", false}, // ระดับการศึกษาไม่ตรง
+		{"B66SC0001", "B", "EN", false}, // คณะไม่ตรง
+		{"B66EN001", "B", "EN", false},  // เลขลำดับสั้นไป
+		{"b66EN0001", "B", "EN", false},
+	}
+	for _, tt := range tests {
+		err := matchStudentID(tt.id, tt.prefix, tt.code)
+		if tt.ok && err != nil {
+			t.Errorf("matchStudentID(%q, %q, %q) = %v, want nil", tt.id, tt.prefix, tt.code, err)
+		}
+		if !tt.ok && !errors.Is(err, ErrStudentIDPattern) {
+			t.Errorf("matchStudentID(%q, %q, %q) = %v, want ErrStudentIDPattern", tt.id, tt.prefix, tt.code, err)
+		}
+	}
+}
@@ -0,0 +1,343 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"reg_system/config"
+	"reg_system/entity"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrStatusTransition   = errors.New("status transition is not allowed")
+	ErrLeaveLimit         = errors.New("leave of absence exceeds the maximum number of terms")
+	ErrStatusRequestOpen  = errors.New("student already has a pending or scheduled status request")
+	ErrStatusRequestState = errors.New("status request has already been reviewed")
+	ErrStatusRequestType  = errors.New("invalid status request type")
+	ErrRegistrationStatus = errors.New("student status does not allow registration")
+	ErrStatusFutureTerm   = errors.New("effective semester has not started yet")
+)
+
+// สถานภาพที่เปลี่ยนไปได้จากแต่ละสถานภาพ
+var statusTransitions = map[string][]string{
+	entity.StatusStudying:            {entity.StatusGraduationRequested, entity.StatusOnLeave, entity.StatusSuspended, entity.StatusWithdrawn, entity.StatusTerminated},
+	entity.StatusGraduationRequested: {entity.StatusGraduated, entity.StatusGraduationRejected, entity.StatusStudying},
+	entity.StatusGraduationRejected:  {entity.StatusGraduationRequested, entity.StatusStudying, entity.StatusWithdrawn, entity.StatusTerminated},
+	entity.StatusOnLeave:             {entity.StatusStudying, entity.StatusWithdrawn, entity.StatusTerminated},
+	entity.StatusSuspended:           {entity.StatusStudying, entity.StatusWithdrawn, entity.StatusTerminated},
+	entity.StatusWithdrawn:           {entity.StatusStudying},
+	entity.StatusTerminated:          {entity.StatusStudying},
+	entity.StatusGraduated:           {},
+}
+
+// สถานภาพปลายทางและสถานภาพต้นทางที่ยื่นคำร้องแต่ละประเภทได้
+var statusRequestTargets = map[string]struct {
+	To   string
+	From []string
+}{
+	entity.StatusRequestLeave:     {entity.StatusOnLeave, []string{entity.StatusStudying}},
+	entity.StatusRequestReturn:    {entity.StatusStudying, []string{entity.StatusOnLeave}},
+	entity.StatusRequestWithdraw:  {entity.StatusWithdrawn, []string{entity.StatusStudying, entity.StatusOnLeave, entity.StatusSuspended, entity.StatusGraduationRejected}},
+	entity.StatusRequestReinstate: {entity.StatusStudying, []string{entity.StatusWithdrawn, entity.StatusTerminated, entity.StatusSuspended}},
+}
+
+// ประเภทคำร้องที่ใช้เปลี่ยนสถานภาพจาก from เป็น to (ลาพัก กลับเข้าศึกษา ลาออก คืนสภาพ)
+// ถ้าไม่มีคำร้องประเภทใดครอบคลุมคืนค่า false
+func StatusRequestTypeFor(from, to string) (string, bool) {
+	for typ, target := range statusRequestTargets {
+		if target.To != to {
+			continue
+		}
+		for _, s := range target.From {
+			if s == from {
+				return typ, true
+			}
+		}
+	}
+	return "", false
+}
+
+// สถานภาพปลายทางที่ต้องเปลี่ยนผ่านคำร้องเปลี่ยนสถานภาพ
+func statusRequiresRequest(to string) bool {
+	for _, target := range statusRequestTargets {
+		if target.To == to {
+			return true
+		}
+	}
+	return false
+}
+
+func CanTransitionStatus(from, to string) bool {
+	for _, s := range statusTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// สถานภาพที่ลงทะเบียนเรียนได้
+func StatusAllowsRegistration(status string) bool {
+	switch status {
+	case entity.StatusStudying, entity.StatusGraduationRequested, entity.StatusGraduationRejected:
+		return true
+	}
+	return false
+}
+
+// รายละเอียดการเปลี่ยนสถานภาพที่บันทึกลงประวัติ
+type StatusChange struct {
+	EffectiveSemesterID *int
+	Reason              string
+	ApprovedBy          string
+	StatusRequestID     *int
+}
+
+// เปลี่ยนสถานภาพนักศึกษาตามเส้นทางที่อนุญาต บันทึกประวัติ และทำผลข้างเคียงของสถานภาพใหม่
+// ถ้าสถานภาพไม่เปลี่ยนจะไม่ทำอะไร ภาคการศึกษาที่มีผลต้องเริ่มแล้ว
+// (การเปลี่ยนล่วงหน้าใช้คำร้องเปลี่ยนสถานภาพ ซึ่งรอจนถึงวันเปิดภาค)
+func ChangeStudentStatus(tx *gorm.DB, studentID, to string, change StatusChange) error {
+	var student entity.Students
+	if err := tx.First(&student, "student_id = ?", studentID).Error; err != nil {
+		return err
+	}
+	if change.EffectiveSemesterID != nil {
+		var semester entity.Semester
+		if err := tx.First(&semester, "id = ?", *change.EffectiveSemesterID).Error; err != nil {
+			return err
+		}
+		if !semesterStarted(semester, time.Now()) {
+			return fmt.Errorf("%w: semester %s starts %s", ErrStatusFutureTerm, semester.ID, semester.StartDate.Format("2006-01-02"))
+		}
+	}
+	from := student.StatusStudentID
+	if from == to {
+		return nil
+	}
+	if !CanTransitionStatus(from, to) {
+		return fmt.Errorf("%w: %s -> %s", ErrStatusTransition, from, to)
+	}
+
+	if err := tx.Model(&entity.Students{}).Where("student_id = ?", studentID).
+		Update("status_student_id", to).Error; err != nil {
+		return err
+	}
+	if err := tx.Create(&entity.StudentStatusLog{
+		StudentID:           studentID,
+		FromStatusID:        from,
+		ToStatusID:          to,
+		EffectiveSemesterID: change.EffectiveSemesterID,
+		Reason:              change.Reason,
+		ApprovedBy:          change.ApprovedBy,
+		StatusRequestID:     change.StatusRequestID,
+	}).Error; err != nil {
+		return err
+	}
+	return applyStatusSideEffects(tx, student, to, change.EffectiveSemesterID)
+}
+
+// ผลข้างเคียงของสถานภาพใหม่ในภาคการศึกษาที่มีผล:
+// ลาพัก/พักการศึกษา/ลาออก/สิ้นสภาพ ถอนรายวิชาที่ลงไว้ในเทอมนั้น (คืนเงินตามนโยบาย)
+// และลาพักคิดค่ารักษาสภาพนักศึกษา
+func applyStatusSideEffects(tx *gorm.DB, student entity.Students, to string, semesterID *int) error {
+	switch to {
+	case entity.StatusOnLeave, entity.StatusSuspended, entity.StatusWithdrawn, entity.StatusTerminated:
+	default:
+		return nil
+	}
+	if semesterID == nil {
+		return nil
+	}
+	var semester entity.Semester
+	if err := tx.First(&semester, "id = ?", *semesterID).Error; err != nil {
+		return err
+	}
+
+	var regs []entity.Registration
+	if err := tx.Joins("JOIN subjects ON subjects.subject_id = registrations.subject_id").
+		Where("registrations.student_id = ? AND subjects.semester_id = ?", student.StudentID, semester.ID).
+		Find(&regs).Error; err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, reg := range regs {
+		if err := ApplyDropPolicy(tx, reg, now); err != nil {
+			return err
+		}
+		if err := tx.Delete(&reg).Error; err != nil {
+			return err
+		}
+	}
+
+	if to == entity.StatusOnLeave {
+		if fee := config.LeaveMaintenanceFee(); fee > 0 {
+			bill, err := BuildBill(tx, student.StudentID, semester.AcademicYear, semester.Term)
+			if err != nil {
+				return err
+			}
+			if err := tx.Create(&entity.BillItem{
+				BillID:      bill.ID,
+				Type:        entity.BillItemTermFee,
+				Description: "ค่ารักษาสภาพนักศึกษา (ลาพักการศึกษา)",
+				Amount:      fee,
+				Manual:      true,
+			}).Error; err != nil {
+				return err
+			}
+		}
+	}
+	_, err := RefreshBill(tx, student.StudentID, semester.AcademicYear, semester.Term)
+	return err
+}
+
+// จำนวนภาคการศึกษาที่ลาพักไปแล้ว (คำร้องที่อนุมัติแล้ว รวมที่รอภาคการศึกษาที่มีผล)
+func UsedLeaveTerms(tx *gorm.DB, studentID string) (int, error) {
+	var used int
+	err := tx.Model(&entity.StatusRequest{}).
+		Where("student_id = ? AND type = ? AND status IN ?", studentID, entity.StatusRequestLeave,
+			[]string{entity.StatusRequestApproved, entity.StatusRequestScheduled}).
+		Select("COALESCE(SUM(terms), 0)").Scan(&used).Error
+	return used, err
+}
+
+// ยื่นคำร้องเปลี่ยนสถานภาพ ตรวจประเภท สถานภาพปัจจุบัน และจำนวนภาคที่ลาพักได้
+func CreateStatusRequest(tx *gorm.DB, req *entity.StatusRequest) error {
+	target, ok := statusRequestTargets[req.Type]
+	if !ok {
+		return ErrStatusRequestType
+	}
+	var student entity.Students
+	if err := tx.First(&student, "student_id = ?", req.StudentID).Error; err != nil {
+		return err
+	}
+	allowed := false
+	for _, s := range target.From {
+		if s == student.StatusStudentID {
+			allowed = true
+		}
+	}
+	if !allowed {
+		return fmt.Errorf("%w: cannot request %s from status %s", ErrStatusTransition, req.Type, student.StatusStudentID)
+	}
+	if err := tx.First(&entity.Semester{}, "id = ?", req.EffectiveSemesterID).Error; err != nil {
+		return err
+	}
+
+	if req.Type == entity.StatusRequestLeave {
+		if req.Terms <= 0 {
+			req.Terms = 1
+		}
+		used, err := UsedLeaveTerms(tx, req.StudentID)
+		if err != nil {
+			return err
+		}
+		if used+req.Terms > config.MaxLeaveTerms() {
+			return fmt.Errorf("%w (used %d, requested %d, max %d)", ErrLeaveLimit, used, req.Terms, config.MaxLeaveTerms())
+		}
+	} else {
+		req.Terms = 0
+	}
+
+	var open int64
+	if err := tx.Model(&entity.StatusRequest{}).
+		Where("student_id = ? AND status IN ?", req.StudentID,
+			[]string{entity.StatusRequestPending, entity.StatusRequestScheduled}).
+		Count(&open).Error; err != nil {
+		return err
+	}
+	if open > 0 {
+		return ErrStatusRequestOpen
+	}
+
+	req.Status = entity.StatusRequestPending
+	req.RequestedAt = time.Now()
+	return tx.Create(req).Error
+}
+
+// เจ้าหน้าที่พิจารณาคำร้อง อนุมัติแล้วเปลี่ยนสถานภาพพร้อมบันทึกประวัติ
+// ถ้าภาคการศึกษาที่มีผลยังไม่เริ่ม คำร้องจะรอ (scheduled) และเปลี่ยนสถานภาพเมื่อถึงวันเปิดภาคใน ApplyDueStatusRequests
+func ReviewStatusRequest(tx *gorm.DB, req *entity.StatusRequest, approve bool, reason, reviewer string) error {
+	if req.Status != entity.StatusRequestPending {
+		return ErrStatusRequestState
+	}
+	now := time.Now()
+	req.ReviewedBy = reviewer
+	req.ReviewedAt = &now
+
+	if !approve {
+		req.Status = entity.StatusRequestRejected
+		req.RejectReason = reason
+		return tx.Save(req).Error
+	}
+
+	if req.Type == entity.StatusRequestLeave {
+		used, err := UsedLeaveTerms(tx, req.StudentID)
+		if err != nil {
+			return err
+		}
+		if used+req.Terms > config.MaxLeaveTerms() {
+			return ErrLeaveLimit
+		}
+	}
+
+	var semester entity.Semester
+	if err := tx.First(&semester, "id = ?", req.EffectiveSemesterID).Error; err != nil {
+		return err
+	}
+	if !semesterStarted(semester, now) {
+		req.Status = entity.StatusRequestScheduled
+		return tx.Save(req).Error
+	}
+	return applyStatusRequest(tx, req)
+}
+
+// เปลี่ยนสถานภาพตามคำร้องที่อนุมัติแล้ว
+func applyStatusRequest(tx *gorm.DB, req *entity.StatusRequest) error {
+	semesterID := req.EffectiveSemesterID
+	requestID := req.ID
+	if err := ChangeStudentStatus(tx, req.StudentID, statusRequestTargets[req.Type].To, StatusChange{
+		EffectiveSemesterID: &semesterID,
+		Reason:              req.Reason,
+		ApprovedBy:          req.ReviewedBy,
+		StatusRequestID:     &requestID,
+	}); err != nil {
+		return err
+	}
+	req.Status = entity.StatusRequestApproved
+	return tx.Save(req).Error
+}
+
+// เปลี่ยนสถานภาพตามคำร้องที่รอ (scheduled) ซึ่งภาคการศึกษาที่มีผลเริ่มแล้ว คืนค่าจำนวนที่เปลี่ยน
+// คำร้องที่เปลี่ยนไม่ได้ (เช่น สถานภาพนักศึกษาเปลี่ยนไประหว่างรอ หรือคำนวณบิลใหม่ไม่ได้) จะถูกปิดเป็นไม่อนุมัติพร้อมเหตุผล
+func ApplyDueStatusRequests(tx *gorm.DB, now time.Time) (int, error) {
+	var due []entity.StatusRequest
+	if err := tx.Joins("JOIN semesters ON semesters.id = status_requests.effective_semester_id").
+		Where("status_requests.status = ? AND (semesters.start_date IS NULL OR semesters.start_date <= ?)",
+			entity.StatusRequestScheduled, now).
+		Order("status_requests.id ASC").Find(&due).Error; err != nil {
+		return 0, err
+	}
+	applied := 0
+	for i := range due {
+		// แต่ละคำขอทำใน savepoint ของตัวเอง คำขอที่ล้มเหลวถูกปฏิเสธพร้อมเหตุผลโดยไม่กระทบคำขออื่น
+		err := tx.Transaction(func(tx *gorm.DB) error {
+			return applyStatusRequest(tx, &due[i])
+		})
+		if err == nil {
+			applied++
+			continue
+		}
+		log.Printf("scheduled status request %d could not be applied: %v", due[i].ID, err)
+		if err := tx.Model(&entity.StatusRequest{}).Where("id = ?", due[i].ID).Updates(map[string]interface{}{
+			"status":        entity.StatusRequestRejected,
+			"reject_reason": "ไม่สามารถเปลี่ยนสถานภาพเมื่อถึงภาคการศึกษาที่มีผล: " + err.Error(),
+		}).Error; err != nil {
+			return applied, err
+		}
+	}
+	return applied, nil
+}
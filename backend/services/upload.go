@@ -0,0 +1,38 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const UploadDir = "./uploads"
+
+// บันทึกไฟล์ที่อัปโหลดลงโฟลเดอร์ uploads ตั้งชื่อใหม่ด้วยเวลาเพื่อไม่ให้ทับไฟล์เดิม
+// คืนค่าชื่อไฟล์ที่บันทึก (ใช้กับ GET /bills/preview/:id)
+func SaveUpload(file *multipart.FileHeader) (string, error) {
+	if err := os.MkdirAll(UploadDir, os.ModePerm); err != nil {
+		return "", fmt.Errorf("cannot create upload folder: %w", err)
+	}
+
+	fileName := fmt.Sprintf("%s_%s", time.Now().Format("20060102150405.000000"), filepath.Base(file.Filename))
+	src, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(filepath.Join(UploadDir, fileName))
+	if err != nil {
+		return "", err
+	}
+	defer dst.Close()
+
+	if _, err := io.Copy(dst, src); err != nil {
+		return "", err
+	}
+	return fileName, nil
+}
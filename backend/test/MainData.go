@@ -23,6 +23,8 @@ func ExampleData() {
 	StudentExample()
 	BillExample()
 	BillStatus()
+	FeeScheduleExample()
+	RefundPolicyExample()
 	//GradeExample()
 	//ScoresExample()
 	ReportExampleData()
@@ -41,7 +43,7 @@ func ExampleData() {
 		PositionID: 1, // Assuming PositionID 1 exists
 	}
 	db.FirstOrCreate(&teacher)
-	hashedPasswordTeacher, _ := config.HashPassword(teacher.CitizenID)
+	hashedPasswordTeacher, _ := config.HashPassword(string(teacher.CitizenID))
 	userTeacher := entity.Users{
 		Username: teacher.TeacherID,
 		Password: hashedPasswordTeacher,
@@ -63,9 +65,10 @@ func ExampleData() {
 		StatusStudentID: "10",
 		CurriculumID:    "curr23",
 		//TeacherID:       "T2900364",
+		AdvisorID:       "T2900364",
 	}
 	db.FirstOrCreate(&student)
-	hashedPasswordStudent, _ := config.HashPassword(student.CitizenID)
+	hashedPasswordStudent, _ := config.HashPassword(string(student.CitizenID))
 	userStudent := entity.Users{
 		Username: student.StudentID,
 		Password: hashedPasswordStudent,
@@ -19,6 +19,8 @@ func CurriculumExample() {
 		FacultyID:      "F01",
 		StartYear:      2565,
 		Description:    "หลักสูตรวิศวกรรมคอมพิวเตอร์2565 ถูกพัฒนาขึ้นสำหรับนักศึกษาปีการศึกษา 2565 เป็นต้นไป",
+
+		RequireAdvisorApproval: true,
 	}
 
 	// ใช้ key ที่ไม่ซ้ำ (curriculum_id) ในการ FirstOrCreate
@@ -9,9 +9,9 @@ func DegreeExample() {
 	db := config.DB()
 
 	degrees := []entity.Degree{
-		{DegreeID: 1 , Degree: "ระดับปริญญาตรี"},
-		{DegreeID: 2 ,Degree: "ระดับปริญญาโท"},
-		{DegreeID: 3 ,Degree: "ระดับปริญญาเอก"},
+		{DegreeID: 1 , Degree: "ระดับปริญญาตรี", Prefix: "B"},
+		{DegreeID: 2 ,Degree: "ระดับปริญญาโท", Prefix: "M"},
+		{DegreeID: 3 ,Degree: "ระดับปริญญาเอก", Prefix: "D"},
 	}
 
 	for _,degr := range degrees{
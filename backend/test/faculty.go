@@ -9,10 +9,10 @@ func FacultyExample() {
 	db := config.DB()
 
 	faculties := []entity.Faculty{
-		{FacultyID: "F01" , FacultyName: "สำนักวิชาวิศวกรรมศาสตร์" },
-		{FacultyID: "F02" , FacultyName: "สำนักวิชาวิทยาศาสตร์" },
-		{FacultyID: "F03" , FacultyName: "สำนักวิชาเทคโนโลยีสังคม" },
-		{FacultyID: "F04" , FacultyName: "สำนักวิชาเทคโนโลยีการเกษตร" },
+		{FacultyID: "F01" , FacultyName: "สำนักวิชาวิศวกรรมศาสตร์", Code: "01" },
+		{FacultyID: "F02" , FacultyName: "สำนักวิชาวิทยาศาสตร์", Code: "02" },
+		{FacultyID: "F03" , FacultyName: "สำนักวิชาเทคโนโลยีสังคม", Code: "03" },
+		{FacultyID: "F04" , FacultyName: "สำนักวิชาเทคโนโลยีการเกษตร", Code: "04" },
 	}
 
 	for _,fac := range faculties{
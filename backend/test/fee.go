@@ -0,0 +1,25 @@
+package test
+
+import (
+	"time"
+
+	"reg_system/config"
+	"reg_system/entity"
+)
+
+func FeeScheduleExample() {
+	db := config.DB()
+
+	// อัตรามาตรฐาน 800 บาท/หน่วยกิต ใช้กับทุกหลักสูตร (ค่าเดิมของระบบ)
+	schedules := []entity.FeeSchedule{
+		{
+			Name:          "อัตราค่าหน่วยกิตมาตรฐาน",
+			RatePerCredit: 800,
+			EffectiveFrom: time.Date(2020, 1, 1, 0, 0, 0, 0, time.Local),
+		},
+	}
+
+	for _, fs := range schedules {
+		db.FirstOrCreate(&fs, entity.FeeSchedule{Name: fs.Name})
+	}
+}
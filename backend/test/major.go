@@ -13,7 +13,7 @@ func MajorExample() {
 		{MajorID: "SCI05", MajorName: "ฟิสิกส์", FacultyID: "F02"},
 		{MajorID: "SCI02", MajorName: "เคมี", FacultyID: "F02"},
 
-		{MajorID: "ENG23", MajorName: "คอมพิวเตอร์", FacultyID: "F01"},
+		{MajorID: "ENG23", MajorName: "คอมพิวเตอร์", FacultyID: "F01", HeadTeacherID: "T2900364"},
 		{MajorID: "ENG24", MajorName: "เคมี", FacultyID: "F01"},
 		{MajorID: "ENG25", MajorName: "ไฟฟ้า", FacultyID: "F01"},
 
@@ -0,0 +1,21 @@
+package test
+
+import (
+	"reg_system/config"
+	"reg_system/entity"
+)
+
+func RefundPolicyExample() {
+	db := config.DB()
+
+	// สัปดาห์ 1-2 (ช่วงเพิ่ม-ถอน) คืน 100%, สัปดาห์ 3 คืน 50%, หลังจากนั้นไม่คืน
+	policies := []entity.RefundPolicy{
+		{FromWeek: 1, ToWeek: 2, Percent: 100, Note: "ช่วงเพิ่ม-ถอนรายวิชา"},
+		{FromWeek: 3, ToWeek: 3, Percent: 50},
+		{FromWeek: 4, ToWeek: 0, Percent: 0},
+	}
+
+	for _, p := range policies {
+		db.FirstOrCreate(&p, entity.RefundPolicy{FromWeek: p.FromWeek})
+	}
+}
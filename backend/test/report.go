@@ -15,9 +15,28 @@ func ReportExampleData() {
 	db := config.DB()
 
 	// ---------- Report Types ----------
+	minCredit, maxCredit := 1.0, 6.0
 	reportTypes := []entity.ReportType{
-		{ReportType_id: "RT01", ReportType_Name: "ลาพักการเรียน", Description: "นักศึกษาขอลาพักการเรียน"},
-		{ReportType_id: "RT02", ReportType_Name: "ขอลงทะเบียนเพิ่ม", Description: "นักศึกษาขอลงทะเบียนเกินเกณฑ์"},
+		{ReportType_id: "RT01", ReportType_Name: "ลาพักการเรียน", Description: "นักศึกษาขอลาพักการเรียน",
+			RequiredAttachments: []string{"หนังสือยินยอมผู้ปกครอง"},
+			FormSchema: []entity.ReportFormField{
+				{Key: "semester", Label: "ภาคการศึกษาที่ขอลาพัก", Type: entity.ReportFieldSemester, Required: true},
+				{Key: "start_date", Label: "วันที่เริ่มลาพัก", Type: entity.ReportFieldDate, Required: true},
+				{Key: "reason", Label: "เหตุผล", Type: entity.ReportFieldText, Required: true},
+			},
+			Actions: []entity.ReportAction{
+				{Type: entity.ReportActionChangeStatus, Status: entity.StatusOnLeave, SemesterField: "semester"},
+			}},
+		{ReportType_id: "RT02", ReportType_Name: "ขอลงทะเบียนเพิ่ม", Description: "นักศึกษาขอลงทะเบียนเกินเกณฑ์",
+			FormSchema: []entity.ReportFormField{
+				{Key: "subject", Label: "รายวิชาที่ขอลงทะเบียน", Type: entity.ReportFieldSubject, Required: true},
+				{Key: "semester", Label: "ภาคการศึกษา", Type: entity.ReportFieldSemester, Required: true},
+				{Key: "extra_credits", Label: "หน่วยกิตที่ขอเกิน", Type: entity.ReportFieldNumber, Min: &minCredit, Max: &maxCredit},
+				{Key: "evidence", Label: "เอกสารประกอบ", Type: entity.ReportFieldFile},
+			},
+			Actions: []entity.ReportAction{
+				{Type: entity.ReportActionAddRegistration, SubjectField: "subject"},
+			}},
 	}
 	for _, rt := range reportTypes {
 		if err := db.FirstOrCreate(&rt, entity.ReportType{ReportType_id: rt.ReportType_id}).Error; err != nil {
@@ -25,6 +44,21 @@ func ReportExampleData() {
 		}
 	}
 
+	// ---------- Workflow ----------
+	// RT01: อาจารย์ที่ปรึกษา -> หัวหน้าสาขา -> งานทะเบียน, RT02: อาจารย์ที่ปรึกษา -> งานทะเบียน
+	steps := []entity.ReportWorkflowStep{
+		{ReportType_id: "RT01", Seq: 1, Name: "อาจารย์ที่ปรึกษา", Role: entity.ReportStepAdvisor},
+		{ReportType_id: "RT01", Seq: 2, Name: "หัวหน้าสาขาวิชา", Role: entity.ReportStepDepartmentHead},
+		{ReportType_id: "RT01", Seq: 3, Name: "งานทะเบียน", Role: entity.ReportStepRegistrar},
+		{ReportType_id: "RT02", Seq: 1, Name: "อาจารย์ที่ปรึกษา", Role: entity.ReportStepAdvisor},
+		{ReportType_id: "RT02", Seq: 2, Name: "งานทะเบียน", Role: entity.ReportStepRegistrar},
+	}
+	for _, st := range steps {
+		if err := db.FirstOrCreate(&st, entity.ReportWorkflowStep{ReportType_id: st.ReportType_id, Seq: st.Seq}).Error; err != nil {
+			log.Printf("error seeding workflow step %s/%d: %v", st.ReportType_id, st.Seq, err)
+		}
+	}
+
     // ---------- Reviewer ----------
     // หา user_id ของ admin และ teacher จากตาราง users แบบไม่เดาเลข id
     type userRow struct{ ID uint; Role string; Username string }
@@ -13,6 +13,9 @@ func StatusExample() {
 		{StatusStudentID: "20", Status: "แจ้งจบการศึกษา"},
 		{StatusStudentID: "30", Status: "สำเร็จการศึกษา"},
 		{StatusStudentID: "40", Status: "ไม่อนุมัติให้สำเร็จการศึกษา"},
+		{StatusStudentID: "50", Status: "ลาพักการศึกษา"},
+		{StatusStudentID: "60", Status: "ถูกพักการศึกษา"},
+		{StatusStudentID: "70", Status: "ลาออก"},
 		{StatusStudentID: "00", Status: "สิ้นสภาพการศึกษา"},
 	}
 
@@ -24,6 +24,7 @@ func StudentExample() {
 			StatusStudentID: "10",
 			CurriculumID:    "curr23",
 			//TeacherID:       "T2900364",
+			AdvisorID:       "T2900364",
 		},
 		{
 			StudentID:       "B6630654",
@@ -39,6 +40,7 @@ func StudentExample() {
 			StatusStudentID: "10",
 			CurriculumID:    "curr23",
 			//TeacherID:       "T2900366",
+			AdvisorID:       "T2900364",
 		},
 	}
 
@@ -50,7 +52,7 @@ func StudentExample() {
 		}
 
 		// สร้างรหัสผ่าน hash
-		hashedPasswordStudent, err := config.HashPassword(student.CitizenID)
+		hashedPasswordStudent, err := config.HashPassword(string(student.CitizenID))
 		if err != nil {
 			log.Println("Failed to hash password:", err)
 			continue